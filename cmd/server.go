@@ -12,9 +12,14 @@ import (
 	// this is needed to call the init function on each plugin
 	_ "github.com/hellofresh/janus/pkg/plugin/basic"
 	_ "github.com/hellofresh/janus/pkg/plugin/bodylmt"
+	_ "github.com/hellofresh/janus/pkg/plugin/cache"
 	_ "github.com/hellofresh/janus/pkg/plugin/cb"
 	_ "github.com/hellofresh/janus/pkg/plugin/compression"
+	_ "github.com/hellofresh/janus/pkg/plugin/concurrency"
+	_ "github.com/hellofresh/janus/pkg/plugin/consumers"
 	_ "github.com/hellofresh/janus/pkg/plugin/cors"
+	_ "github.com/hellofresh/janus/pkg/plugin/keyauth"
+	_ "github.com/hellofresh/janus/pkg/plugin/mirror"
 	_ "github.com/hellofresh/janus/pkg/plugin/oauth2"
 	_ "github.com/hellofresh/janus/pkg/plugin/rate"
 	_ "github.com/hellofresh/janus/pkg/plugin/requesttransformer"
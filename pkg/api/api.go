@@ -74,7 +74,12 @@ func NewDefinition() *Definition {
 
 // Validate validates proxy data
 func (d *Definition) Validate() (bool, error) {
-	return govalidator.ValidateStruct(d)
+	isValid, err := govalidator.ValidateStruct(d)
+	if !isValid || err != nil {
+		return isValid, err
+	}
+
+	return d.Proxy.Validate()
 }
 
 // UnmarshalJSON api.Definition JSON.Unmarshaller implementation
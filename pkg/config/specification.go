@@ -80,7 +80,8 @@ type Stats struct {
 // used by admin JWT configuration
 type Credentials struct {
 	// Algorithm defines admin JWT signing algorithm.
-	// Currently the following algorithms are supported: HS256, HS384, HS512.
+	// The HS256, HS384, HS512, RS*, PS*, ES* and EdDSA algorithms are supported, the secret of the asymmetric
+	// algorithms is the PEM-encoded private key.
 	Algorithm      string        `envconfig:"ALGORITHM"`
 	Secret         string        `envconfig:"SECRET"`
 	JanusAdminTeam string        `envconfig:"JANUS_ADMIN_TEAM"`
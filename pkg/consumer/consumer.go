@@ -0,0 +1,53 @@
+// Package consumer models who is calling the APIs. A consumer owns the credentials of the auth plugins, carries
+// groups and tags and can override the configuration of the plugins for its own requests.
+package consumer
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/errors"
+)
+
+var (
+	// ErrConsumerNotFound is used when a consumer is not found
+	ErrConsumerNotFound = errors.New(http.StatusNotFound, "consumer not found")
+	// ErrConsumerExists is used when a consumer already exists
+	ErrConsumerExists = errors.New(http.StatusConflict, "consumer already exists")
+	// ErrInvalidID is used when a consumer has no id
+	ErrInvalidID = errors.New(http.StatusBadRequest, "please provide a valid consumer id")
+	// ErrInvalidMongoDBSession is used when mongodb is not being used
+	ErrInvalidMongoDBSession = errors.New(http.StatusNotFound, "invalid mongodb session given")
+)
+
+// Consumer represents a client of the APIs
+type Consumer struct {
+	// ID identifies the consumer, it is what the credentials of the auth plugins refer to
+	ID     string   `json:"id" bson:"id"`
+	Groups []string `json:"groups,omitempty" bson:"groups,omitempty"`
+	Tags   []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	// Plugins holds configuration overrides by plugin name, they are merged over the API configuration of the
+	// plugin for the requests of the consumer
+	Plugins   map[string]map[string]interface{} `json:"plugins,omitempty" bson:"plugins,omitempty"`
+	CreatedAt time.Time                         `json:"created_at" bson:"created_at"`
+}
+
+// InGroup tells if the consumer belongs to the group
+func (c *Consumer) InGroup(group string) bool {
+	return contains(c.Groups, group)
+}
+
+// HasTag tells if the consumer has the tag
+func (c *Consumer) HasTag(tag string) bool {
+	return contains(c.Tags, tag)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,90 @@
+package consumer
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type contextKey string
+
+const (
+	consumerKey = contextKey("consumer")
+	holderKey   = contextKey("consumer_holder")
+)
+
+var (
+	defaultRepoMu sync.RWMutex
+	defaultRepo   Repository
+)
+
+// holder lets the middleware that runs before the authentication, like the request logger, see the consumer
+// resolved later in the chain
+type holder struct {
+	sync.Mutex
+	consumer *Consumer
+}
+
+// NewContext returns a context the consumer resolved down the middleware chain can be read back from
+func NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, holderKey, &holder{})
+}
+
+// WithConsumer returns a context holding the consumer
+func WithConsumer(ctx context.Context, c *Consumer) context.Context {
+	if h, ok := ctx.Value(holderKey).(*holder); ok {
+		h.Lock()
+		h.consumer = c
+		h.Unlock()
+	}
+
+	return context.WithValue(ctx, consumerKey, c)
+}
+
+// FromContext returns the consumer of the request
+func FromContext(ctx context.Context) (*Consumer, bool) {
+	if c, ok := ctx.Value(consumerKey).(*Consumer); ok {
+		return c, true
+	}
+
+	if h, ok := ctx.Value(holderKey).(*holder); ok {
+		h.Lock()
+		defer h.Unlock()
+		return h.consumer, h.consumer != nil
+	}
+
+	return nil, false
+}
+
+// SetRepository sets the repository the authenticated consumers are looked up in
+func SetRepository(repo Repository) {
+	defaultRepoMu.Lock()
+	defer defaultRepoMu.Unlock()
+
+	defaultRepo = repo
+}
+
+// Resolve returns the request carrying the consumer with the given id, as authenticated by an auth plugin. A
+// consumer missing from the repository is still set with its id only, so the credentials created before the
+// consumers keep working.
+func Resolve(r *http.Request, id string) *http.Request {
+	c := &Consumer{ID: id}
+
+	defaultRepoMu.RLock()
+	repo := defaultRepo
+	defaultRepoMu.RUnlock()
+
+	if repo != nil {
+		found, err := repo.FindByID(id)
+		switch {
+		case err == nil:
+			c = found
+		case err != ErrConsumerNotFound:
+			log.WithError(err).WithField("consumer", id).Warn("Could not look up the consumer")
+		}
+	}
+
+	return r.WithContext(WithConsumer(r.Context(), c))
+}
@@ -0,0 +1,46 @@
+package consumer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	_, ok := FromContext(ctx)
+	assert.False(t, ok)
+
+	outer := NewContext(ctx)
+	inner := WithConsumer(outer, &Consumer{ID: "mobile-app"})
+
+	c, ok := FromContext(inner)
+	require.True(t, ok)
+	assert.Equal(t, "mobile-app", c.ID)
+
+	c, ok = FromContext(outer)
+	require.True(t, ok, "the consumer resolved down the chain is seen by the outer middleware")
+	assert.Equal(t, "mobile-app", c.ID)
+}
+
+func TestResolve(t *testing.T) {
+	repo := NewInMemoryRepository()
+	require.NoError(t, repo.Add(&Consumer{ID: "mobile-app", Groups: []string{"partners"}}))
+	SetRepository(repo)
+	defer SetRepository(nil)
+
+	r := Resolve(httptest.NewRequest(http.MethodGet, "/", nil), "mobile-app")
+	c, ok := FromContext(r.Context())
+	require.True(t, ok)
+	assert.True(t, c.InGroup("partners"))
+
+	r = Resolve(httptest.NewRequest(http.MethodGet, "/", nil), "unknown")
+	c, ok = FromContext(r.Context())
+	require.True(t, ok, "the consumers missing from the repository are set by id")
+	assert.Equal(t, "unknown", c.ID)
+	assert.Empty(t, c.Groups)
+}
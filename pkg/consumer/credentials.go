@@ -0,0 +1,44 @@
+package consumer
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = make(map[string]CredentialSource)
+)
+
+// CredentialSource is implemented by the auth plugins storing credentials of the consumers
+type CredentialSource interface {
+	// FindByConsumer returns the credentials of the consumer as they are shown on the admin API, without secrets
+	FindByConsumer(id string) (interface{}, error)
+	// RemoveByConsumer removes all the credentials of the consumer
+	RemoveByConsumer(id string) error
+}
+
+// RegisterCredentialSource registers the credentials of an auth plugin, so they are listed and removed together
+// with their consumer
+func RegisterCredentialSource(name string, source CredentialSource) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+
+	sources[name] = source
+}
+
+// credentialSources returns the registered sources by name, sorted by name
+func credentialSources() ([]string, map[string]CredentialSource) {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+
+	names := make([]string, 0, len(sources))
+	copied := make(map[string]CredentialSource, len(sources))
+	for name, source := range sources {
+		names = append(names, name)
+		copied[name] = source
+	}
+	sort.Strings(names)
+
+	return names, copied
+}
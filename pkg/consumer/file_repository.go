@@ -0,0 +1,54 @@
+package consumer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// FileSystemRepository represents a consumer repository loaded from the JSON files of a directory, one consumer
+// per file. The consumers managed on the admin API are kept in memory only.
+type FileSystemRepository struct {
+	*InMemoryRepository
+}
+
+// NewFileSystemRepository creates a file based consumer repository
+func NewFileSystemRepository(dir string) (*FileSystemRepository, error) {
+	repo := &FileSystemRepository{InMemoryRepository: NewInMemoryRepository()}
+
+	files, err := ioutil.ReadDir(dir)
+	if nil != err {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+
+		filePath := filepath.Join(dir, f.Name())
+		raw, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			log.WithError(err).WithField("path", filePath).Error("Couldn't load the consumer file")
+			return nil, err
+		}
+
+		c := new(Consumer)
+		if err := json.Unmarshal(raw, c); err != nil {
+			return nil, errors.Wrapf(err, "could not parse the consumer file %s", filePath)
+		}
+
+		if c.ID == "" {
+			return nil, errors.Wrapf(ErrInvalidID, "could not load the consumer file %s", filePath)
+		}
+
+		if err := repo.Add(c); err != nil {
+			return nil, errors.Wrapf(err, "could not add the consumer %s", c.ID)
+		}
+	}
+
+	return repo, nil
+}
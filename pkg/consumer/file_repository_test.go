@@ -0,0 +1,32 @@
+package consumer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFileSystemRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "consumers")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	content := `{"id": "mobile-app", "groups": ["partners"], "plugins": {"cors": {"domains": ["https://app.example.com"]}}}`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "mobile-app.json"), []byte(content), 0600))
+
+	repo, err := NewFileSystemRepository(dir)
+	require.NoError(t, err)
+
+	c, err := repo.FindByID("mobile-app")
+	require.NoError(t, err)
+	assert.True(t, c.InGroup("partners"))
+	assert.Contains(t, c.Plugins, "cors")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "invalid.json"), []byte(`{"groups": []}`), 0600))
+	_, err = NewFileSystemRepository(dir)
+	assert.Error(t, err)
+}
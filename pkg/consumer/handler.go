@@ -0,0 +1,185 @@
+package consumer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/render"
+	"github.com/hellofresh/janus/pkg/router"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+)
+
+// Handler is the api rest handlers
+type Handler struct {
+	repo Repository
+}
+
+// NewHandler creates a new instance of Handler
+func NewHandler(repo Repository) *Handler {
+	return &Handler{repo}
+}
+
+// Index is the find all handler, the consumers can be filtered by group and tag
+func (h *Handler) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := trace.StartSpan(r.Context(), "repo.FindAll")
+		consumers, err := h.repo.FindAll()
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		group := r.URL.Query().Get("group")
+		tag := r.URL.Query().Get("tag")
+
+		data := make([]*Consumer, 0, len(consumers))
+		for _, c := range consumers {
+			if (group == "" || c.InGroup(group)) && (tag == "" || c.HasTag(tag)) {
+				data = append(data, c)
+			}
+		}
+
+		render.JSON(w, http.StatusOK, data)
+	}
+}
+
+// Show is the find by handler
+func (h *Handler) Show() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := trace.StartSpan(r.Context(), "repo.FindByID")
+		c, err := h.repo.FindByID(router.URLParam(r, "id"))
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, c)
+	}
+}
+
+// Create is the create handler
+func (h *Handler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := new(Consumer)
+		if err := json.NewDecoder(r.Body).Decode(c); err != nil {
+			errors.Handler(w, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		if c.ID == "" {
+			errors.Handler(w, ErrInvalidID)
+			return
+		}
+		c.CreatedAt = time.Now().UTC()
+
+		_, span := trace.StartSpan(r.Context(), "repo.Add")
+		err := h.repo.Add(c)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		w.Header().Add("Location", fmt.Sprintf("/consumers/%s", c.ID))
+		render.JSON(w, http.StatusCreated, c)
+	}
+}
+
+// Update is the update handler, it replaces the groups, tags and plugin overrides of the consumer
+func (h *Handler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := trace.StartSpan(r.Context(), "repo.FindByID")
+		existing, err := h.repo.FindByID(router.URLParam(r, "id"))
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		c := new(Consumer)
+		if err := json.NewDecoder(r.Body).Decode(c); err != nil {
+			errors.Handler(w, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+		c.ID = existing.ID
+		c.CreatedAt = existing.CreatedAt
+
+		_, span = trace.StartSpan(r.Context(), "repo.Save")
+		err = h.repo.Save(c)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, c)
+	}
+}
+
+// Delete is the delete handler, the credentials of the consumer are removed too
+func (h *Handler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := router.URLParam(r, "id")
+
+		_, span := trace.StartSpan(r.Context(), "repo.Remove")
+		err := h.repo.Remove(id)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		names, sources := credentialSources()
+		for _, name := range names {
+			if err := sources[name].RemoveByConsumer(id); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"consumer":    id,
+					"credentials": name,
+				}).Error("Could not remove the credentials of the consumer")
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Credentials is the handler listing the credentials of the consumer, by auth plugin
+func (h *Handler) Credentials() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := router.URLParam(r, "id")
+
+		_, span := trace.StartSpan(r.Context(), "repo.FindByID")
+		_, err := h.repo.FindByID(id)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		names, sources := credentialSources()
+		data := make(map[string]interface{}, len(names))
+		for _, name := range names {
+			credentials, err := sources[name].FindByConsumer(id)
+			if err != nil {
+				errors.Handler(w, err)
+				return
+			}
+			data[name] = credentials
+		}
+
+		render.JSON(w, http.StatusOK, data)
+	}
+}
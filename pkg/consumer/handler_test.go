@@ -0,0 +1,93 @@
+package consumer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hellofresh/janus/pkg/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource struct {
+	credentials map[string][]string
+}
+
+func (s *fakeSource) FindByConsumer(id string) (interface{}, error) {
+	return s.credentials[id], nil
+}
+
+func (s *fakeSource) RemoveByConsumer(id string) error {
+	delete(s.credentials, id)
+	return nil
+}
+
+func newTestRouter(repo Repository) router.Router {
+	handlers := NewHandler(repo)
+
+	r := router.NewChiRouter()
+	r.GET("/consumers/", handlers.Index())
+	r.POST("/consumers/", handlers.Create())
+	r.GET("/consumers/{id}", handlers.Show())
+	r.PUT("/consumers/{id}", handlers.Update())
+	r.DELETE("/consumers/{id}", handlers.Delete())
+	r.GET("/consumers/{id}/credentials", handlers.Credentials())
+
+	return r
+}
+
+func doRequest(r router.Router, method, url, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	source := &fakeSource{credentials: map[string][]string{"mobile-app": {"key-1"}}}
+	RegisterCredentialSource("fake", source)
+	defer RegisterCredentialSource("fake", &fakeSource{})
+
+	repo := NewInMemoryRepository()
+	r := newTestRouter(repo)
+
+	w := doRequest(r, http.MethodPost, "/consumers/", `{"id": "mobile-app", "groups": ["partners"], "plugins": {"rate_limit": {"limit": "100-M"}}}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/consumers/mobile-app", w.Header().Get("Location"))
+
+	w = doRequest(r, http.MethodPost, "/consumers/", `{"id": "mobile-app"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doRequest(r, http.MethodPost, "/consumers/", `{"tags": ["internal"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	require.Equal(t, http.StatusCreated, doRequest(r, http.MethodPost, "/consumers/", `{"id": "web-app", "tags": ["internal"]}`).Code)
+
+	w = doRequest(r, http.MethodGet, "/consumers/?group=partners", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var consumers []*Consumer
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &consumers))
+	require.Len(t, consumers, 1)
+	assert.Equal(t, "100-M", consumers[0].Plugins["rate_limit"]["limit"])
+
+	w = doRequest(r, http.MethodPut, "/consumers/web-app", `{"id": "ignored", "groups": ["partners"]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	c, err := repo.FindByID("web-app")
+	require.NoError(t, err)
+	assert.True(t, c.InGroup("partners"))
+	assert.False(t, c.HasTag("internal"))
+
+	w = doRequest(r, http.MethodGet, "/consumers/mobile-app/credentials", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"fake": ["key-1"]}`, w.Body.String())
+
+	w = doRequest(r, http.MethodDelete, "/consumers/mobile-app", "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, source.credentials, "the credentials are removed with their consumer")
+
+	w = doRequest(r, http.MethodGet, "/consumers/mobile-app", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,82 @@
+package consumer
+
+import (
+	"sort"
+	"sync"
+)
+
+// InMemoryRepository represents a in memory repository
+type InMemoryRepository struct {
+	sync.RWMutex
+	consumers map[string]*Consumer
+}
+
+// NewInMemoryRepository creates a in memory repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{consumers: make(map[string]*Consumer)}
+}
+
+// FindAll fetches all the consumers available
+func (r *InMemoryRepository) FindAll() ([]*Consumer, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	consumers := make([]*Consumer, 0, len(r.consumers))
+	for _, c := range r.consumers {
+		consumers = append(consumers, c)
+	}
+
+	sort.Slice(consumers, func(i, j int) bool {
+		return consumers[i].ID < consumers[j].ID
+	})
+
+	return consumers, nil
+}
+
+// FindByID finds a consumer by id
+func (r *InMemoryRepository) FindByID(id string) (*Consumer, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	c, ok := r.consumers[id]
+	if !ok {
+		return nil, ErrConsumerNotFound
+	}
+
+	return c, nil
+}
+
+// Add adds a new consumer to the repository
+func (r *InMemoryRepository) Add(c *Consumer) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.consumers[c.ID]; ok {
+		return ErrConsumerExists
+	}
+
+	r.consumers[c.ID] = c
+	return nil
+}
+
+// Save saves a consumer to the repository
+func (r *InMemoryRepository) Save(c *Consumer) error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.consumers[c.ID] = c
+	return nil
+}
+
+// Remove removes a consumer from the repository
+func (r *InMemoryRepository) Remove(id string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.consumers[id]; !ok {
+		return ErrConsumerNotFound
+	}
+
+	delete(r.consumers, id)
+	return nil
+}
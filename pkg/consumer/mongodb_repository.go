@@ -0,0 +1,121 @@
+package consumer
+
+import (
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	collectionName string = "consumers"
+)
+
+// MongoRepository represents a mongodb repository
+type MongoRepository struct {
+	session *mgo.Session
+}
+
+// NewMongoRepository creates a mongo consumer repository
+func NewMongoRepository(session *mgo.Session) (*MongoRepository, error) {
+	if session == nil {
+		return nil, ErrInvalidMongoDBSession
+	}
+
+	return &MongoRepository{session}, nil
+}
+
+// EnsureIndexes creates the unique index on the consumer id
+func (r *MongoRepository) EnsureIndexes() error {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	return coll.EnsureIndex(mgo.Index{
+		Key:        []string{"id"},
+		Unique:     true,
+		Background: true,
+	})
+}
+
+// FindAll fetches all the consumers available
+func (r *MongoRepository) FindAll() ([]*Consumer, error) {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	result := []*Consumer{}
+	if err := coll.Find(nil).Sort("id").All(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindByID finds a consumer by id
+func (r *MongoRepository) FindByID(id string) (*Consumer, error) {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	var result Consumer
+	if err := coll.Find(bson.M{"id": id}).One(&result); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, ErrConsumerNotFound
+		}
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Add adds a new consumer to the repository
+func (r *MongoRepository) Add(c *Consumer) error {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	if err := coll.Insert(c); err != nil {
+		if mgo.IsDup(err) {
+			return ErrConsumerExists
+		}
+		log.WithError(err).WithField("id", c.ID).Error("There was an error adding the consumer")
+		return err
+	}
+
+	log.WithField("id", c.ID).Debug("Consumer added")
+	return nil
+}
+
+// Save saves a consumer to the repository
+func (r *MongoRepository) Save(c *Consumer) error {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	if _, err := coll.Upsert(bson.M{"id": c.ID}, c); err != nil {
+		log.WithError(err).WithField("id", c.ID).Error("There was an error saving the consumer")
+		return err
+	}
+
+	log.WithField("id", c.ID).Debug("Consumer saved")
+	return nil
+}
+
+// Remove removes a consumer from the repository
+func (r *MongoRepository) Remove(id string) error {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	if err := coll.Remove(bson.M{"id": id}); err != nil {
+		if err == mgo.ErrNotFound {
+			return ErrConsumerNotFound
+		}
+		log.WithError(err).WithField("id", id).Error("There was an error removing the consumer")
+		return err
+	}
+
+	log.WithField("id", id).Debug("Consumer removed")
+	return nil
+}
+
+func (r *MongoRepository) getSession() (*mgo.Session, *mgo.Collection) {
+	session := r.session.Copy()
+	coll := session.DB("").C(collectionName)
+
+	return session, coll
+}
@@ -0,0 +1,101 @@
+package consumer
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Builder builds the middleware of a plugin from its configuration
+type Builder func(config map[string]interface{}) (func(http.Handler) http.Handler, error)
+
+// NewOverrideMiddleware builds the middleware of a plugin with its API configuration and, for the requests of the
+// consumers overriding the plugin, with the consumer configuration merged over the API one. The middleware of
+// every consumer is built on its first request and rebuilt when the consumer overrides change. The consumer must
+// be resolved by an auth plugin that runs before.
+func NewOverrideMiddleware(pluginName string, config map[string]interface{}, build Builder) (func(http.Handler) http.Handler, error) {
+	mw, err := build(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &overridable{
+			pluginName: pluginName,
+			config:     config,
+			build:      build,
+			next:       next,
+			fallback:   mw(next),
+			handlers:   make(map[string]*override),
+		}
+	}, nil
+}
+
+type overridable struct {
+	sync.RWMutex
+	pluginName string
+	config     map[string]interface{}
+	build      Builder
+	next       http.Handler
+	fallback   http.Handler
+	handlers   map[string]*override
+}
+
+type override struct {
+	config  map[string]interface{}
+	handler http.Handler
+}
+
+func (o *overridable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c, ok := FromContext(r.Context())
+	if !ok || c.Plugins[o.pluginName] == nil {
+		o.fallback.ServeHTTP(w, r)
+		return
+	}
+
+	o.handler(c).ServeHTTP(w, r)
+}
+
+func (o *overridable) handler(c *Consumer) http.Handler {
+	config := c.Plugins[o.pluginName]
+
+	o.RLock()
+	h, ok := o.handlers[c.ID]
+	o.RUnlock()
+
+	if ok && reflect.DeepEqual(h.config, config) {
+		return h.handler
+	}
+
+	h = &override{config: config, handler: o.fallback}
+	mw, err := o.build(Merge(o.config, config))
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"plugin":   o.pluginName,
+			"consumer": c.ID,
+		}).Error("Invalid consumer override, using the API configuration of the plugin")
+	} else {
+		h.handler = mw(o.next)
+	}
+
+	o.Lock()
+	o.handlers[c.ID] = h
+	o.Unlock()
+
+	return h.handler
+}
+
+// Merge returns the configuration with the top level keys of the override replacing the ones of the base
+func Merge(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+
+	return merged
+}
@@ -0,0 +1,73 @@
+package consumer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func headerBuilder(builds *int) Builder {
+	return func(config map[string]interface{}) (func(http.Handler) http.Handler, error) {
+		value, ok := config["value"].(string)
+		if !ok {
+			return nil, errors.New("value is required")
+		}
+		*builds++
+
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Value", value)
+				next.ServeHTTP(w, r)
+			})
+		}, nil
+	}
+}
+
+func TestOverrideMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var builds int
+	mw, err := NewOverrideMiddleware("example", map[string]interface{}{"value": "api"}, headerBuilder(&builds))
+	require.NoError(t, err)
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(c *Consumer) string {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if c != nil {
+			r = r.WithContext(WithConsumer(r.Context(), c))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w.Header().Get("X-Value")
+	}
+
+	assert.Equal(t, "api", serve(nil))
+	assert.Equal(t, "api", serve(&Consumer{ID: "plain"}))
+
+	partner := &Consumer{ID: "partner", Plugins: map[string]map[string]interface{}{"example": {"value": "partner"}}}
+	assert.Equal(t, "partner", serve(partner))
+	assert.Equal(t, "partner", serve(partner))
+	assert.Equal(t, 2, builds, "the consumer middleware is built once")
+
+	partner = &Consumer{ID: "partner", Plugins: map[string]map[string]interface{}{"example": {"value": "changed"}}}
+	assert.Equal(t, "changed", serve(partner), "the consumer middleware is rebuilt when the override changes")
+
+	broken := &Consumer{ID: "broken", Plugins: map[string]map[string]interface{}{"example": {"value": 1}}}
+	assert.Equal(t, "api", serve(broken), "an invalid override falls back to the API configuration")
+}
+
+func TestOverrideMiddlewareInvalidConfig(t *testing.T) {
+	var builds int
+	_, err := NewOverrideMiddleware("example", map[string]interface{}{}, headerBuilder(&builds))
+	assert.Error(t, err)
+}
+
+func TestMerge(t *testing.T) {
+	merged := Merge(map[string]interface{}{"a": 1, "b": 2}, map[string]interface{}{"b": 3, "c": 4})
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 3, "c": 4}, merged)
+}
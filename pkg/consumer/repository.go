@@ -0,0 +1,48 @@
+package consumer
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/globalsign/mgo"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	mongodb = "mongodb"
+	file    = "file"
+)
+
+// Repository represents a consumer repository
+type Repository interface {
+	FindAll() ([]*Consumer, error)
+	FindByID(id string) (*Consumer, error)
+	Add(c *Consumer) error
+	Save(c *Consumer) error
+	Remove(id string) error
+}
+
+// BuildRepository creates the consumer repository for the given database DSN
+func BuildRepository(dsn string, session *mgo.Session) (Repository, error) {
+	dsnURL, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing the DSN")
+	}
+
+	switch dsnURL.Scheme {
+	case mongodb:
+		return NewMongoRepository(session)
+	case file:
+		consumersPath := fmt.Sprintf("%s/consumers", dsnURL.Path)
+		log.WithField("path", consumersPath).Debug("Trying to load consumer files")
+
+		repo, err := NewFileSystemRepository(consumersPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not create a file based repository for the consumers")
+		}
+		return repo, nil
+	default:
+		return nil, errors.New("The selected scheme is not supported to load consumers")
+	}
+}
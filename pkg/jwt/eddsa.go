@@ -0,0 +1,58 @@
+package jwt
+
+import (
+	"errors"
+
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/crypto/ed25519"
+)
+
+// ErrEdDSAVerification is the error returned when an EdDSA signature is invalid
+var ErrEdDSAVerification = errors.New("EdDSA verification failed")
+
+// SigningMethodEdDSA implements the EdDSA signing method with Ed25519 keys. It expects ed25519.PrivateKey for
+// signing and ed25519.PublicKey for verification.
+type SigningMethodEdDSA struct{}
+
+// SigningMethodEd25519 is the EdDSA signing method instance, registered as "EdDSA"
+var SigningMethodEd25519 = &SigningMethodEdDSA{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEd25519.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEd25519
+	})
+}
+
+// Alg returns the name of the signing method
+func (m *SigningMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Verify checks the signature of the signing string with an ed25519.PublicKey
+func (m *SigningMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return ErrEdDSAVerification
+	}
+
+	return nil
+}
+
+// Sign signs the signing string with an ed25519.PrivateKey
+func (m *SigningMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
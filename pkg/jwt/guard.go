@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/hellofresh/janus/pkg/config"
+	log "github.com/sirupsen/logrus"
 )
 
 // Guard struct
@@ -23,11 +24,17 @@ type Guard struct {
 	MaxRefresh time.Duration
 }
 
-// NewGuard creates a new instance of Guard with default handlers
+// NewGuard creates a new instance of Guard with default handlers. With an asymmetric algorithm the secret is the
+// private key the tokens are signed with, they are verified with its public key.
 func NewGuard(cred config.Credentials) Guard {
+	verificationMethod, err := PublicSigningMethod(SigningMethod{Alg: cred.Algorithm, Key: cred.Secret})
+	if err != nil {
+		log.WithError(err).WithField("alg", cred.Algorithm).Error("Invalid admin JWT signing key")
+	}
+
 	return Guard{
 		ParserConfig: ParserConfig{
-			SigningMethods: []SigningMethod{{Alg: cred.Algorithm, Key: cred.Secret}},
+			SigningMethods: []SigningMethod{verificationMethod},
 			TokenLookup:    "header:Authorization",
 		},
 		SigningMethod: SigningMethod{Alg: cred.Algorithm, Key: cred.Secret},
@@ -0,0 +1,325 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	defaultJWKSRefreshInterval    = time.Hour
+	defaultJWKSMinRefreshInterval = time.Minute
+	defaultJWKSTimeout            = 10 * time.Second
+)
+
+var (
+	// ErrUnknownKey is the error returned when the key set has no key with the token kid
+	ErrUnknownKey = errors.New("unknown JWKS key")
+	// ErrKeyAlgMismatch is the error returned when the token is signed with an algorithm the key is not meant for
+	ErrKeyAlgMismatch = errors.New("the token algorithm does not match the JWKS key")
+)
+
+// JWK is a JSON web key, as served by the JWKS URL of an identity provider
+type JWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKSOption is the key set functional option type
+type JWKSOption func(*JWKS)
+
+// WithRefreshInterval sets how long the keys are used before they are fetched again, in the background
+func WithRefreshInterval(d time.Duration) JWKSOption {
+	return func(s *JWKS) {
+		s.refreshInterval = d
+	}
+}
+
+// WithMinRefreshInterval sets the minimum time between two fetches, it limits the fetches for unknown kids
+func WithMinRefreshInterval(d time.Duration) JWKSOption {
+	return func(s *JWKS) {
+		s.minRefreshInterval = d
+	}
+}
+
+// WithHTTPClient sets the client the keys are fetched with
+func WithHTTPClient(client *http.Client) JWKSOption {
+	return func(s *JWKS) {
+		s.client = client
+	}
+}
+
+// JWKS is a key set fetched from a JWKS URL. The keys are selected by the kid of the token, refreshed in the
+// background once the refresh interval elapsed and fetched again when a token has an unknown kid, at most once
+// per minimum refresh interval. The cached keys are kept when the URL can not be fetched.
+type JWKS struct {
+	url                string
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	client             *http.Client
+	now                func() time.Time
+
+	fetchMu sync.Mutex
+
+	mu          sync.RWMutex
+	keys        map[string]*jwkKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	refreshing  bool
+}
+
+type jwkKey struct {
+	alg string
+	key interface{}
+}
+
+// NewJWKS creates a new instance of JWKS
+func NewJWKS(url string, opts ...JWKSOption) *JWKS {
+	s := &JWKS{
+		url:                url,
+		refreshInterval:    defaultJWKSRefreshInterval,
+		minRefreshInterval: defaultJWKSMinRefreshInterval,
+		client:             &http.Client{Timeout: defaultJWKSTimeout},
+		now:                time.Now,
+		keys:               make(map[string]*jwkKey),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// URL returns the JWKS URL the keys are fetched from
+func (s *JWKS) URL() string {
+	return s.url
+}
+
+// Key returns the verification key with the given kid for a token signed with alg. When the key set is empty, or
+// the kid is unknown, the keys are fetched right away, if they were not fetched within the minimum refresh interval.
+func (s *JWKS) Key(kid, alg string) (interface{}, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	stale := !s.fetchedAt.IsZero() && s.now().Sub(s.fetchedAt) >= s.refreshInterval
+	s.mu.RUnlock()
+
+	if !ok {
+		if err := s.refreshIfDue(); err != nil {
+			log.WithError(err).WithField("url", s.url).Warn("Could not fetch the JWKS keys, using the cached ones")
+		}
+
+		s.mu.RLock()
+		key, ok = s.keys[kid]
+		s.mu.RUnlock()
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+	} else if stale {
+		s.refreshInBackground()
+	}
+
+	if key.alg != "" && key.alg != alg {
+		return nil, ErrKeyAlgMismatch
+	}
+
+	return key.key, nil
+}
+
+// Refresh fetches the keys from the JWKS URL. The cached keys are kept when the keys can not be fetched.
+func (s *JWKS) Refresh() error {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	return s.refresh()
+}
+
+// refreshIfDue fetches the keys unless they were fetched, or tried to, within the minimum refresh interval
+func (s *JWKS) refreshIfDue() error {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	s.mu.RLock()
+	due := s.attemptedAt.IsZero() || s.now().Sub(s.attemptedAt) >= s.minRefreshInterval
+	s.mu.RUnlock()
+
+	if !due {
+		return nil
+	}
+
+	return s.refresh()
+}
+
+func (s *JWKS) refresh() error {
+	s.mu.Lock()
+	s.attemptedAt = s.now()
+	s.mu.Unlock()
+
+	keys, err := s.fetch()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = s.now()
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *JWKS) refreshInBackground() {
+	s.mu.Lock()
+	if s.refreshing {
+		s.mu.Unlock()
+		return
+	}
+	s.refreshing = true
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			s.refreshing = false
+			s.mu.Unlock()
+		}()
+
+		if err := s.refreshIfDue(); err != nil {
+			log.WithError(err).WithField("url", s.url).Warn("Could not refresh the JWKS keys, using the cached ones")
+		}
+	}()
+}
+
+func (s *JWKS) fetch() (map[string]*jwkKey, error) {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected JWKS response status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []JWK `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*jwkKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.PublicKey()
+		if err != nil {
+			log.WithError(err).WithField("kid", jwk.Kid).Debug("Skipping JWKS key")
+			continue
+		}
+
+		keys[jwk.Kid] = &jwkKey{alg: jwk.Alg, key: key}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("the JWKS has no usable signing key")
+	}
+
+	return keys, nil
+}
+
+// PublicKey returns the public key of the JWK
+func (k JWK) PublicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("invalid RSA exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported JWK curve %q", k.Crv)
+		}
+
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC point")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported JWK curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.X, "="))
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported JWK key type %q", k.Kty)
+	}
+}
+
+func decodeJWKInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, errors.New("missing JWK key parameter")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(raw), nil
+}
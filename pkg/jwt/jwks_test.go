@@ -0,0 +1,218 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	baseJWT "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []JWK
+	down    bool
+	fetches int32
+}
+
+func newJWKSServer(keys ...JWK) *jwksServer {
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.fetches, 1)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+
+	return s
+}
+
+func (s *jwksServer) set(down bool, keys ...JWK) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.down = down
+	s.keys = keys
+}
+
+func (s *jwksServer) fetchCount() int {
+	return int(atomic.LoadInt32(&s.fetches))
+}
+
+func newRSAKey(t *testing.T, kid string) (*rsa.PrivateKey, JWK) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	return key, JWK{
+		Kid: kid,
+		Kty: "RSA",
+		Alg: "RS256",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func signWithKid(t *testing.T, alg, kid string, key interface{}) string {
+	token := baseJWT.NewWithClaims(baseJWT.GetSigningMethod(alg), baseJWT.MapClaims{
+		"iss":      clientID,
+		"username": userName,
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func TestJWKS_Key(t *testing.T) {
+	key1, jwk1 := newRSAKey(t, "2018-05")
+	_, jwk2 := newRSAKey(t, "2018-06")
+
+	server := newJWKSServer(jwk1)
+	defer server.Close()
+
+	now := time.Now()
+	jwks := NewJWKS(server.URL, WithMinRefreshInterval(time.Minute))
+	jwks.now = func() time.Time { return now }
+
+	key, err := jwks.Key("2018-05", "RS256")
+	require.NoError(t, err)
+	assert.Equal(t, key1.N, key.(*rsa.PublicKey).N)
+	assert.Equal(t, 1, server.fetchCount())
+
+	_, err = jwks.Key("2018-05", "RS512")
+	assert.Equal(t, ErrKeyAlgMismatch, err)
+
+	// the key of the monthly rotation is unknown, the keys are fetched again once per minimum interval
+	server.set(false, jwk1, jwk2)
+	now = now.Add(10 * time.Second)
+	_, err = jwks.Key("2018-06", "RS256")
+	assert.Equal(t, ErrUnknownKey, err)
+	_, err = jwks.Key("unknown", "RS256")
+	assert.Equal(t, ErrUnknownKey, err)
+	assert.Equal(t, 1, server.fetchCount())
+
+	now = now.Add(time.Minute)
+	_, err = jwks.Key("2018-06", "RS256")
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.fetchCount())
+}
+
+func TestJWKS_KeyProviderDown(t *testing.T) {
+	_, jwk := newRSAKey(t, "2018-05")
+
+	server := newJWKSServer(jwk)
+	defer server.Close()
+
+	now := time.Now()
+	jwks := NewJWKS(server.URL, WithRefreshInterval(time.Hour), WithMinRefreshInterval(time.Minute))
+	jwks.now = func() time.Time { return now }
+	require.NoError(t, jwks.Refresh())
+
+	server.set(true)
+	assert.Error(t, jwks.Refresh())
+
+	now = now.Add(2 * time.Hour)
+	_, err := jwks.Key("2018-05", "RS256")
+	assert.NoError(t, err, "the cached keys are used when the provider is down")
+
+	now = now.Add(2 * time.Minute)
+	_, err = jwks.Key("unknown", "RS256")
+	assert.Equal(t, ErrUnknownKey, err)
+	_, err = jwks.Key("2018-05", "RS256")
+	assert.NoError(t, err, "the cached keys are kept when the fetch failed")
+}
+
+func TestJWKS_KeyRefreshedInBackground(t *testing.T) {
+	_, jwk1 := newRSAKey(t, "2018-05")
+	_, jwk2 := newRSAKey(t, "2018-06")
+
+	server := newJWKSServer(jwk1)
+	defer server.Close()
+
+	var mu sync.Mutex
+	now := time.Now()
+	jwks := NewJWKS(server.URL, WithRefreshInterval(time.Hour), WithMinRefreshInterval(time.Minute))
+	jwks.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	require.NoError(t, jwks.Refresh())
+
+	server.set(false, jwk2)
+	mu.Lock()
+	now = now.Add(2 * time.Hour)
+	mu.Unlock()
+
+	_, err := jwks.Key("2018-05", "RS256")
+	require.NoError(t, err, "the stale key is used while the keys are refreshed")
+
+	for i := 0; i < 100 && server.fetchCount() < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 0; i < 100; i++ {
+		if _, err = jwks.Key("2018-06", "RS256"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err)
+}
+
+func TestParser_Parse_JWKS(t *testing.T) {
+	key, jwk := newRSAKey(t, "2018-05")
+	otherKey, _ := newRSAKey(t, "2018-05")
+
+	server := newJWKSServer(jwk)
+	defer server.Close()
+
+	config := NewParserConfig(0, SigningMethod{Alg: "HS256", Key: "static-secret"})
+	config.JWKS = NewJWKS(server.URL)
+	parser := NewParser(config)
+
+	tests := []struct {
+		scenario string
+		token    string
+		valid    bool
+	}{
+		{scenario: "token signed with the JWKS key", token: signWithKid(t, "RS256", "2018-05", key), valid: true},
+		{scenario: "token signed with another key", token: signWithKid(t, "RS256", "2018-05", otherKey), valid: false},
+		{scenario: "token with an unknown kid", token: signWithKid(t, "RS256", "unknown", key), valid: false},
+		{scenario: "token signed with a static method", token: signWithKid(t, "HS256", "", []byte("static-secret")), valid: true},
+		{scenario: "token signed with the public key as HMAC secret", token: signWithKid(t, "HS256", "2018-05", []byte(jwk.N)), valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			req := &http.Request{Header: http.Header{"Authorization": {"Bearer " + test.token}}}
+			if !test.valid {
+				_, err := parser.ParseFromRequest(req)
+				assert.Error(t, err)
+				return
+			}
+
+			assertParseToken(t, parser, req)
+		})
+	}
+}
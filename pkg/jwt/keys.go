@@ -0,0 +1,230 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	// ErrNotPublicKey is the error returned for verification keys that are neither a public key, a certificate or a JWK
+	ErrNotPublicKey = errors.New("invalid key: expected PUBLIC KEY or CERTIFICATE block type, or a JWK")
+	// ErrNotPrivateKey is the error returned for invalid signing keys
+	ErrNotPrivateKey = errors.New("invalid key: expected a PEM-encoded private key")
+)
+
+// oidEd25519 is the algorithm identifier of the Ed25519 keys, from RFC 8410
+var oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type pkcs8PrivateKey struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// VerificationKey returns the key the tokens signed with the method are verified with. HMAC methods use the key as
+// secret, the other methods a PEM-encoded public key or certificate, or a JWK.
+func VerificationKey(method jwt.SigningMethod, key string) (interface{}, error) {
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		return []byte(key), nil
+	}
+
+	pub, err := parsePublicKey(key)
+	if err != nil {
+		if err == ErrNotPublicKey && isRSA(method) {
+			return nil, ErrNotRSAPublicKey
+		}
+		return nil, err
+	}
+
+	if err := checkKeyType(method, pub); err != nil {
+		return nil, err
+	}
+
+	return pub, nil
+}
+
+// SigningKey returns the key the tokens are signed with. HMAC methods use the key as secret, the other methods a
+// PEM-encoded private key: PKCS #1 or PKCS #8 for RSA, SEC 1 or PKCS #8 for ECDSA and PKCS #8 for EdDSA.
+func SigningKey(method jwt.SigningMethod, key string) (interface{}, error) {
+	switch m := method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return []byte(key), nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		return jwt.ParseRSAPrivateKeyFromPEM([]byte(key))
+	case *jwt.SigningMethodECDSA:
+		privateKey, err := jwt.ParseECPrivateKeyFromPEM([]byte(key))
+		if err != nil {
+			return nil, err
+		}
+		if privateKey.Curve.Params().BitSize != m.CurveBits {
+			return nil, ErrBadPublicKey
+		}
+		return privateKey, nil
+	case *SigningMethodEdDSA:
+		return parseEd25519PrivateKey(key)
+	default:
+		return nil, ErrUnsupportedSigningMethod
+	}
+}
+
+// PublicSigningMethod returns the signing method verifying the tokens signed with the given one. The private key of
+// the asymmetric methods is replaced by its PEM-encoded public key, the HMAC and unknown methods are returned as
+// they are.
+func PublicSigningMethod(signingMethod SigningMethod) (SigningMethod, error) {
+	method := jwt.GetSigningMethod(signingMethod.Alg)
+	if method == nil {
+		return signingMethod, nil
+	}
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		return signingMethod, nil
+	}
+
+	privateKey, err := SigningKey(method, signingMethod.Key)
+	if err != nil {
+		return signingMethod, err
+	}
+
+	var der []byte
+	switch k := privateKey.(type) {
+	case *rsa.PrivateKey:
+		der, err = x509.MarshalPKIXPublicKey(&k.PublicKey)
+	case *ecdsa.PrivateKey:
+		der, err = x509.MarshalPKIXPublicKey(&k.PublicKey)
+	case ed25519.PrivateKey:
+		publicKey := k[ed25519.PrivateKeySize-ed25519.PublicKeySize:]
+		der, err = asn1.Marshal(subjectPublicKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidEd25519},
+			PublicKey: asn1.BitString{Bytes: publicKey, BitLength: 8 * len(publicKey)},
+		})
+	}
+	if err != nil {
+		return signingMethod, err
+	}
+
+	return SigningMethod{
+		Alg: signingMethod.Alg,
+		Key: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}, nil
+}
+
+func parsePublicKey(key string) (interface{}, error) {
+	if trimmed := strings.TrimSpace(key); strings.HasPrefix(trimmed, "{") {
+		var jwk JWK
+		if err := json.Unmarshal([]byte(trimmed), &jwk); err != nil {
+			return nil, err
+		}
+		return jwk.PublicKey()
+	}
+
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, ErrInvalidPEMBlock
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return parsePKIXPublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if cert.PublicKey == nil {
+			return parsePKIXPublicKey(cert.RawSubjectPublicKeyInfo)
+		}
+		return cert.PublicKey, nil
+	default:
+		return nil, ErrNotPublicKey
+	}
+}
+
+// parsePKIXPublicKey parses a DER-encoded public key. The Ed25519 keys are parsed here, since the standard library
+// does not support them on every Go version we build with.
+func parsePKIXPublicKey(der []byte) (interface{}, error) {
+	var spki subjectPublicKeyInfo
+	if rest, err := asn1.Unmarshal(der, &spki); err == nil && len(rest) == 0 && spki.Algorithm.Algorithm.Equal(oidEd25519) {
+		publicKey := spki.PublicKey.RightAlign()
+		if len(publicKey) != ed25519.PublicKeySize {
+			return nil, ErrBadPublicKey
+		}
+		return ed25519.PublicKey(publicKey), nil
+	}
+
+	return x509.ParsePKIXPublicKey(der)
+}
+
+func parseEd25519PrivateKey(key string) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, ErrInvalidPEMBlock
+	}
+
+	var pkcs8 pkcs8PrivateKey
+	if _, err := asn1.Unmarshal(block.Bytes, &pkcs8); err != nil {
+		return nil, err
+	}
+	if block.Type != "PRIVATE KEY" || !pkcs8.Algorithm.Algorithm.Equal(oidEd25519) {
+		return nil, ErrNotPrivateKey
+	}
+
+	var seed []byte
+	if _, err := asn1.Unmarshal(pkcs8.PrivateKey, &seed); err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, ErrNotPrivateKey
+	}
+
+	// the key is generated from the seed read as randomness, NewKeyFromSeed is not in every version we build with
+	_, privateKey, err := ed25519.GenerateKey(bytes.NewReader(seed))
+	return privateKey, err
+}
+
+// checkKeyType checks the key is meant for the signing method, so a token can not pick a method the key was not
+// issued for
+func checkKeyType(method jwt.SigningMethod, key interface{}) error {
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if _, ok := key.(*rsa.PublicKey); !ok {
+			return ErrBadPublicKey
+		}
+	case *jwt.SigningMethodECDSA:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve.Params().BitSize != m.CurveBits {
+			return ErrBadPublicKey
+		}
+	case *SigningMethodEdDSA:
+		if edKey, ok := key.(ed25519.PublicKey); !ok || len(edKey) != ed25519.PublicKeySize {
+			return ErrBadPublicKey
+		}
+	default:
+		return ErrUnsupportedSigningMethod
+	}
+
+	return nil
+}
+
+func isRSA(method jwt.SigningMethod) bool {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,288 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	baseJWT "github.com/dgrijalva/jwt-go"
+	"github.com/hellofresh/janus/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+)
+
+type testKey struct {
+	private    interface{}
+	privatePEM string
+	publicPEM  string
+	certPEM    string
+	jwk        string
+}
+
+func newECDSATestKey(t *testing.T, curve elliptic.Curve, crv string) testKey {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	size := (curve.Params().BitSize + 7) / 8
+	jwk, err := json.Marshal(JWK{
+		Kty: "EC",
+		Crv: crv,
+		X:   base64.RawURLEncoding.EncodeToString(padded(key.X, size)),
+		Y:   base64.RawURLEncoding.EncodeToString(padded(key.Y, size)),
+	})
+	require.NoError(t, err)
+
+	return testKey{
+		private:    key,
+		privatePEM: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})),
+		publicPEM:  publicPEM(t, &key.PublicKey),
+		certPEM:    certificatePEM(t, &key.PublicKey, key),
+		jwk:        string(jwk),
+	}
+}
+
+func newRSATestKey(t *testing.T) testKey {
+	key, err := baseJWT.ParseRSAPrivateKeyFromPEM([]byte(rsa2048Private))
+	require.NoError(t, err)
+
+	jwk, err := json.Marshal(JWK{
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	})
+	require.NoError(t, err)
+
+	return testKey{
+		private:    key,
+		privatePEM: rsa2048Private,
+		publicPEM:  rsa2048Public,
+		certPEM:    certificatePEM(t, &key.PublicKey, key),
+		jwk:        string(jwk),
+	}
+}
+
+func newEd25519TestKey(t *testing.T) testKey {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	seed, err := asn1.Marshal(private[:ed25519.SeedSize])
+	require.NoError(t, err)
+	der, err := asn1.Marshal(pkcs8PrivateKey{Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidEd25519}, PrivateKey: seed})
+	require.NoError(t, err)
+
+	spki, err := asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidEd25519},
+		PublicKey: asn1.BitString{Bytes: public, BitLength: 8 * len(public)},
+	})
+	require.NoError(t, err)
+
+	jwk, err := json.Marshal(JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(public)})
+	require.NoError(t, err)
+
+	return testKey{
+		private:    private,
+		privatePEM: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		publicPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: spki})),
+		jwk:        string(jwk),
+	}
+}
+
+func padded(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	return append(make([]byte, size-len(b)), b...)
+}
+
+func publicPEM(t *testing.T, pub interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func certificatePEM(t *testing.T, pub, priv interface{}) string {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "janus"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func signToken(t *testing.T, alg string, key interface{}) string {
+	token := baseJWT.NewWithClaims(baseJWT.GetSigningMethod(alg), baseJWT.MapClaims{
+		"iss":      clientID,
+		"username": userName,
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	})
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func TestParser_Parse_AsymmetricAlgorithms(t *testing.T) {
+	rsaKey := newRSATestKey(t)
+	p256 := newECDSATestKey(t, elliptic.P256(), "P-256")
+	p384 := newECDSATestKey(t, elliptic.P384(), "P-384")
+	p521 := newECDSATestKey(t, elliptic.P521(), "P-521")
+	edKey := newEd25519TestKey(t)
+
+	tests := []struct {
+		alg string
+		key testKey
+	}{
+		{alg: "RS256", key: rsaKey},
+		{alg: "PS256", key: rsaKey},
+		{alg: "PS384", key: rsaKey},
+		{alg: "PS512", key: rsaKey},
+		{alg: "ES256", key: p256},
+		{alg: "ES384", key: p384},
+		{alg: "ES512", key: p521},
+		{alg: "EdDSA", key: edKey},
+	}
+
+	for _, test := range tests {
+		token := signToken(t, test.alg, test.key.private)
+		req := &http.Request{Header: http.Header{"Authorization": {"Bearer " + token}}}
+
+		keys := map[string]string{"pem": test.key.publicPEM, "certificate": test.key.certPEM, "jwk": test.key.jwk}
+		for format, key := range keys {
+			if key == "" {
+				continue
+			}
+
+			t.Run(test.alg+" "+format, func(t *testing.T) {
+				assertParseToken(t, NewParser(NewParserConfig(0, SigningMethod{Alg: test.alg, Key: key})), req)
+			})
+		}
+	}
+}
+
+func TestParser_Parse_KeyOfAnotherAlgorithm(t *testing.T) {
+	p256 := newECDSATestKey(t, elliptic.P256(), "P-256")
+	p384 := newECDSATestKey(t, elliptic.P384(), "P-384")
+	edKey := newEd25519TestKey(t)
+
+	tests := []struct {
+		scenario string
+		alg      string
+		signWith interface{}
+		key      string
+	}{
+		{scenario: "ES256 token with a P-384 key", alg: "ES256", signWith: p256.private, key: p384.publicPEM},
+		{scenario: "ES384 token with a P-256 key", alg: "ES384", signWith: p384.private, key: p256.publicPEM},
+		{scenario: "ES256 token with a RSA key", alg: "ES256", signWith: p256.private, key: rsa2048Public},
+		{scenario: "EdDSA token with an ECDSA key", alg: "EdDSA", signWith: edKey.private, key: p256.publicPEM},
+		{scenario: "EdDSA token with a private key", alg: "EdDSA", signWith: edKey.private, key: edKey.privatePEM},
+		{scenario: "HS256 token signed with the public key", alg: "HS256", signWith: []byte(p256.publicPEM), key: p256.publicPEM},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			token := signToken(t, test.alg, test.signWith)
+			method := test.alg
+			if method == "HS256" {
+				method = "ES256"
+			}
+
+			_, err := NewParser(NewParserConfig(0, SigningMethod{Alg: method, Key: test.key})).Parse(token)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestIssueAdminToken_Asymmetric(t *testing.T) {
+	tests := []struct {
+		alg string
+		key testKey
+	}{
+		{alg: "RS256", key: newRSATestKey(t)},
+		{alg: "PS384", key: newRSATestKey(t)},
+		{alg: "ES256", key: newECDSATestKey(t, elliptic.P256(), "P-256")},
+		{alg: "EdDSA", key: newEd25519TestKey(t)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.alg, func(t *testing.T) {
+			guard := NewGuard(config.Credentials{Algorithm: test.alg, Secret: test.key.privatePEM})
+
+			accessToken, err := IssueAdminToken(guard.SigningMethod, baseJWT.MapClaims{"id": "admin"}, time.Hour)
+			require.NoError(t, err)
+
+			token, err := NewParser(guard.ParserConfig).Parse(accessToken.Token)
+			require.NoError(t, err)
+
+			claims, ok := NewParser(guard.ParserConfig).GetMapClaims(token)
+			assert.True(t, ok)
+			assert.Equal(t, "admin", claims["id"])
+
+			_, err = NewParser(NewParserConfig(0, SigningMethod{Alg: test.alg, Key: test.key.publicPEM})).Parse(accessToken.Token)
+			assert.NoError(t, err, "the token is verified with the public key")
+		})
+	}
+}
+
+func TestIssueAdminToken_InvalidKey(t *testing.T) {
+	_, err := IssueAdminToken(SigningMethod{Alg: "ES256", Key: rsa2048Private}, baseJWT.MapClaims{}, time.Hour)
+	assert.Error(t, err)
+
+	_, err = IssueAdminToken(SigningMethod{Alg: "unknown", Key: "secret"}, baseJWT.MapClaims{}, time.Hour)
+	assert.Equal(t, ErrUnsupportedSigningMethod, err)
+}
+
+func TestJWKS_Key_ECAndEd25519(t *testing.T) {
+	p256 := newECDSATestKey(t, elliptic.P256(), "P-256")
+	edKey := newEd25519TestKey(t)
+
+	var ecJWK, edJWK JWK
+	require.NoError(t, json.Unmarshal([]byte(p256.jwk), &ecJWK))
+	require.NoError(t, json.Unmarshal([]byte(edKey.jwk), &edJWK))
+	ecJWK.Kid, edJWK.Kid = "ec", "ed"
+
+	server := newJWKSServer(ecJWK, edJWK)
+	defer server.Close()
+
+	config := NewParserConfig(0)
+	config.JWKS = NewJWKS(server.URL)
+	parser := NewParser(config)
+
+	for kid, token := range map[string]*baseJWT.Token{
+		"ec": baseJWT.NewWithClaims(baseJWT.SigningMethodES256, baseJWT.MapClaims{"iss": clientID, "username": userName}),
+		"ed": baseJWT.NewWithClaims(SigningMethodEd25519, baseJWT.MapClaims{"iss": clientID, "username": userName}),
+	} {
+		token.Header["kid"] = kid
+		key := p256.private
+		if kid == "ed" {
+			key = edKey.private
+		}
+
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+
+		assertParseToken(t, parser, &http.Request{Header: http.Header{"Authorization": {"Bearer " + signed}}})
+	}
+
+	mismatched := signWithKid(t, "ES256", "ed", p256.private)
+	_, err := parser.Parse(mismatched)
+	assert.Error(t, err, "the Ed25519 key can not verify an ES256 token")
+}
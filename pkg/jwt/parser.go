@@ -1,9 +1,6 @@
 package jwt
 
 import (
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
 	"errors"
 	"net/http"
 	"strings"
@@ -28,8 +25,10 @@ var (
 
 // SigningMethod defines signing method algorithm and key
 type SigningMethod struct {
-	// Alg defines JWT signing algorithm. Possible values are: HS256, HS384, HS512, RS256, RS384, RS512
+	// Alg defines JWT signing algorithm. Possible values are: HS256, HS384, HS512, RS256, RS384, RS512,
+	// PS256, PS384, PS512, ES256, ES384, ES512, EdDSA
 	Alg string `json:"alg"`
+	// Key is the HMAC secret, or the PEM-encoded public key, certificate or JWK of the asymmetric algorithms
 	Key string `json:"key"`
 }
 
@@ -49,6 +48,10 @@ type ParserConfig struct {
 
 	// Leeway is the time in seconds to account for clock skew when checking nbf, iat or expiration times
 	Leeway int64
+
+	// JWKS is the key set the tokens with a kid are verified with, before the signing methods are tried.
+	// Optional.
+	JWKS *JWKS
 }
 
 // NewParserConfig creates a new instance of ParserConfig
@@ -130,36 +133,20 @@ func (jp *Parser) ParseRequest(r *http.Request) (string, error) {
 
 // Parse a JWT token and validates it
 func (jp *Parser) Parse(tokenString string) (*jwt.Token, error) {
+	if jp.Config.JWKS != nil {
+		token, err := jwt.ParseWithClaims(tokenString, NewJanusClaims(jp.Config.Leeway), jp.jwksKey)
+		if err == nil || !isUnverified(err) {
+			return token, err
+		}
+	}
+
 	for _, method := range jp.Config.SigningMethods {
 		token, err := jwt.ParseWithClaims(tokenString, NewJanusClaims(jp.Config.Leeway), func(token *jwt.Token) (interface{}, error) {
 			if token.Method.Alg() != method.Alg {
 				return nil, ErrSigningMethodMismatch
 			}
 
-			switch token.Method.(type) {
-			case *jwt.SigningMethodHMAC:
-				return []byte(method.Key), nil
-			case *jwt.SigningMethodRSA:
-				block, _ := pem.Decode([]byte(method.Key))
-				if block == nil {
-					return nil, ErrInvalidPEMBlock
-				}
-				if got, want := block.Type, "PUBLIC KEY"; got != want {
-					return nil, ErrNotRSAPublicKey
-				}
-				pub, err := x509.ParsePKIXPublicKey(block.Bytes)
-				if nil != err {
-					return nil, err
-				}
-
-				if _, ok := pub.(*rsa.PublicKey); !ok {
-					return nil, ErrBadPublicKey
-				}
-
-				return pub, nil
-			default:
-				return nil, ErrUnsupportedSigningMethod
-			}
+			return VerificationKey(token.Method, method.Key)
 		})
 
 		if err != nil {
@@ -167,7 +154,7 @@ func (jp *Parser) Parse(tokenString string) (*jwt.Token, error) {
 				continue
 			}
 
-			if validationErr, ok := err.(*jwt.ValidationError); ok && (validationErr.Errors&jwt.ValidationErrorUnverifiable > 0 || validationErr.Errors&jwt.ValidationErrorSignatureInvalid > 0) {
+			if isUnverified(err) {
 				continue
 			}
 		}
@@ -178,6 +165,31 @@ func (jp *Parser) Parse(tokenString string) (*jwt.Token, error) {
 	return nil, ErrFailedToParseToken
 }
 
+// jwksKey returns the key of the JWKS the token is verified with, selected by the token kid
+func (jp *Parser) jwksKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKey
+	}
+
+	key, err := jp.Config.JWKS.Key(kid, token.Method.Alg())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkKeyType(token.Method, key); err != nil {
+		return nil, ErrKeyAlgMismatch
+	}
+
+	return key, nil
+}
+
+// isUnverified checks if the token could not be verified with the key, so the next key can be tried
+func isUnverified(err error) bool {
+	validationErr, ok := err.(*jwt.ValidationError)
+	return ok && (validationErr.Errors&jwt.ValidationErrorUnverifiable > 0 || validationErr.Errors&jwt.ValidationErrorSignatureInvalid > 0)
+}
+
 // GetMapClaims returns a map version of Claims Section
 func (jp *Parser) GetMapClaims(token *jwt.Token) (jwt.MapClaims, bool) {
 	claims, ok := token.Claims.(*JanusClaims)
@@ -182,7 +182,7 @@ V2pT9Twi0IeXmw==
 	assert.Error(t, err)
 }
 
-func TestParser_Parse_ErrUnsupportedSigningMethod(t *testing.T) {
+func TestParser_Parse_RSAPSS(t *testing.T) {
 	alg := "PS256"
 
 	tokenString, err := generateToken(alg, rsa2048Private)
@@ -193,6 +193,21 @@ func TestParser_Parse_ErrUnsupportedSigningMethod(t *testing.T) {
 
 	req := &http.Request{Header: http.Header{"Authorization": {"Bearer " + tokenString}}}
 
+	assertParseToken(t, parser, req)
+}
+
+func TestParser_Parse_ErrUnsupportedSigningMethod(t *testing.T) {
+	alg := "none"
+
+	token := baseJWT.NewWithClaims(baseJWT.SigningMethodNone, baseJWT.MapClaims{"iss": clientID, "username": userName})
+	tokenString, err := token.SignedString(baseJWT.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	config := NewParserConfig(0, SigningMethod{Alg: alg, Key: rsa2048Public})
+	parser := NewParser(config)
+
+	req := &http.Request{Header: http.Header{"Authorization": {"Bearer " + tokenString}}}
+
 	_, err = parser.ParseFromRequest(req)
 	assert.Error(t, err)
 }
@@ -13,21 +13,30 @@ type AccessToken struct {
 	Expires int64  `json:"expires_in"`
 }
 
-// IssueAdminToken issues admin JWT for API access
+// IssueAdminToken issues admin JWT for API access. The key of the asymmetric algorithms is a PEM-encoded private key.
 func IssueAdminToken(signingMethod SigningMethod, claims jwt.MapClaims, expireIn time.Duration) (*AccessToken, error) {
-	token := jwt.New(jwt.GetSigningMethod(signingMethod.Alg))
+	method := jwt.GetSigningMethod(signingMethod.Alg)
+	if method == nil {
+		return nil, ErrUnsupportedSigningMethod
+	}
+
+	key, err := SigningKey(method, signingMethod.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	token := jwt.New(method)
 	exp := time.Now().Add(expireIn).Unix()
 
 	token.Claims = claims
 	claims["exp"] = exp
 	claims["iat"] = time.Now().Unix()
 
-	accessToken, err := token.SignedString([]byte(signingMethod.Key))
+	accessToken, err := token.SignedString(key)
 	if err != nil {
 		return nil, err
 	}
 
-	// currently only HSXXX algorithms are supported for issuing admin token, so we cast key to bytes array
 	return &AccessToken{
 		Type:    "Bearer",
 		Token:   accessToken,
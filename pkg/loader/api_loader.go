@@ -22,13 +22,23 @@ func NewAPILoader(register *proxy.Register) *APILoader {
 
 // RegisterAPIs load application middleware
 func (m *APILoader) RegisterAPIs(cfgs []*api.Definition) {
+	var registered []string
 	for _, spec := range cfgs {
-		m.RegisterAPI(spec)
+		if m.registerAPI(spec) {
+			registered = append(registered, spec.Name)
+		}
 	}
+
+	// drop the upstream state (e.g. health checkers) of the APIs that are gone or not active anymore
+	m.register.Retain(registered)
 }
 
 // RegisterAPI register an API Definition in the register
 func (m *APILoader) RegisterAPI(def *api.Definition) {
+	m.registerAPI(def)
+}
+
+func (m *APILoader) registerAPI(def *api.Definition) bool {
 	logger := log.WithField("api_name", def.Name)
 	logger.Debug("Starting RegisterAPI")
 
@@ -44,6 +54,12 @@ func (m *APILoader) RegisterAPI(def *api.Definition) {
 
 	if active {
 		routerDefinition := proxy.NewRouterDefinition(def.Proxy)
+		routerDefinition.Name = def.Name
+		for _, plg := range def.Plugins {
+			if plg.Enabled {
+				routerDefinition.Plugins = append(routerDefinition.Plugins, plg.Name)
+			}
+		}
 
 		for _, plg := range def.Plugins {
 			l := logger.WithField("name", plg.Name)
@@ -81,9 +97,14 @@ func (m *APILoader) RegisterAPI(def *api.Definition) {
 		}
 		routerDefinition.AddMiddleware(middleware.NewStatsTagger(tags).Handler)
 
-		m.register.Add(routerDefinition)
+		if err := m.register.Add(routerDefinition); err != nil {
+			return false
+		}
+
 		logger.Debug("API registered")
-	} else {
-		logger.WithError(err).Warn("API URI is invalid or not active, skipping...")
+		return true
 	}
+
+	logger.WithError(err).Warn("API URI is invalid or not active, skipping...")
+	return false
 }
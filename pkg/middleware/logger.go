@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/felixge/httpsnoop"
+	"github.com/hellofresh/janus/pkg/consumer"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -34,12 +35,18 @@ func (m *Logger) Handler(handler http.Handler) http.Handler {
 			"user-agent":  r.UserAgent(),
 		}
 
+		// the consumer is resolved by the auth plugins down the chain
+		r = r.WithContext(consumer.NewContext(r.Context()))
 		m := httpsnoop.CaptureMetrics(handler, w, r)
 
 		fields["code"] = m.Code
 		fields["duration"] = int(m.Duration / time.Millisecond)
 		fields["duration-fmt"] = m.Duration.String()
 
+		if c, ok := consumer.FromContext(r.Context()); ok {
+			fields["consumer"] = c.ID
+		}
+
 		session := getSession(r)
 
 		if session != nil {
@@ -32,15 +32,33 @@ var (
 	KeyListenPath, _             = tag.NewKey("path")
 	KeyUpstreamPath, _           = tag.NewKey("upstream_path")
 	KeyJWTValidationErrorType, _ = tag.NewKey("error")
+	KeyAPIName, _                = tag.NewKey("api_name")
+	KeyUpstreamTarget, _         = tag.NewKey("upstream_target")
+	KeyResult, _                 = tag.NewKey("result")
+	KeyAccessRule, _             = tag.NewKey("rule")
 )
 
 // Metrics
 var (
-	MJWTManagerValidationErrors = stats.Int64("plugin_jwt_manager_validation_error_total", "Number of validation errors by error type", dimensionless)
-	MOAuth2MissingHeader        = stats.Int64("plugin_oauth2_missing_header_total", "Number of failed oauth2 authentication due to missing header", dimensionless)
-	MOAuth2MalformedHeader      = stats.Int64("plugin_oauth2_malformed_header_total", "Number of failed oauth2 authentication due to malformed bearer header", dimensionless)
-	MOAuth2Authorized           = stats.Int64("plugin_oauth2_authorized_request_total", "Number of successful and authorized oauth2 authentication", dimensionless)
-	MOAuth2Unauthorized         = stats.Int64("plugin_oauth2_unauthorized_request_total", "Number of successful but unauthorized oauth2 authentication", dimensionless)
+	MJWTManagerValidationErrors  = stats.Int64("plugin_jwt_manager_validation_error_total", "Number of validation errors by error type", dimensionless)
+	MJWTPolicyViolations         = stats.Int64("plugin_jwt_policy_violation_total", "Number of tokens rejected by the claims validation policy by reason", dimensionless)
+	MOAuth2MissingHeader         = stats.Int64("plugin_oauth2_missing_header_total", "Number of failed oauth2 authentication due to missing header", dimensionless)
+	MOAuth2MalformedHeader       = stats.Int64("plugin_oauth2_malformed_header_total", "Number of failed oauth2 authentication due to malformed bearer header", dimensionless)
+	MOAuth2Authorized            = stats.Int64("plugin_oauth2_authorized_request_total", "Number of successful and authorized oauth2 authentication", dimensionless)
+	MOAuth2Unauthorized          = stats.Int64("plugin_oauth2_unauthorized_request_total", "Number of successful but unauthorized oauth2 authentication", dimensionless)
+	MOAuth2AccessRuleDecisions   = stats.Int64("plugin_oauth2_access_rule_decision_total", "Number of oauth2 access rules decisions by result and matching rule", dimensionless)
+	MUpstreamHealthChecks        = stats.Int64("upstream_health_check_total", "Number of active health checks by upstream target and result", dimensionless)
+	MUpstreamTargetHealthy       = stats.Int64("upstream_target_healthy", "Whether an upstream target is healthy (1) or ejected (0)", dimensionless)
+	MUpstreamTargetEjections     = stats.Int64("upstream_target_ejection_total", "Number of upstream target ejections by the outlier detection", dimensionless)
+	MUpstreamAllTargetsUnhealthy = stats.Int64("upstream_all_targets_unhealthy_request_total", "Number of requests balanced between all the upstream targets because none of them is healthy", dimensionless)
+	MMirrorRequests              = stats.Int64("plugin_mirror_request_total", "Number of mirrored requests by result", dimensionless)
+	MMirrorLatency               = stats.Float64("plugin_mirror_request_latency", "Latency of the mirrored requests", ms)
+	MCircuitBreakerState         = stats.Int64("plugin_cb_state", "State of the circuit breaker of an upstream target: closed (0), half-open (1) or open (2)", dimensionless)
+	MCircuitBreakerRejected      = stats.Int64("plugin_cb_rejected_request_total", "Number of requests rejected by the circuit breaker by reason", dimensionless)
+	MCacheRequests               = stats.Int64("plugin_cache_request_total", "Number of cacheable requests by cache result", dimensionless)
+	MConcurrencyLimit            = stats.Int64("plugin_concurrency_limit", "Current limit of requests in flight", dimensionless)
+	MConcurrencyInFlight         = stats.Int64("plugin_concurrency_in_flight", "Number of requests in flight", dimensionless)
+	MConcurrencyShed             = stats.Int64("plugin_concurrency_shed_request_total", "Number of requests shed by the concurrency limit by reason", dimensionless)
 )
 
 // AllViews aggregates the metrics
@@ -51,6 +69,12 @@ var AllViews = []*view.View{
 		Measure:     MJWTManagerValidationErrors,
 		Aggregation: view.Count(),
 	},
+	{
+		Name:        "plugin_jwt_policy_violation_total",
+		TagKeys:     []tag.Key{KeyJWTValidationErrorType},
+		Measure:     MJWTPolicyViolations,
+		Aggregation: view.Count(),
+	},
 	{
 		Name:        "plugin_oauth2_missing_header_total",
 		Measure:     MOAuth2MissingHeader,
@@ -71,6 +95,84 @@ var AllViews = []*view.View{
 		Measure:     MOAuth2Unauthorized,
 		Aggregation: view.Count(),
 	},
+	{
+		Name:        "plugin_oauth2_access_rule_decision_total",
+		TagKeys:     []tag.Key{KeyResult, KeyAccessRule},
+		Measure:     MOAuth2AccessRuleDecisions,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "upstream_health_check_total",
+		TagKeys:     []tag.Key{KeyAPIName, KeyUpstreamTarget, KeyResult},
+		Measure:     MUpstreamHealthChecks,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "upstream_target_healthy",
+		TagKeys:     []tag.Key{KeyAPIName, KeyUpstreamTarget},
+		Measure:     MUpstreamTargetHealthy,
+		Aggregation: view.LastValue(),
+	},
+	{
+		Name:        "upstream_target_ejection_total",
+		TagKeys:     []tag.Key{KeyAPIName, KeyUpstreamTarget},
+		Measure:     MUpstreamTargetEjections,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "upstream_all_targets_unhealthy_request_total",
+		TagKeys:     []tag.Key{KeyListenPath},
+		Measure:     MUpstreamAllTargetsUnhealthy,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "plugin_mirror_request_total",
+		TagKeys:     []tag.Key{KeyAPIName, KeyResult},
+		Measure:     MMirrorRequests,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "plugin_mirror_request_latency",
+		TagKeys:     []tag.Key{KeyAPIName},
+		Measure:     MMirrorLatency,
+		Aggregation: ochttp.DefaultLatencyDistribution,
+	},
+	{
+		Name:        "plugin_cb_state",
+		TagKeys:     []tag.Key{KeyAPIName, KeyUpstreamTarget},
+		Measure:     MCircuitBreakerState,
+		Aggregation: view.LastValue(),
+	},
+	{
+		Name:        "plugin_cb_rejected_request_total",
+		TagKeys:     []tag.Key{KeyAPIName, KeyResult},
+		Measure:     MCircuitBreakerRejected,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "plugin_cache_request_total",
+		TagKeys:     []tag.Key{KeyAPIName, KeyResult},
+		Measure:     MCacheRequests,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "plugin_concurrency_limit",
+		TagKeys:     []tag.Key{KeyAPIName},
+		Measure:     MConcurrencyLimit,
+		Aggregation: view.LastValue(),
+	},
+	{
+		Name:        "plugin_concurrency_in_flight",
+		TagKeys:     []tag.Key{KeyAPIName},
+		Measure:     MConcurrencyInFlight,
+		Aggregation: view.LastValue(),
+	},
+	{
+		Name:        "plugin_concurrency_shed_request_total",
+		TagKeys:     []tag.Key{KeyAPIName, KeyResult},
+		Measure:     MConcurrencyShed,
+		Aggregation: view.Count(),
+	},
 	{
 		Name:        "http_server_response_count_by_path_code_and_method",
 		TagKeys:     []tag.Key{KeyListenPath, ochttp.StatusCode, ochttp.Method},
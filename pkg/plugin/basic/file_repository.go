@@ -0,0 +1,188 @@
+package basic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// FileSystemRepository represents an user repository loaded from the files of a directory. The JSON files hold a
+// list of users and the htpasswd files, named htpasswd or *.htpasswd, hold bcrypt hashed passwords. The directory
+// is watched, so the changed files are reloaded right away. The users managed on the admin API are kept in memory
+// only.
+type FileSystemRepository struct {
+	*InMemoryRepository
+	dir     string
+	watcher *fsnotify.Watcher
+
+	mu        sync.Mutex
+	fileUsers map[string]bool
+}
+
+// NewFileSystemRepository creates a file based user repository
+func NewFileSystemRepository(dir string) (*FileSystemRepository, error) {
+	repo := &FileSystemRepository{
+		InMemoryRepository: NewInMemoryRepository(),
+		dir:                dir,
+		fileUsers:          make(map[string]bool),
+	}
+
+	if err := repo.Reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a file system watcher")
+	}
+
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, errors.Wrap(err, "failed to watch the basic auth users directory")
+	}
+
+	repo.watcher = watcher
+	go repo.watch()
+
+	return repo, nil
+}
+
+// Close stops watching the users directory
+func (r *FileSystemRepository) Close() error {
+	return r.watcher.Close()
+}
+
+// Reload loads the users of the files again. The users removed from the files are removed from the repository,
+// the ones added on the admin API are kept. The users are left unchanged when a file is invalid.
+func (r *FileSystemRepository) Reload() error {
+	users, err := readUsers(r.dir)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded := make(map[string]bool, len(users))
+	for _, u := range users {
+		loaded[u.Username] = true
+		if err := r.Add(u); err != nil {
+			return errors.Wrapf(err, "could not add the user %s", u.Username)
+		}
+	}
+
+	for username := range r.fileUsers {
+		if !loaded[username] {
+			if err := r.Remove(username); err != nil && err != ErrUserNotFound {
+				return errors.Wrapf(err, "could not remove the user %s", username)
+			}
+		}
+	}
+	r.fileUsers = loaded
+
+	return nil
+}
+
+func (r *FileSystemRepository) watch() {
+	for {
+		select {
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if !isUsersFile(event.Name) || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+
+			if err := r.Reload(); err != nil {
+				log.WithError(err).WithField("path", r.dir).Error("Couldn't reload the basic auth users")
+				continue
+			}
+			log.WithField("path", event.Name).Debug("Basic auth users reloaded")
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.WithError(err).WithField("path", r.dir).Error("error received from file system notify")
+		}
+	}
+}
+
+func isUsersFile(name string) bool {
+	base := filepath.Base(name)
+	return filepath.Ext(base) == ".json" || filepath.Ext(base) == ".htpasswd" || base == "htpasswd"
+}
+
+func readUsers(dir string) ([]*User, error) {
+	files, err := ioutil.ReadDir(dir)
+	if nil != err {
+		return nil, err
+	}
+
+	var users []*User
+	for _, f := range files {
+		if f.IsDir() || !isUsersFile(f.Name()) {
+			continue
+		}
+
+		filePath := filepath.Join(dir, f.Name())
+		raw, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			log.WithError(err).WithField("path", filePath).Error("Couldn't load the basic auth users file")
+			return nil, err
+		}
+
+		var fileUsers []*User
+		if filepath.Ext(f.Name()) == ".json" {
+			if err := json.Unmarshal(raw, &fileUsers); err != nil {
+				return nil, errors.Wrapf(err, "could not parse the basic auth users file %s", filePath)
+			}
+		} else if fileUsers, err = parseHtpasswd(raw); err != nil {
+			return nil, errors.Wrapf(err, "could not parse the htpasswd file %s", filePath)
+		}
+
+		for _, u := range fileUsers {
+			if u.Username == "" || u.Password == "" {
+				return nil, errors.Errorf("the basic auth users in %s need a username and a password", filePath)
+			}
+		}
+		users = append(users, fileUsers...)
+	}
+
+	return users, nil
+}
+
+// parseHtpasswd reads the users of a htpasswd file, only the bcrypt hashes are supported
+func parseHtpasswd(raw []byte) ([]*User, error) {
+	var users []*User
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("line %d is not a username:password entry", line)
+		}
+
+		if !IsHashed(parts[1]) {
+			return nil, errors.Errorf("the password of %s is not a bcrypt hash, create it with htpasswd -B", parts[0])
+		}
+
+		users = append(users, &User{Username: parts[0], Password: parts[1]})
+	}
+
+	return users, scanner.Err()
+}
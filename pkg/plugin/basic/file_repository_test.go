@@ -0,0 +1,110 @@
+package basic
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFileSystemRepository(t *testing.T) {
+	hash, err := HashPassword("pay-your-debt")
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "basic_auth")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "users.json"), []byte(`[{"username": "lanister", "password": "`+hash+`", "consumer": "lanister-bank"}]`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "htpasswd"), []byte("# the night's watch\nsnow:"+hash+"\n\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not users"), 0600))
+
+	repo, err := NewFileSystemRepository(dir)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	users, err := repo.FindAll()
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "lanister", users[0].Username)
+	assert.Equal(t, "lanister-bank", users[0].ConsumerID())
+	assert.Equal(t, "snow", users[1].Username)
+	assert.True(t, VerifyPassword(users[1].Password, "pay-your-debt"))
+}
+
+func TestNewFileSystemRepositoryInvalidFiles(t *testing.T) {
+	tests := []struct {
+		scenario string
+		name     string
+		content  string
+	}{
+		{scenario: "invalid json", name: "users.json", content: `{"username": "snow"`},
+		{scenario: "user without password", name: "users.json", content: `[{"username": "snow"}]`},
+		{scenario: "htpasswd without bcrypt", name: "users.htpasswd", content: "snow:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g="},
+		{scenario: "htpasswd without password", name: "users.htpasswd", content: "snow"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "basic_auth")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, test.name), []byte(test.content), 0600))
+
+			_, err = NewFileSystemRepository(dir)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewFileSystemRepositoryMissingDir(t *testing.T) {
+	_, err := NewFileSystemRepository("/does/not/exist")
+	assert.Error(t, err)
+}
+
+func TestFileSystemRepositoryReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "basic_auth")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	usersFile := filepath.Join(dir, "users.json")
+	require.NoError(t, ioutil.WriteFile(usersFile, []byte(`[{"username": "snow", "password": "ghost"}]`), 0600))
+
+	repo, err := NewFileSystemRepository(dir)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	require.NoError(t, repo.Add(&User{Username: "admin-user", Password: "secret"}))
+
+	require.NoError(t, ioutil.WriteFile(usersFile, []byte(`[{"username": "stark", "password": "winter"}]`), 0600))
+	waitFor(t, func() bool {
+		_, err := repo.FindByUsername("stark")
+		return err == nil
+	})
+
+	_, err = repo.FindByUsername("snow")
+	assert.Equal(t, ErrUserNotFound, err, "the users removed from the files are removed")
+	_, err = repo.FindByUsername("admin-user")
+	assert.NoError(t, err, "the users added on the admin API are kept")
+
+	require.NoError(t, ioutil.WriteFile(usersFile, []byte(`[{"username": "stark"`), 0600))
+	assert.Error(t, repo.Reload())
+	_, err = repo.FindByUsername("stark")
+	assert.NoError(t, err, "the users are kept when a file is invalid")
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	t.Fatal("the condition was not met in time")
+}
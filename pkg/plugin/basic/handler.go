@@ -26,7 +26,7 @@ func NewHandler(repo Repository) *Handler {
 func (c *Handler) Index() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		_, span := trace.StartSpan(r.Context(), "repo.FindAll")
-		data, err := c.repo.FindAll()
+		users, err := c.repo.FindAll()
 		span.End()
 
 		if err != nil {
@@ -34,6 +34,11 @@ func (c *Handler) Index() http.HandlerFunc {
 			return
 		}
 
+		data := make([]*User, 0, len(users))
+		for _, u := range users {
+			data = append(data, u.public())
+		}
+
 		render.JSON(w, http.StatusOK, data)
 	}
 }
@@ -43,7 +48,7 @@ func (c *Handler) Show() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		username := router.URLParam(r, "username")
 		_, span := trace.StartSpan(r.Context(), "repo.Show")
-		data, err := c.repo.FindByUsername(username)
+		user, err := c.repo.FindByUsername(username)
 		span.End()
 
 		if err != nil {
@@ -51,7 +56,7 @@ func (c *Handler) Show() http.HandlerFunc {
 			return
 		}
 
-		render.JSON(w, http.StatusOK, data)
+		render.JSON(w, http.StatusOK, user.public())
 	}
 }
 
@@ -81,6 +86,11 @@ func (c *Handler) Update() http.HandlerFunc {
 			return
 		}
 
+		if user.Password, err = HashPassword(user.Password); err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
 		_, span = trace.StartSpan(r.Context(), "repo.Add")
 		err = c.repo.Add(user)
 		span.End()
@@ -115,6 +125,11 @@ func (c *Handler) Create() http.HandlerFunc {
 			return
 		}
 
+		if user.Password, err = HashPassword(user.Password); err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
 		_, span = trace.StartSpan(r.Context(), "repo.Add")
 		err = c.repo.Add(user)
 		span.End()
@@ -146,3 +161,19 @@ func (c *Handler) Delete() http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// Migrate is the handler hashing the plaintext passwords of the users
+func (c *Handler) Migrate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := trace.StartSpan(r.Context(), "repo.MigratePasswords")
+		migrated, err := MigratePasswords(c.repo)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, map[string]int{"migrated": migrated})
+	}
+}
@@ -1,6 +1,7 @@
 package basic
 
 import (
+	"sort"
 	"sync"
 )
 
@@ -15,16 +16,19 @@ func NewInMemoryRepository() *InMemoryRepository {
 	return &InMemoryRepository{users: make(map[string]*User)}
 }
 
-// FindAll fetches all the users available
+// FindAll fetches all the users available, sorted by username
 func (r *InMemoryRepository) FindAll() ([]*User, error) {
 	r.RLock()
 	defer r.RUnlock()
 
-	var users []*User
+	users := make([]*User, 0, len(r.users))
 	for _, user := range r.users {
-		users = append(users, user)
+		u := *user
+		users = append(users, &u)
 	}
 
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+
 	return users, nil
 }
 
@@ -32,7 +36,14 @@ func (r *InMemoryRepository) FindAll() ([]*User, error) {
 func (r *InMemoryRepository) FindByUsername(username string) (*User, error) {
 	r.RLock()
 	defer r.RUnlock()
-	return r.findByUsername(username)
+
+	user, err := r.findByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *user
+	return &u, nil
 }
 
 // Add adds an user to the repository
@@ -40,7 +51,8 @@ func (r *InMemoryRepository) Add(user *User) error {
 	r.Lock()
 	defer r.Unlock()
 
-	r.users[user.Username] = user
+	u := *user
+	r.users[user.Username] = &u
 
 	return nil
 }
@@ -1,15 +1,18 @@
 package basic
 
 import (
-	"crypto/subtle"
 	"net/http"
 
+	"github.com/hellofresh/janus/pkg/consumer"
 	"github.com/hellofresh/janus/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
-// NewBasicAuth is a HTTP basic auth middleware
+// NewBasicAuth is a HTTP basic auth middleware. The user is looked up by username and the credentials verified
+// recently are cached, so the password hash is not checked on every request.
 func NewBasicAuth(repo Repository) func(http.Handler) http.Handler {
+	cache := newVerifiedCache(defaultCacheSize, defaultCacheTTL)
+
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			log.Debug("Starting basic auth middleware")
@@ -24,28 +27,47 @@ func NewBasicAuth(repo Repository) func(http.Handler) http.Handler {
 				return
 			}
 
-			var found bool
-			users, err := repo.FindAll()
+			user, err := repo.FindByUsername(username)
+			if err == ErrUserNotFound {
+				logger.Debug("Invalid user/password provided.")
+				errors.Handler(w, ErrNotAuthorized)
+				return
+			}
 			if err != nil {
-				log.WithError(err).Error("Error when getting all users")
+				log.WithError(err).Error("Error when looking for the user")
 				errors.Handler(w, errors.New(http.StatusInternalServerError, "there was an error when looking for users"))
 				return
 			}
 
-			for _, u := range users {
-				if username == u.Username && (subtle.ConstantTimeCompare([]byte(password), []byte(u.Password)) == 1) {
-					found = true
-					break
+			if !cache.verified(user, password) {
+				if !VerifyPassword(user.Password, password) {
+					logger.Debug("Invalid user/password provided.")
+					errors.Handler(w, ErrNotAuthorized)
+					return
 				}
-			}
+				cache.add(user, password)
 
-			if !found {
-				logger.Debug("Invalid user/password provided.")
-				errors.Handler(w, ErrNotAuthorized)
-				return
+				if !IsHashed(user.Password) {
+					migratePassword(repo, user, password)
+				}
 			}
 
-			handler.ServeHTTP(w, r)
+			handler.ServeHTTP(w, consumer.Resolve(r, user.ConsumerID()))
 		})
 	}
 }
+
+// migratePassword stores the hash of the plaintext password the user was just authenticated with
+func migratePassword(repo Repository, user *User, password string) {
+	hash, err := HashPassword(password)
+	if err != nil {
+		log.WithError(err).WithField("username", user.Username).Error("Could not hash the password of the user")
+		return
+	}
+
+	migrated := *user
+	migrated.Password = hash
+	if err := repo.Add(&migrated); err != nil {
+		log.WithError(err).WithField("username", user.Username).Error("Could not store the hashed password of the user")
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/hellofresh/janus/pkg/consumer"
 	"github.com/hellofresh/janus/pkg/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAuthorizedAccess(t *testing.T) {
@@ -27,6 +29,56 @@ func TestAuthorizedAccess(t *testing.T) {
 	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 }
 
+func TestAuthorizedAccessResolvesConsumer(t *testing.T) {
+	repo := NewInMemoryRepository()
+	repo.Add(&User{Username: "test", Password: "test", Consumer: "mobile-app"})
+
+	var resolved *consumer.Consumer
+	mw := NewBasicAuth(repo)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resolved, _ = consumer.FromContext(r.Context())
+	}))
+
+	_, err := test.Record("GET", "/", map[string]string{"Authorization": "Basic " + basicAuth("test", "test")}, mw)
+	assert.NoError(t, err)
+	if assert.NotNil(t, resolved) {
+		assert.Equal(t, "mobile-app", resolved.ID)
+	}
+}
+
+func TestAuthorizedAccessHashedPassword(t *testing.T) {
+	hash, err := HashPassword("test")
+	require.NoError(t, err)
+
+	repo := NewInMemoryRepository()
+	repo.Add(&User{Username: "test", Password: hash})
+	mw := NewBasicAuth(repo)
+
+	// the second request is verified from the cache
+	for i := 0; i < 2; i++ {
+		w, err := test.Record("GET", "/", map[string]string{"Authorization": "Basic " + basicAuth("test", "test")}, mw(http.HandlerFunc(test.Ping)))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w, err := test.Record("GET", "/", map[string]string{"Authorization": "Basic " + basicAuth("test", hash)}, mw(http.HandlerFunc(test.Ping)))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthorizedAccessMigratesPlaintextPassword(t *testing.T) {
+	repo := setupRepo()
+	mw := NewBasicAuth(repo)
+
+	w, err := test.Record("GET", "/", map[string]string{"Authorization": "Basic " + basicAuth("test", "test")}, mw(http.HandlerFunc(test.Ping)))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	user, err := repo.FindByUsername("test")
+	require.NoError(t, err)
+	assert.True(t, IsHashed(user.Password))
+	assert.True(t, VerifyPassword(user.Password, "test"))
+}
+
 func TestInvalidBasicHeader(t *testing.T) {
 	mw := NewBasicAuth(setupRepo())
 
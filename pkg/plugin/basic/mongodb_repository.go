@@ -13,7 +13,26 @@ const (
 // User represents an user
 type User struct {
 	Username string `json:"username"`
-	Password string `json:"password"`
+	// Password is the bcrypt hash of the password, it is never shown on the admin API
+	Password string `json:"password,omitempty"`
+	// Consumer is the id of the consumer owning the user, the username is used when empty
+	Consumer string `json:"consumer,omitempty" bson:"consumer,omitempty"`
+}
+
+// ConsumerID returns the id of the consumer owning the user
+func (u *User) ConsumerID() string {
+	if u.Consumer != "" {
+		return u.Consumer
+	}
+
+	return u.Username
+}
+
+// public returns a copy of the user without the password
+func (u *User) public() *User {
+	public := *u
+	public.Password = ""
+	return &public
 }
 
 // Repository represents an user repository
@@ -0,0 +1,142 @@
+package basic
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// DefaultCost is the bcrypt cost the passwords are hashed with
+	DefaultCost = bcrypt.DefaultCost
+
+	defaultCacheSize = 1000
+	defaultCacheTTL  = time.Minute
+)
+
+// HashPassword returns the bcrypt hash of the password. A password that already is a bcrypt hash is returned as is,
+// so the hashes of other systems can be imported.
+func HashPassword(password string) (string, error) {
+	if IsHashed(password) {
+		return password, nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// IsHashed checks if the stored password is a bcrypt hash
+func IsHashed(password string) bool {
+	_, err := bcrypt.Cost([]byte(password))
+	return err == nil
+}
+
+// VerifyPassword checks the password against the stored one. The plaintext passwords stored before the
+// passwords were hashed are still compared, in constant time.
+func VerifyPassword(stored, password string) bool {
+	if IsHashed(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	}
+
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+}
+
+// MigratePasswords hashes the plaintext passwords of the users in the repository. It returns the number of
+// migrated users.
+func MigratePasswords(repo Repository) (int, error) {
+	users, err := repo.FindAll()
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, u := range users {
+		if IsHashed(u.Password) {
+			continue
+		}
+
+		if u.Password, err = HashPassword(u.Password); err != nil {
+			return migrated, err
+		}
+		if err := repo.Add(u); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+
+	return migrated, nil
+}
+
+// verifiedCache remembers the credentials verified recently, so bcrypt does not run on every request. An entry
+// is only valid for the stored password it was verified against, changing or removing the user invalidates it.
+type verifiedCache struct {
+	sync.Mutex
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]verifiedEntry
+}
+
+type verifiedEntry struct {
+	stored    string
+	password  [sha256.Size]byte
+	expiresAt time.Time
+}
+
+func newVerifiedCache(size int, ttl time.Duration) *verifiedCache {
+	return &verifiedCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]verifiedEntry),
+	}
+}
+
+func (c *verifiedCache) verified(user *User, password string) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	entry, ok := c.entries[user.Username]
+	if !ok {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(password))
+	if entry.stored != user.Password || !c.now().Before(entry.expiresAt) ||
+		subtle.ConstantTimeCompare(entry.password[:], sum[:]) != 1 {
+		delete(c.entries, user.Username)
+		return false
+	}
+
+	return true
+}
+
+func (c *verifiedCache) add(user *User, password string) {
+	c.Lock()
+	defer c.Unlock()
+
+	now := c.now()
+	if len(c.entries) >= c.size {
+		for username, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, username)
+			}
+		}
+	}
+	if len(c.entries) >= c.size {
+		c.entries = make(map[string]verifiedEntry)
+	}
+
+	c.entries[user.Username] = verifiedEntry{
+		stored:    user.Password,
+		password:  sha256.Sum256([]byte(password)),
+		expiresAt: now.Add(c.ttl),
+	}
+}
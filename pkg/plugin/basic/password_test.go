@@ -0,0 +1,87 @@
+package basic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("pay-your-debt")
+	require.NoError(t, err)
+
+	assert.True(t, IsHashed(hash))
+	assert.NotEqual(t, "pay-your-debt", hash)
+
+	again, err := HashPassword(hash)
+	require.NoError(t, err)
+	assert.Equal(t, hash, again, "a bcrypt hash is not hashed twice")
+}
+
+func TestVerifyPassword(t *testing.T) {
+	hash, err := HashPassword("pay-your-debt")
+	require.NoError(t, err)
+
+	tests := []struct {
+		scenario string
+		stored   string
+		password string
+		expected bool
+	}{
+		{scenario: "hashed password", stored: hash, password: "pay-your-debt", expected: true},
+		{scenario: "wrong hashed password", stored: hash, password: "wrong", expected: false},
+		{scenario: "plaintext password", stored: "pay-your-debt", password: "pay-your-debt", expected: true},
+		{scenario: "wrong plaintext password", stored: "pay-your-debt", password: "wrong", expected: false},
+		{scenario: "the hash is not the password", stored: hash, password: hash, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			assert.Equal(t, test.expected, VerifyPassword(test.stored, test.password))
+		})
+	}
+}
+
+func TestMigratePasswords(t *testing.T) {
+	repo := newInMemoryRepo()
+
+	migrated, err := MigratePasswords(repo)
+	require.NoError(t, err)
+	assert.Equal(t, 2, migrated)
+
+	user, err := repo.FindByUsername("test1")
+	require.NoError(t, err)
+	assert.True(t, VerifyPassword(user.Password, "test1"))
+	assert.True(t, IsHashed(user.Password))
+
+	migrated, err = MigratePasswords(repo)
+	require.NoError(t, err)
+	assert.Equal(t, 0, migrated, "the hashed passwords are kept")
+}
+
+func TestVerifiedCache(t *testing.T) {
+	now := time.Now()
+	cache := newVerifiedCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	user := &User{Username: "test", Password: "hash"}
+	assert.False(t, cache.verified(user, "test"))
+
+	cache.add(user, "test")
+	assert.True(t, cache.verified(user, "test"))
+	assert.False(t, cache.verified(user, "wrong"))
+
+	cache.add(user, "test")
+	assert.False(t, cache.verified(&User{Username: "test", Password: "changed"}, "test"), "the entry is invalidated when the password changes")
+
+	cache.add(user, "test")
+	now = now.Add(time.Minute)
+	assert.False(t, cache.verified(user, "test"), "the entry expires")
+
+	cache.add(&User{Username: "test1"}, "test1")
+	cache.add(&User{Username: "test2"}, "test2")
+	cache.add(&User{Username: "test3"}, "test3")
+	assert.Len(t, cache.entries, 1, "the cache is bounded")
+}
@@ -0,0 +1,42 @@
+package basic
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/globalsign/mgo"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	mongodb = "mongodb"
+	file    = "file"
+)
+
+// BuildRepository creates the user repository for the given database DSN
+func BuildRepository(dsn string, session *mgo.Session) (Repository, error) {
+	dsnURL, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing the DSN")
+	}
+
+	switch dsnURL.Scheme {
+	case mongodb:
+		if session == nil {
+			return nil, ErrInvalidMongoDBSession
+		}
+		return NewMongoRepository(session)
+	case file:
+		usersPath := fmt.Sprintf("%s/basic_auth", dsnURL.Path)
+		log.WithField("path", usersPath).Debug("Trying to load basic auth user files")
+
+		repo, err := NewFileSystemRepository(usersPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not create a file based repository for the basic auth users")
+		}
+		return repo, nil
+	default:
+		return nil, errors.New("The selected scheme is not supported to load basic auth users")
+	}
+}
@@ -3,6 +3,8 @@ package basic
 import (
 	"errors"
 
+	"github.com/hellofresh/janus/pkg/consumer"
+	"github.com/hellofresh/janus/pkg/jwt"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/hellofresh/janus/pkg/router"
@@ -42,35 +44,89 @@ func onAdminAPIStartup(event interface{}) error {
 }
 
 func onStartup(event interface{}) error {
-	var err error
-
 	e, ok := event.(plugin.OnStartup)
 	if !ok {
 		return errors.New("could not convert event to startup type")
 	}
 
-	if e.MongoSession == nil {
-		return ErrInvalidMongoDBSession
-	}
-
 	if adminRouter == nil {
 		return ErrInvalidAdminRouter
 	}
 
-	repo, err = NewMongoRepository(e.MongoSession)
+	if e.Config == nil {
+		return errors.New("the database configuration is needed to load the basic auth users")
+	}
+
+	r, err := BuildRepository(e.Config.Database.DSN, e.MongoSession)
 	if err != nil {
 		return err
 	}
 
+	repo = r
+	consumer.RegisterCredentialSource("basic_auth", credentialSource{repo})
+
 	handlers := NewHandler(repo)
+	guard := jwt.NewGuard(e.Config.Web.Credentials)
 	group := adminRouter.Group("/credentials/basic_auth")
+	group.Use(jwt.NewMiddleware(guard).Handler)
 	{
 		group.GET("/", handlers.Index())
 		group.POST("/", handlers.Create())
 		group.GET("/{username}", handlers.Show())
 		group.PUT("/{username}", handlers.Update())
 		group.DELETE("/{username}", handlers.Delete())
+		group.POST("/migrate", handlers.Migrate())
 	}
 
 	return nil
 }
+
+// credentialSource lists and removes the users of the consumers for the consumers admin API
+type credentialSource struct {
+	repo Repository
+}
+
+func (s credentialSource) FindByConsumer(id string) (interface{}, error) {
+	users, err := s.findByConsumer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make([]map[string]string, 0, len(users))
+	for _, u := range users {
+		usernames = append(usernames, map[string]string{"username": u.Username})
+	}
+
+	return usernames, nil
+}
+
+func (s credentialSource) RemoveByConsumer(id string) error {
+	users, err := s.findByConsumer(id)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		if err := s.repo.Remove(u.Username); err != nil && err != ErrUserNotFound {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s credentialSource) findByConsumer(id string) ([]*User, error) {
+	users, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var owned []*User
+	for _, u := range users {
+		if u.ConsumerID() == id {
+			owned = append(owned, u)
+		}
+	}
+
+	return owned, nil
+}
@@ -1,13 +1,20 @@
 package basic
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/globalsign/mgo"
 
+	"github.com/hellofresh/janus/pkg/config"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/hellofresh/janus/pkg/router"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -18,26 +25,66 @@ func TestSetup(t *testing.T) {
 	err := onAdminAPIStartup(event1)
 	require.NoError(t, err)
 
-	event2 := plugin.OnStartup{Register: proxy.NewRegister(proxy.WithRouter(router.NewChiRouter())), MongoSession: &mgo.Session{}}
+	event2 := plugin.OnStartup{
+		Register:     proxy.NewRegister(proxy.WithRouter(router.NewChiRouter())),
+		MongoSession: &mgo.Session{},
+		Config:       &config.Specification{Database: config.Database{DSN: "mongodb://localhost:27017/janus"}},
+	}
 	err = onStartup(event2)
 	require.NoError(t, err)
+	assert.IsType(t, &MongoRepository{}, repo)
 
 	err = setupBasicAuth(def, make(plugin.Config))
 	require.NoError(t, err)
 }
 
+func TestSetupWithFileSystem(t *testing.T) {
+	dir, err := ioutil.TempDir("", "janus")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "basic_auth"), 0700))
+
+	r := router.NewChiRouter()
+	err = onAdminAPIStartup(plugin.OnAdminAPIStartup{Router: r})
+	require.NoError(t, err)
+
+	event := plugin.OnStartup{Config: &config.Specification{Database: config.Database{DSN: "file://" + dir}}}
+	err = onStartup(event)
+	require.NoError(t, err)
+	assert.IsType(t, &FileSystemRepository{}, repo)
+	defer repo.(*FileSystemRepository).Close()
+
+	for _, path := range []string{"/credentials/basic_auth/", "/credentials/basic_auth/migrate"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "the basic auth endpoints need an admin token")
+	}
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	err = setupBasicAuth(def, make(plugin.Config))
+	require.NoError(t, err)
+	assert.Len(t, def.Middleware(), 1)
+}
+
 func TestOnStartupMissingMongoSession(t *testing.T) {
-	event := plugin.OnStartup{Register: proxy.NewRegister(proxy.WithRouter(router.NewChiRouter()))}
+	require.NoError(t, onAdminAPIStartup(plugin.OnAdminAPIStartup{Router: router.NewChiRouter()}))
+
+	event := plugin.OnStartup{
+		Register: proxy.NewRegister(proxy.WithRouter(router.NewChiRouter())),
+		Config:   &config.Specification{Database: config.Database{DSN: "mongodb://localhost:27017/janus"}},
+	}
 	err := onStartup(event)
 	require.Error(t, err)
-	require.IsType(t, ErrInvalidMongoDBSession, err)
+	assert.Equal(t, ErrInvalidMongoDBSession, err)
 }
 
 func TestOnStartupMissingAdminRouter(t *testing.T) {
+	adminRouter = nil
+
 	event := plugin.OnStartup{}
 	err := onStartup(event)
 	require.Error(t, err)
-	require.IsType(t, ErrInvalidAdminRouter, err)
+	assert.Equal(t, ErrInvalidAdminRouter, err)
 }
 
 func TestOnStartupWrongEvent(t *testing.T) {
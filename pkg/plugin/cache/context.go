@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+// valueOnlyContext keeps the values of the parent context, e.g. the consumer and the metric tags, without its
+// deadline and cancellation, so the background revalidation outlives the request it was started by
+type valueOnlyContext struct {
+	context.Context
+}
+
+func (valueOnlyContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (valueOnlyContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (valueOnlyContext) Err() error {
+	return nil
+}
+
+// detachContext returns a context holding the values of the request context that stays valid once the request
+// is served. The route context is copied since the router reuses it for the following requests.
+func detachContext(ctx context.Context) context.Context {
+	detached := context.Context(valueOnlyContext{ctx})
+
+	if rctx, ok := ctx.Value(chi.RouteCtxKey).(*chi.Context); ok {
+		clone := chi.NewRouteContext()
+		clone.Routes = rctx.Routes
+		clone.RoutePatterns = append([]string(nil), rctx.RoutePatterns...)
+		clone.URLParams.Keys = append([]string(nil), rctx.URLParams.Keys...)
+		clone.URLParams.Values = append([]string(nil), rctx.URLParams.Values...)
+		detached = context.WithValue(detached, chi.RouteCtxKey, clone)
+	}
+
+	return detached
+}
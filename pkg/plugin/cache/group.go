@@ -0,0 +1,52 @@
+package cache
+
+import "sync"
+
+// fill is the outcome of a request sent to the upstream to fill the cache
+type fill struct {
+	// entry is nil when the response could not be cached
+	entry *Entry
+	// variant is the key the entry was stored under
+	variant string
+}
+
+type call struct {
+	wg     sync.WaitGroup
+	result fill
+}
+
+// group coalesces the concurrent upstream requests for the same key, so only one of them reaches the upstream
+type group struct {
+	sync.Mutex
+	calls map[string]*call
+}
+
+func newGroup() *group {
+	return &group{calls: make(map[string]*call)}
+}
+
+// do runs fn unless there is already a call in flight for the key, in which case it waits for that call and
+// returns its result. The returned flag is true when the result comes from another call.
+func (g *group) do(key string, fn func() fill) (fill, bool) {
+	g.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.Unlock()
+		c.wg.Wait()
+		return c.result, true
+	}
+
+	c := new(call)
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.Unlock()
+
+	defer func() {
+		g.Lock()
+		delete(g.calls, key)
+		g.Unlock()
+		c.wg.Done()
+	}()
+
+	c.result = fn()
+	return c.result, false
+}
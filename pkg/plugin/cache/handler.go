@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"net/http"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/render"
+	"github.com/hellofresh/janus/pkg/router"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrCacheNotFound is used when the API does not use the cache plugin
+	ErrCacheNotFound = errors.New(http.StatusNotFound, "cache is not enabled for this api")
+	// ErrInvalidPolicy is used when an invalid policy was provided
+	ErrInvalidPolicy = errors.New(http.StatusBadRequest, "policy is not supported")
+)
+
+// NewPurgeHandler creates the handler that removes the cached responses of an API, the "path" query parameter
+// limits the purge to the responses of that path
+func NewPurgeHandler(registry *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := router.URLParam(r, "name")
+		c, ok := registry.Get(name)
+		if !ok {
+			errors.Handler(w, ErrCacheNotFound)
+			return
+		}
+
+		purged, err := c.Purge(r.URL.Query().Get("path"))
+		if err != nil {
+			log.WithError(err).WithField("api_name", name).Error("Could not purge the cache")
+			errors.Handler(w, err)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, map[string]int{"purged": purged})
+	}
+}
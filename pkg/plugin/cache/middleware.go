@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
+	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/hellofresh/janus/pkg/request"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const (
+	// HeaderCache tells the client how the response was served
+	HeaderCache = "X-Cache"
+
+	resultHit    = "HIT"
+	resultStale  = "STALE"
+	resultMiss   = "MISS"
+	resultBypass = "BYPASS"
+)
+
+// Cache holds the cached responses of an API
+type Cache struct {
+	name      string
+	cfg       Config
+	store     Store
+	bodyLimit int64
+	calls     *group
+	now       func() time.Time
+}
+
+// NewCache creates a new instance of Cache
+func NewCache(name string, cfg Config, store Store) *Cache {
+	limit, err := bytefmt.ToBytes(cfg.MaxBodySize)
+	if err != nil {
+		log.WithError(err).WithField("max_body_size", cfg.MaxBodySize).Error("invalid cache max_body_size")
+	}
+
+	return &Cache{
+		name:      name,
+		cfg:       cfg,
+		store:     store,
+		bodyLimit: int64(limit),
+		calls:     newGroup(),
+		now:       time.Now,
+	}
+}
+
+// Purge removes the cached responses of the API, when a path is given only the responses of that path are removed
+func (c *Cache) Purge(path string) (int, error) {
+	if path == "" {
+		return c.store.Purge(c.name + ":")
+	}
+
+	escaped := (&url.URL{Path: path}).EscapedPath()
+	purged := 0
+	for _, method := range c.cfg.Methods {
+		n, err := c.store.Purge(c.name + ":" + method + " " + escaped + "?")
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+	}
+
+	return purged, nil
+}
+
+// Close releases the store of the cache
+func (c *Cache) Close() error {
+	return c.store.Close()
+}
+
+// NewCacheMiddleware creates a new cache middleware. Fresh responses are served from the cache, stale ones are
+// served while they are revalidated in background and concurrent misses for the same key share one upstream request.
+func NewCacheMiddleware(c *Cache) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if bypassRequest(r, c.cfg) {
+				c.record(resultBypass)
+				w.Header().Set(HeaderCache, resultBypass)
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			key := c.name + ":" + requestKey(r, c.cfg.Key)
+			if !refreshRequest(r) {
+				entry := c.lookup(key, r)
+				now := c.now()
+				if entry != nil && entry.Fresh(now) {
+					c.serve(w, r, entry, resultHit)
+					return
+				}
+
+				if entry != nil && entry.Stale(now) {
+					c.serve(w, r, entry, resultStale)
+					go c.revalidate(key, detachRequest(r), handler)
+					return
+				}
+			}
+
+			result, shared := c.calls.do(key, func() fill {
+				c.record(resultMiss)
+				w.Header().Set(HeaderCache, resultMiss)
+				return c.fetch(newRecorder(w, c.bodyLimit), key, r, handler)
+			})
+			if !shared {
+				return
+			}
+
+			// the shared response is only reused when it was cacheable and matches the variant of this request
+			if result.entry == nil || (len(result.entry.Vary) > 0 && variantKey(key, r, result.entry.Vary) != result.variant) {
+				c.record(resultMiss)
+				w.Header().Set(HeaderCache, resultMiss)
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			c.serve(w, r, result.entry, resultHit)
+		})
+	}
+}
+
+// lookup returns the cached response matching the request, following the vary marker to the right variant
+func (c *Cache) lookup(key string, r *http.Request) *Entry {
+	entry, err := c.store.Get(key)
+	if err == nil && entry != nil && entry.StatusCode == 0 && len(entry.Vary) > 0 {
+		entry, err = c.store.Get(variantKey(key, r, entry.Vary))
+	}
+
+	if err != nil {
+		log.WithError(err).WithField("api_name", c.name).Error("Could not read from the cache store")
+		return nil
+	}
+
+	if entry == nil || entry.StatusCode == 0 {
+		return nil
+	}
+
+	return entry
+}
+
+// revalidate refreshes a stale entry in background, a refresh already in flight for the same key is joined
+func (c *Cache) revalidate(key string, r *http.Request, handler http.Handler) {
+	c.calls.do(key, func() fill {
+		return c.fetch(newRecorder(nil, c.bodyLimit), key, r, handler)
+	})
+}
+
+// detachRequest copies the request for a background revalidation, it must be called before the request is served
+func detachRequest(r *http.Request) *http.Request {
+	req := r.WithContext(detachContext(r.Context()))
+	req.Header = request.CloneHeader(r.Header)
+
+	return req
+}
+
+// fetch sends the request upstream and stores the response if it is cacheable
+func (c *Cache) fetch(rec *recorder, key string, r *http.Request, handler http.Handler) fill {
+	handler.ServeHTTP(rec, r)
+	if rec.overflow || !rec.wroteHeader {
+		return fill{}
+	}
+
+	now := c.now()
+	fresh, stale, ok := lifetime(rec.statusCode, rec.header, c.cfg, now)
+	if !ok {
+		return fill{}
+	}
+
+	header := request.CloneHeader(rec.header)
+	header.Del(HeaderCache)
+
+	entry := &Entry{
+		StatusCode: rec.statusCode,
+		Header:     header,
+		Body:       rec.body.Bytes(),
+		Vary:       varyHeaders(rec.header),
+		StoredAt:   now,
+		FreshUntil: now.Add(fresh),
+		StaleUntil: now.Add(fresh + stale),
+	}
+
+	variant := key
+	if len(entry.Vary) > 0 {
+		marker := &Entry{Vary: entry.Vary, StoredAt: now, FreshUntil: entry.FreshUntil, StaleUntil: entry.StaleUntil}
+		if err := c.store.Set(key, marker, fresh+stale); err != nil {
+			log.WithError(err).WithField("api_name", c.name).Error("Could not write to the cache store")
+			return fill{}
+		}
+		variant = variantKey(key, r, entry.Vary)
+	}
+
+	if err := c.store.Set(variant, entry, fresh+stale); err != nil {
+		log.WithError(err).WithField("api_name", c.name).Error("Could not write to the cache store")
+		return fill{}
+	}
+
+	return fill{entry: entry, variant: variant}
+}
+
+func (c *Cache) serve(w http.ResponseWriter, r *http.Request, entry *Entry, result string) {
+	c.record(result)
+
+	h := w.Header()
+	for k, v := range entry.Header {
+		h[k] = append([]string(nil), v...)
+	}
+
+	age := int64(c.now().Sub(entry.StoredAt) / time.Second)
+	if upstreamAge, err := strconv.ParseInt(entry.Header.Get("Age"), 10, 64); err == nil && upstreamAge > 0 {
+		age += upstreamAge
+	}
+	h.Set("Age", strconv.FormatInt(age, 10))
+	h.Set(HeaderCache, result)
+
+	w.WriteHeader(entry.StatusCode)
+	if r.Method != http.MethodHead {
+		w.Write(entry.Body)
+	}
+}
+
+func (c *Cache) record(result string) {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(obs.KeyAPIName, c.name),
+		tag.Insert(obs.KeyResult, strings.ToLower(result)),
+	)
+	if err != nil {
+		log.WithError(err).Debug("Failed to tag cache metrics")
+		return
+	}
+
+	stats.Record(ctx, obs.MCacheRequests.M(1))
+}
@@ -0,0 +1,250 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type upstream struct {
+	calls  int32
+	header http.Header
+	delay  time.Duration
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := atomic.AddInt32(&u.calls, 1)
+	time.Sleep(u.delay)
+
+	for k, v := range u.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("response " + strconv.Itoa(int(n)) + " " + r.Header.Get("Accept-Language")))
+}
+
+func (u *upstream) count() int {
+	return int(atomic.LoadInt32(&u.calls))
+}
+
+func newTestCache(cfg Config) (*Cache, *time.Time) {
+	if cfg.Methods == nil {
+		cfg.Methods = defaultMethods
+	}
+	if cfg.StatusCodes == nil {
+		cfg.StatusCodes = defaultStatusCodes
+	}
+	cfg.MaxBodySize = defaultMaxBodySize
+
+	now := time.Now()
+	c := NewCache("example", cfg, NewMemoryStore(0))
+	c.now = func() time.Time { return now }
+	store := c.store.(*MemoryStore)
+	store.now = c.now
+
+	return c, &now
+}
+
+func get(handler http.Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestCacheHitAndMiss(t *testing.T) {
+	t.Parallel()
+
+	u := &upstream{header: http.Header{"Cache-Control": {"max-age=60"}}}
+	c, now := newTestCache(Config{})
+	handler := NewCacheMiddleware(c)(u)
+
+	w := get(handler, "/items", nil)
+	assert.Equal(t, resultMiss, w.Header().Get(HeaderCache))
+	assert.Equal(t, "response 1 ", w.Body.String())
+
+	*now = now.Add(10 * time.Second)
+	w = get(handler, "/items", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, resultHit, w.Header().Get(HeaderCache))
+	assert.Equal(t, "10", w.Header().Get("Age"))
+	assert.Equal(t, "max-age=60", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "response 1 ", w.Body.String())
+
+	w = get(handler, "/items?page=2", nil)
+	assert.Equal(t, resultMiss, w.Header().Get(HeaderCache))
+
+	w = get(handler, "/items", http.Header{"Cache-Control": {"no-cache"}})
+	assert.Equal(t, resultMiss, w.Header().Get(HeaderCache), "the client asked for a fresh response")
+	assert.Equal(t, "response 3 ", w.Body.String())
+
+	w = get(handler, "/items", nil)
+	assert.Equal(t, "response 3 ", w.Body.String(), "the refreshed response replaced the cached one")
+
+	*now = now.Add(2 * time.Minute)
+	w = get(handler, "/items", nil)
+	assert.Equal(t, resultMiss, w.Header().Get(HeaderCache))
+	assert.Equal(t, 4, u.count())
+}
+
+func TestCacheBypass(t *testing.T) {
+	t.Parallel()
+
+	u := &upstream{header: http.Header{"Cache-Control": {"max-age=60"}}}
+	c, _ := newTestCache(Config{})
+	handler := NewCacheMiddleware(c)(u)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/items", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, resultBypass, w.Header().Get(HeaderCache))
+	}
+	assert.Equal(t, 2, u.count())
+}
+
+func TestCacheNotCacheableResponse(t *testing.T) {
+	t.Parallel()
+
+	u := &upstream{header: http.Header{"Cache-Control": {"private"}}}
+	c, _ := newTestCache(Config{TTL: proxy.Duration(time.Minute)})
+	handler := NewCacheMiddleware(c)(u)
+
+	get(handler, "/items", nil)
+	w := get(handler, "/items", nil)
+	assert.Equal(t, resultMiss, w.Header().Get(HeaderCache))
+	assert.Equal(t, 2, u.count())
+}
+
+func TestCacheVary(t *testing.T) {
+	t.Parallel()
+
+	u := &upstream{header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept-Language"}}}
+	c, _ := newTestCache(Config{})
+	handler := NewCacheMiddleware(c)(u)
+
+	w := get(handler, "/items", http.Header{"Accept-Language": {"de"}})
+	assert.Equal(t, "response 1 de", w.Body.String())
+
+	w = get(handler, "/items", http.Header{"Accept-Language": {"en"}})
+	assert.Equal(t, resultMiss, w.Header().Get(HeaderCache))
+	assert.Equal(t, "response 2 en", w.Body.String())
+
+	w = get(handler, "/items", http.Header{"Accept-Language": {"de"}})
+	assert.Equal(t, resultHit, w.Header().Get(HeaderCache))
+	assert.Equal(t, "response 1 de", w.Body.String())
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	t.Parallel()
+
+	u := &upstream{header: http.Header{"Cache-Control": {"max-age=10, stale-while-revalidate=60"}}}
+	c, now := newTestCache(Config{})
+	handler := NewCacheMiddleware(c)(u)
+
+	get(handler, "/items", nil)
+
+	*now = now.Add(30 * time.Second)
+	w := get(handler, "/items", nil)
+	assert.Equal(t, resultStale, w.Header().Get(HeaderCache))
+	assert.Equal(t, "response 1 ", w.Body.String())
+
+	for i := 0; i < 100 && u.count() < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, 2, u.count(), "the stale entry is refreshed in background")
+
+	var body string
+	for i := 0; i < 100 && body != "response 2 "; i++ {
+		time.Sleep(10 * time.Millisecond)
+		body = get(handler, "/items", nil).Body.String()
+	}
+	assert.Equal(t, "response 2 ", body)
+}
+
+func TestCacheStaleWhileRevalidateURLParams(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	c, now := newTestCache(Config{})
+	r := router.NewChiRouter()
+	r.GET("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=60")
+		w.Write([]byte("item " + router.URLParam(r, "id") + " " + strconv.Itoa(int(n))))
+	}, NewCacheMiddleware(c))
+
+	get(r, "/items/42", nil)
+
+	*now = now.Add(30 * time.Second)
+	w := get(r, "/items/42", nil)
+	assert.Equal(t, resultStale, w.Header().Get(HeaderCache))
+	assert.Equal(t, "item 42 1", w.Body.String())
+
+	var body string
+	for i := 0; i < 100 && body != "item 42 2"; i++ {
+		time.Sleep(10 * time.Millisecond)
+		body = get(r, "/items/42", nil).Body.String()
+	}
+	assert.Equal(t, "item 42 2", body, "the background refresh keeps the URL parameters of its request")
+}
+
+func TestCacheCoalescesMisses(t *testing.T) {
+	t.Parallel()
+
+	u := &upstream{header: http.Header{"Cache-Control": {"max-age=60"}}, delay: 100 * time.Millisecond}
+	c, _ := newTestCache(Config{})
+	handler := NewCacheMiddleware(c)(u)
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 5)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i] = get(handler, "/items", nil).Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, u.count())
+	for _, body := range bodies {
+		assert.Equal(t, "response 1 ", body)
+	}
+}
+
+func TestCachePurge(t *testing.T) {
+	t.Parallel()
+
+	u := &upstream{header: http.Header{"Cache-Control": {"max-age=60"}}}
+	c, _ := newTestCache(Config{})
+	handler := NewCacheMiddleware(c)(u)
+
+	get(handler, "/items", nil)
+	get(handler, "/items?page=2", nil)
+	get(handler, "/items/1", nil)
+
+	purged, err := c.Purge("/items")
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	assert.Equal(t, resultHit, get(handler, "/items/1", nil).Header().Get(HeaderCache))
+	assert.Equal(t, resultMiss, get(handler, "/items", nil).Header().Get(HeaderCache))
+
+	purged, err = c.Purge("")
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+}
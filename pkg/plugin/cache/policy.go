@@ -0,0 +1,242 @@
+package cache
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/consumer"
+)
+
+// cacheControl holds the directives of a Cache-Control header, directives without a value map to an empty string
+type cacheControl map[string]string
+
+func parseCacheControl(values []string) cacheControl {
+	cc := make(cacheControl)
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+
+			name, arg := directive, ""
+			if i := strings.Index(directive, "="); i >= 0 {
+				name, arg = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+			}
+			cc[strings.ToLower(strings.TrimSpace(name))] = arg
+		}
+	}
+
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// seconds returns the value of a delta-seconds directive
+func (cc cacheControl) seconds(directive string) (time.Duration, bool) {
+	arg, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	return time.Duration(n) * time.Second, true
+}
+
+// bypassRequest checks if the request must not be answered from nor stored in the cache
+func bypassRequest(r *http.Request, cfg Config) bool {
+	if !contains(cfg.Methods, r.Method) {
+		return true
+	}
+
+	// responses to authenticated requests are private unless the credentials are part of the key
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if r.Header.Get(name) != "" && !containsFold(cfg.Key.Headers, name) {
+			return true
+		}
+	}
+
+	return parseCacheControl(r.Header["Cache-Control"]).has("no-store")
+}
+
+// refreshRequest checks if the client asked for a response validated by the upstream
+func refreshRequest(r *http.Request) bool {
+	cc := parseCacheControl(r.Header["Cache-Control"])
+	if cc.has("no-cache") {
+		return true
+	}
+
+	maxAge, ok := cc.seconds("max-age")
+	return ok && maxAge == 0
+}
+
+// lifetime returns for how long a response is fresh and for how long it can be served stale afterwards. The
+// Cache-Control and Expires headers of the upstream win over the configured TTL, false is returned when the
+// response must not be stored.
+func lifetime(statusCode int, header http.Header, cfg Config, now time.Time) (time.Duration, time.Duration, bool) {
+	if !containsInt(cfg.StatusCodes, statusCode) {
+		return 0, 0, false
+	}
+
+	cc := parseCacheControl(header["Cache-Control"])
+	if cc.has("no-store") || cc.has("no-cache") || cc.has("private") {
+		return 0, 0, false
+	}
+
+	if header.Get("Set-Cookie") != "" || containsFold(varyHeaders(header), "*") {
+		return 0, 0, false
+	}
+
+	fresh := time.Duration(cfg.TTL)
+	if maxAge, ok := cc.seconds("s-maxage"); ok {
+		fresh = maxAge
+	} else if maxAge, ok := cc.seconds("max-age"); ok {
+		fresh = maxAge
+	} else if expires := header.Get("Expires"); expires != "" {
+		fresh = 0
+		if t, err := http.ParseTime(expires); err == nil {
+			date := now
+			if d, err := http.ParseTime(header.Get("Date")); err == nil {
+				date = d
+			}
+			fresh = t.Sub(date)
+		}
+	}
+
+	if age, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && age > 0 {
+		fresh -= time.Duration(age) * time.Second
+	}
+
+	if fresh <= 0 {
+		return 0, 0, false
+	}
+
+	stale := time.Duration(cfg.StaleWhileRevalidate)
+	if swr, ok := cc.seconds("stale-while-revalidate"); ok {
+		stale = swr
+	}
+	if cc.has("must-revalidate") || cc.has("proxy-revalidate") {
+		stale = 0
+	}
+
+	return fresh, stale, true
+}
+
+// varyHeaders returns the canonical names of the request headers the response varies on
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, value := range header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	return names
+}
+
+// requestKey builds the cache key of the request from its method, path, query and the configured headers. The
+// requests of a consumer resolved by the auth plugins are cached apart from the others.
+func requestKey(r *http.Request, cfg KeyConfig) string {
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteString(" ")
+	b.WriteString(r.URL.EscapedPath())
+	b.WriteString("?")
+
+	if !cfg.IgnoreQuery {
+		query := r.URL.Query()
+		if len(cfg.QueryParams) > 0 {
+			selected := make(url.Values, len(cfg.QueryParams))
+			for _, param := range cfg.QueryParams {
+				if values, ok := query[param]; ok {
+					selected[param] = values
+				}
+			}
+			query = selected
+		}
+		b.WriteString(query.Encode())
+	}
+
+	writeHeaders(&b, "|", r.Header, cfg.Headers)
+
+	if c, ok := consumer.FromContext(r.Context()); ok {
+		b.WriteString("@")
+		b.WriteString(url.QueryEscape(c.ID))
+	}
+
+	return b.String()
+}
+
+// variantKey builds the key of the variant of a response matching the request headers it varies on
+func variantKey(key string, r *http.Request, vary []string) string {
+	var b strings.Builder
+	b.WriteString(key)
+	writeHeaders(&b, "#", r.Header, vary)
+
+	return b.String()
+}
+
+func writeHeaders(b *strings.Builder, separator string, header http.Header, names []string) {
+	if len(names) == 0 {
+		return
+	}
+
+	sorted := make([]string, len(names))
+	for i, name := range names {
+		sorted[i] = http.CanonicalHeaderKey(name)
+	}
+	sort.Strings(sorted)
+
+	b.WriteString(separator)
+	for i, name := range sorted {
+		if i > 0 {
+			b.WriteString("&")
+		}
+		b.WriteString(url.QueryEscape(name))
+		b.WriteString("=")
+		b.WriteString(url.QueryEscape(strings.Join(header[name], ",")))
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/consumer"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifetime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	cfg := Config{
+		TTL:                  proxy.Duration(10 * time.Second),
+		StaleWhileRevalidate: proxy.Duration(5 * time.Second),
+		StatusCodes:          defaultStatusCodes,
+	}
+
+	tests := []struct {
+		scenario   string
+		statusCode int
+		header     http.Header
+		fresh      time.Duration
+		stale      time.Duration
+		ok         bool
+	}{
+		{
+			scenario:   "when the upstream does not tell, the configured ttl is used",
+			statusCode: http.StatusOK,
+			header:     http.Header{},
+			fresh:      10 * time.Second,
+			stale:      5 * time.Second,
+			ok:         true,
+		},
+		{
+			scenario:   "when the status code is not cacheable",
+			statusCode: http.StatusInternalServerError,
+			header:     http.Header{},
+		},
+		{
+			scenario:   "when s-maxage is given it wins over max-age",
+			statusCode: http.StatusOK,
+			header:     http.Header{"Cache-Control": {"public, max-age=60, s-maxage=120"}},
+			fresh:      120 * time.Second,
+			stale:      5 * time.Second,
+			ok:         true,
+		},
+		{
+			scenario:   "when max-age and stale-while-revalidate are given",
+			statusCode: http.StatusOK,
+			header:     http.Header{"Cache-Control": {"max-age=60, stale-while-revalidate=30"}, "Age": {"20"}},
+			fresh:      40 * time.Second,
+			stale:      30 * time.Second,
+			ok:         true,
+		},
+		{
+			scenario:   "when expires is given",
+			statusCode: http.StatusOK,
+			header: http.Header{
+				"Date":    {now.Format(http.TimeFormat)},
+				"Expires": {now.Add(time.Minute).Format(http.TimeFormat)},
+			},
+			fresh: time.Minute,
+			stale: 5 * time.Second,
+			ok:    true,
+		},
+		{
+			scenario:   "when expires is invalid",
+			statusCode: http.StatusOK,
+			header:     http.Header{"Expires": {"0"}},
+		},
+		{
+			scenario:   "when the response is private",
+			statusCode: http.StatusOK,
+			header:     http.Header{"Cache-Control": {"private, max-age=60"}},
+		},
+		{
+			scenario:   "when the response must not be stored",
+			statusCode: http.StatusOK,
+			header:     http.Header{"Cache-Control": {"no-store"}},
+		},
+		{
+			scenario:   "when the response sets a cookie",
+			statusCode: http.StatusOK,
+			header:     http.Header{"Set-Cookie": {"session=1"}},
+		},
+		{
+			scenario:   "when the response varies on everything",
+			statusCode: http.StatusOK,
+			header:     http.Header{"Vary": {"*"}},
+		},
+		{
+			scenario:   "when the response must be revalidated",
+			statusCode: http.StatusOK,
+			header:     http.Header{"Cache-Control": {"max-age=60, must-revalidate"}},
+			fresh:      time.Minute,
+			ok:         true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			fresh, stale, ok := lifetime(test.statusCode, test.header, cfg, now)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.fresh, fresh)
+			assert.Equal(t, test.stale, stale)
+		})
+	}
+}
+
+func TestRequestKey(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/items?b=2&a=1&utm=x", nil)
+	req.Header.Set("Accept-Language", "de")
+	req.Header.Set("X-Tenant", "hf")
+
+	assert.Equal(t, "GET /items?a=1&b=2&utm=x", requestKey(req, KeyConfig{}))
+	assert.Equal(t, "GET /items?", requestKey(req, KeyConfig{IgnoreQuery: true}))
+	assert.Equal(t, "GET /items?a=1", requestKey(req, KeyConfig{QueryParams: []string{"a", "c"}}))
+	assert.Equal(
+		t,
+		"GET /items?a=1&b=2&utm=x|Accept-Language=de&X-Tenant=hf",
+		requestKey(req, KeyConfig{Headers: []string{"x-tenant", "accept-language"}}),
+	)
+	assert.Equal(t, "key#Accept-Language=de", variantKey("key", req, []string{"Accept-Language"}))
+
+	req = req.WithContext(consumer.WithConsumer(req.Context(), &consumer.Consumer{ID: "alice"}))
+	assert.Equal(t, "GET /items?a=1&b=2&utm=x@alice", requestKey(req, KeyConfig{}))
+}
+
+func TestRequestPolicy(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{Methods: defaultMethods}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.False(t, bypassRequest(req, cfg))
+	assert.False(t, refreshRequest(req))
+
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	assert.True(t, bypassRequest(req, cfg))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	assert.True(t, bypassRequest(req, cfg))
+	assert.False(t, bypassRequest(req, Config{Methods: defaultMethods, Key: KeyConfig{Headers: []string{"authorization"}}}))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cookie", "session=1")
+	assert.True(t, bypassRequest(req, cfg))
+	assert.False(t, bypassRequest(req, Config{Methods: defaultMethods, Key: KeyConfig{Headers: []string{"Cookie"}}}))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cache-Control", "no-store")
+	assert.True(t, bypassRequest(req, cfg))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cache-Control", "max-age=0")
+	assert.True(t, refreshRequest(req))
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// recorder captures the upstream response up to a body limit while it is, optionally, written to the client
+type recorder struct {
+	w           http.ResponseWriter
+	header      http.Header
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+	limit       int64
+	overflow    bool
+}
+
+// newRecorder creates a recorder writing through to w, a nil w only records the response
+func newRecorder(w http.ResponseWriter, limit int64) *recorder {
+	return &recorder{w: w, header: make(http.Header), limit: limit}
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(statusCode int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.statusCode = statusCode
+
+	if r.w != nil {
+		h := r.w.Header()
+		for k, v := range r.header {
+			h[k] = append(h[k], v...)
+		}
+		r.w.WriteHeader(statusCode)
+	}
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	if !r.overflow {
+		if int64(r.body.Len()+len(p)) > r.limit {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(p)
+		}
+	}
+
+	if r.w != nil {
+		return r.w.Write(p)
+	}
+
+	return len(p), nil
+}
+
+// Flush lets the reverse proxy flush streamed responses to the client
+func (r *recorder) Flush() {
+	if f, ok := r.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const scanCount = 100
+
+// redisClient is the part of the redis client the store relies on
+type redisClient interface {
+	Get(key string) *redis.StringCmd
+	Set(key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	Del(keys ...string) *redis.IntCmd
+	Scan(cursor uint64, match string, count int64) *redis.ScanCmd
+	Close() error
+}
+
+// RedisStore is a Store shared by all the Janus instances, entries are kept as JSON under prefixed keys
+type RedisStore struct {
+	client redisClient
+	prefix string
+}
+
+// NewRedisStore creates a new instance of RedisStore
+func NewRedisStore(client redisClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix + ":"}
+}
+
+// Get returns the entry stored under the key
+func (s *RedisStore) Get(key string) (*Entry, error) {
+	data, err := s.client.Get(s.prefix + key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Set stores the entry under the key for the given time
+func (s *RedisStore) Set(key string, entry *Entry, ttl time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(s.prefix+key, data, ttl).Err()
+}
+
+// Purge removes all the entries which key starts with the given prefix
+func (s *RedisStore) Purge(prefix string) (int, error) {
+	var (
+		cursor uint64
+		purged int
+	)
+	match := escapePattern(s.prefix+prefix) + "*"
+	for {
+		keys, next, err := s.client.Scan(cursor, match, scanCount).Result()
+		if err != nil {
+			return purged, err
+		}
+
+		if len(keys) > 0 {
+			n, err := s.client.Del(keys...).Result()
+			purged += int(n)
+			if err != nil {
+				return purged, err
+			}
+		}
+
+		if next == 0 {
+			return purged, nil
+		}
+		cursor = next
+	}
+}
+
+// Close closes the redis connections
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// escapePattern escapes the glob characters of a redis SCAN pattern
+func escapePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`).Replace(s)
+}
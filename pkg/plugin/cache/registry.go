@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"reflect"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Registry holds the caches by API name
+type Registry struct {
+	sync.RWMutex
+	caches map[string]*Cache
+	// replaced are the caches of the changed APIs, the router being replaced may still use them until the reload
+	// is done
+	replaced []*Cache
+}
+
+// NewRegistry creates a new instance of Registry
+func NewRegistry() *Registry {
+	return &Registry{caches: make(map[string]*Cache)}
+}
+
+// Set returns the cache of the given API. The cache of an API that did not change its configuration is reused,
+// so the cached responses survive configuration reloads. A replaced cache is closed by the next Retain.
+func (r *Registry) Set(name string, config Config) (*Cache, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if c, ok := r.caches[name]; ok {
+		if reflect.DeepEqual(c.cfg, config) {
+			return c, nil
+		}
+		r.replaced = append(r.replaced, c)
+	}
+
+	store, err := getStore(config)
+	if err != nil {
+		delete(r.caches, name)
+		return nil, err
+	}
+
+	c := NewCache(name, config, store)
+	r.caches[name] = c
+
+	return c, nil
+}
+
+// Get returns the cache of the given API
+func (r *Registry) Get(name string) (*Cache, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	c, ok := r.caches[name]
+	return c, ok
+}
+
+// Retain closes and removes the caches of all the APIs that are not in the given list, and the replaced caches.
+// It is called once the reloaded router serves the requests.
+func (r *Registry) Retain(names []string) {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for name, c := range r.caches {
+		if !keep[name] {
+			closeCache(c)
+			delete(r.caches, name)
+		}
+	}
+
+	for _, c := range r.replaced {
+		closeCache(c)
+	}
+	r.replaced = nil
+}
+
+func closeCache(c *Cache) {
+	if err := c.Close(); err != nil {
+		log.WithError(err).WithField("api_name", c.name).Warn("Could not close the cache store")
+	}
+}
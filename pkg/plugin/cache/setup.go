@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
+	"github.com/asaskevich/govalidator"
+	"github.com/go-redis/redis"
+	"github.com/hellofresh/janus/pkg/jwt"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/router"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	pluginName = "cache"
+
+	// DefaultPrefix is the default prefix to use for the key in the store.
+	DefaultPrefix = "cache"
+
+	defaultMaxBodySize   = "1M"
+	defaultMaxEntries    = 10000
+	defaultRedisPoolSize = 10
+)
+
+var (
+	registry    = NewRegistry()
+	adminRouter router.Router
+
+	// authPlugins resolve the consumer the responses are cached for, they must run before the cache
+	authPlugins = []string{"basic_auth", "key_auth", "oauth2"}
+
+	defaultMethods     = []string{http.MethodGet, http.MethodHead}
+	defaultStatusCodes = []int{200, 203, 204, 300, 301, 404, 410}
+)
+
+// Config represents the cache configuration
+type Config struct {
+	Policy      string      `json:"policy" valid:"in(local|redis)"`
+	RedisConfig redisConfig `json:"redis"`
+	// TTL is used when the upstream response does not tell for how long it is fresh, zero caches only the
+	// responses that do
+	TTL                  proxy.Duration `json:"ttl"`
+	StaleWhileRevalidate proxy.Duration `json:"stale_while_revalidate"`
+	Methods              []string       `json:"methods"`
+	StatusCodes          []int          `json:"status_codes"`
+	Key                  KeyConfig      `json:"key"`
+	// MaxBodySize is the biggest response body that is cached
+	MaxBodySize string `json:"max_body_size"`
+	// MaxEntries limits the number of responses held by the local policy
+	MaxEntries int `json:"max_entries"`
+}
+
+// KeyConfig represents the parts of the request the cache key is built from, besides its method and path
+type KeyConfig struct {
+	IgnoreQuery bool     `json:"ignore_query"`
+	QueryParams []string `json:"query_params"`
+	Headers     []string `json:"headers"`
+}
+
+type redisConfig struct {
+	DSN      string `json:"dsn"`
+	Prefix   string `json:"prefix"`
+	PoolSize int    `json:"pool_size"`
+}
+
+func init() {
+	plugin.RegisterEventHook(plugin.AdminAPIStartupEvent, onAdminAPIStartup)
+	plugin.RegisterEventHook(plugin.StartupEvent, onStartup)
+	plugin.RegisterEventHook(plugin.ReloadEvent, onReload)
+	plugin.RegisterPlugin(pluginName, plugin.Plugin{
+		Action:   setupCache,
+		Validate: validateConfig,
+	})
+}
+
+func setupCache(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
+	config, err := decodeConfig(rawConfig)
+	if err != nil {
+		return err
+	}
+
+	if err := checkPluginsOrder(def.Plugins); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"plugin_event": plugin.SetupEvent,
+		"plugin":       pluginName,
+		"api_name":     def.Name,
+	}).Debug("Configuring cache plugin")
+
+	var c *Cache
+	if def.Name == "" {
+		store, err := getStore(config)
+		if err != nil {
+			return err
+		}
+		c = NewCache(def.Name, config, store)
+	} else {
+		c, err = registry.Set(def.Name, config)
+		if err != nil {
+			return err
+		}
+	}
+
+	def.AddMiddleware(NewCacheMiddleware(c))
+	return nil
+}
+
+// checkPluginsOrder refuses the auth plugins listed after the cache, the cache would answer the requests before
+// their credentials are checked and share the responses of a consumer with everybody
+func checkPluginsOrder(plugins []string) error {
+	cached := false
+	for _, name := range plugins {
+		if name == pluginName {
+			cached = true
+			continue
+		}
+
+		if cached && contains(authPlugins, name) {
+			return errors.Errorf("the %s plugin must be listed before the cache plugin", name)
+		}
+	}
+
+	return nil
+}
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	config, err := decodeConfig(rawConfig)
+	if err != nil {
+		return false, err
+	}
+
+	return govalidator.ValidateStruct(config)
+}
+
+func decodeConfig(rawConfig plugin.Config) (Config, error) {
+	config := Config{
+		Policy:      "local",
+		Methods:     defaultMethods,
+		StatusCodes: defaultStatusCodes,
+		MaxBodySize: defaultMaxBodySize,
+		MaxEntries:  defaultMaxEntries,
+	}
+	if err := plugin.Decode(rawConfig, &config); err != nil {
+		return config, err
+	}
+
+	if _, err := bytefmt.ToBytes(config.MaxBodySize); err != nil {
+		return config, errors.Wrap(err, "invalid cache max_body_size")
+	}
+
+	return config, nil
+}
+
+func getStore(config Config) (Store, error) {
+	switch config.Policy {
+	case "redis":
+		option, err := redis.ParseURL(config.RedisConfig.DSN)
+		if err != nil {
+			return nil, err
+		}
+		option.PoolSize = defaultRedisPoolSize
+		if config.RedisConfig.PoolSize > 0 {
+			option.PoolSize = config.RedisConfig.PoolSize
+		}
+		option.IdleTimeout = 240 * time.Second
+
+		prefix := config.RedisConfig.Prefix
+		if prefix == "" {
+			prefix = DefaultPrefix
+		}
+
+		return NewRedisStore(redis.NewClient(option), prefix), nil
+
+	case "local":
+		return NewMemoryStore(config.MaxEntries), nil
+
+	default:
+		return nil, ErrInvalidPolicy
+	}
+}
+
+func onAdminAPIStartup(event interface{}) error {
+	e, ok := event.(plugin.OnAdminAPIStartup)
+	if !ok {
+		return errors.New("Could not convert event to admin startup type")
+	}
+
+	adminRouter = e.Router
+	return nil
+}
+
+func onStartup(event interface{}) error {
+	e, ok := event.(plugin.OnStartup)
+	if !ok {
+		return errors.New("Could not convert event to startup type")
+	}
+
+	if adminRouter == nil {
+		return errors.New("invalid admin router given")
+	}
+
+	if e.Config == nil {
+		return errors.New("the web configuration is needed to protect the cache purge endpoint")
+	}
+
+	log.WithFields(log.Fields{
+		"plugin_event": plugin.StartupEvent,
+		"plugin":       pluginName,
+	}).Debug("Registering cache purge endpoint")
+
+	guard := jwt.NewGuard(e.Config.Web.Credentials)
+	group := adminRouter.Group("/cache")
+	group.Use(jwt.NewMiddleware(guard).Handler)
+	{
+		group.DELETE("/{name}", NewPurgeHandler(registry))
+	}
+
+	return nil
+}
+
+// onReload drops the caches of the APIs that were removed
+func onReload(event interface{}) error {
+	e, ok := event.(plugin.OnReload)
+	if !ok {
+		return errors.New("Could not convert event to reload type")
+	}
+
+	names := make([]string, 0, len(e.Configurations))
+	for _, def := range e.Configurations {
+		names = append(names, def.Name)
+	}
+	registry.Retain(names)
+
+	return nil
+}
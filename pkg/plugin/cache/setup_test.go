@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	basejwt "github.com/dgrijalva/jwt-go"
+	"github.com/hellofresh/janus/pkg/api"
+	"github.com/hellofresh/janus/pkg/config"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachePlugin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scenario string
+		function func(*testing.T)
+	}{
+		{
+			scenario: "when the correct cache configuration is given",
+			function: testSetupWithCorrectConfig,
+		},
+		{
+			scenario: "when an incorrect cache configuration is given",
+			function: testSetupWithIncorrectConfig,
+		},
+		{
+			scenario: "when the plugin setup is successful",
+			function: testSetupSuccess,
+		},
+		{
+			scenario: "when an auth plugin is listed after the cache",
+			function: testSetupBeforeAuth,
+		},
+		{
+			scenario: "when the plugin admin startup is successful",
+			function: testAdminStartupSuccess,
+		},
+		{
+			scenario: "when the configuration is reloaded",
+			function: testReload,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			test.function(t)
+		})
+	}
+}
+
+func testSetupWithCorrectConfig(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"policy":                 "redis",
+		"redis":                  map[string]interface{}{"dsn": "redis://localhost:6379", "pool_size": 5},
+		"ttl":                    "1m",
+		"stale_while_revalidate": "30s",
+		"max_body_size":          "512K",
+		"key": map[string]interface{}{
+			"query_params": []string{"page"},
+			"headers":      []string{"Accept-Language"},
+		},
+	}
+
+	result, err := validateConfig(rawConfig)
+	assert.True(t, result)
+	require.NoError(t, err)
+}
+
+func testSetupWithIncorrectConfig(t *testing.T) {
+	for _, rawConfig := range []map[string]interface{}{
+		{"policy": "memcached"},
+		{"max_body_size": "wrong"},
+		{"ttl": "wrong"},
+	} {
+		result, err := validateConfig(rawConfig)
+		assert.False(t, result)
+		assert.Error(t, err)
+	}
+}
+
+func testSetupSuccess(t *testing.T) {
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+
+	err := setupCache(def, make(plugin.Config))
+	require.NoError(t, err)
+	assert.Len(t, def.Middleware(), 1)
+}
+
+func testSetupBeforeAuth(t *testing.T) {
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	def.Plugins = []string{"cors", pluginName, "key_auth"}
+
+	err := setupCache(def, make(plugin.Config))
+	require.Error(t, err)
+	assert.Empty(t, def.Middleware(), "the responses are not cached before the key is checked")
+
+	def = proxy.NewRouterDefinition(proxy.NewDefinition())
+	def.Plugins = []string{"key_auth", pluginName, "rate_limit"}
+
+	require.NoError(t, setupCache(def, make(plugin.Config)))
+	assert.Len(t, def.Middleware(), 1)
+}
+
+func testAdminStartupSuccess(t *testing.T) {
+	r := router.NewChiRouter()
+	err := onAdminAPIStartup(plugin.OnAdminAPIStartup{Router: r})
+	require.NoError(t, err)
+
+	credentials := config.Credentials{Algorithm: "HS256", Secret: "secret"}
+	err = onStartup(plugin.OnStartup{Config: &config.Specification{Web: config.Web{Credentials: credentials}}})
+	require.NoError(t, err)
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	def.Name = "admin-example"
+	require.NoError(t, setupCache(def, map[string]interface{}{"ttl": "1m"}))
+	c, ok := registry.Get("admin-example")
+	require.True(t, ok)
+
+	handler := NewCacheMiddleware(c)(&upstream{})
+	get(handler, "/items", nil)
+	get(handler, "/other", nil)
+
+	token, err := basejwt.NewWithClaims(basejwt.SigningMethodHS256, basejwt.MapClaims{}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	purge := func(target string, authorized bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, target, nil)
+		if authorized {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := purge("/cache/admin-example", false)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "the purge endpoint needs an admin token")
+
+	w = purge("/cache/admin-example?path=/items", true)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"purged": 1}`, w.Body.String())
+
+	w = purge("/cache/admin-example", true)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"purged": 1}`, w.Body.String())
+
+	w = purge("/cache/unknown", true)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func testReload(t *testing.T) {
+	for _, name := range []string{"reload-kept", "reload-removed"} {
+		def := proxy.NewRouterDefinition(proxy.NewDefinition())
+		def.Name = name
+		require.NoError(t, setupCache(def, make(plugin.Config)))
+	}
+	kept, _ := registry.Get("reload-kept")
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	def.Name = "reload-kept"
+	require.NoError(t, setupCache(def, make(plugin.Config)))
+	reused, _ := registry.Get("reload-kept")
+	assert.True(t, kept == reused, "the cache of an unchanged API is reused")
+
+	require.NoError(t, kept.store.Set("reload-kept:GET /items?", &Entry{StatusCode: http.StatusOK}, time.Minute))
+	require.NoError(t, setupCache(def, map[string]interface{}{"ttl": "1m"}))
+	changed, _ := registry.Get("reload-kept")
+	assert.False(t, kept == changed, "the cache of a changed API is replaced")
+	assert.Equal(t, 1, kept.store.(*MemoryStore).Len(), "the replaced cache is open until the reload is done")
+
+	err := onReload(plugin.OnReload{Configurations: []*api.Definition{{Name: "reload-kept"}}})
+	require.NoError(t, err)
+	assert.Equal(t, 0, kept.store.(*MemoryStore).Len(), "the replaced cache is closed once the reload is done")
+
+	_, ok := registry.Get("reload-kept")
+	assert.True(t, ok)
+	_, ok = registry.Get("reload-removed")
+	assert.False(t, ok)
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a cached upstream response
+type Entry struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	// Vary holds the request headers the response varies on. An entry with Vary and no status code is a marker
+	// pointing to the variants of the response, that are stored under their own keys.
+	Vary       []string  `json:"vary,omitempty"`
+	StoredAt   time.Time `json:"stored_at"`
+	FreshUntil time.Time `json:"fresh_until"`
+	StaleUntil time.Time `json:"stale_until"`
+}
+
+// Fresh checks if the entry can be served without going to the upstream
+func (e *Entry) Fresh(now time.Time) bool {
+	return now.Before(e.FreshUntil)
+}
+
+// Stale checks if the entry is not fresh anymore but can still be served while it is revalidated
+func (e *Entry) Stale(now time.Time) bool {
+	return !e.Fresh(now) && now.Before(e.StaleUntil)
+}
+
+// Store persists the cached responses
+type Store interface {
+	// Get returns the entry stored under the key, a nil entry is returned when there is none
+	Get(key string) (*Entry, error)
+	// Set stores the entry under the key for the given time
+	Set(key string, entry *Entry, ttl time.Duration) error
+	// Purge removes all the entries which key starts with the given prefix and returns how many were removed
+	Purge(prefix string) (int, error)
+	// Close releases the resources held by the store
+	Close() error
+}
+
+type memoryItem struct {
+	entry     *Entry
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-process Store, it keeps up to a maximum number of entries
+type MemoryStore struct {
+	sync.Mutex
+	items      map[string]memoryItem
+	maxEntries int
+	now        func() time.Time
+}
+
+// NewMemoryStore creates a new instance of MemoryStore, a maxEntries lower than 1 does not limit the entries
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		items:      make(map[string]memoryItem),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// Get returns the entry stored under the key
+func (s *MemoryStore) Get(key string) (*Entry, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	item, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if !s.now().Before(item.expiresAt) {
+		delete(s.items, key)
+		return nil, nil
+	}
+
+	return item.entry, nil
+}
+
+// Set stores the entry under the key for the given time
+func (s *MemoryStore) Set(key string, entry *Entry, ttl time.Duration) error {
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.now()
+	if _, ok := s.items[key]; !ok && s.maxEntries > 0 && len(s.items) >= s.maxEntries {
+		s.evict(now)
+	}
+
+	s.items[key] = memoryItem{entry: entry, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// Purge removes all the entries which key starts with the given prefix
+func (s *MemoryStore) Purge(prefix string) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	purged := 0
+	for key := range s.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.items, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Close drops all the entries
+func (s *MemoryStore) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.items = make(map[string]memoryItem)
+	return nil
+}
+
+// Len returns the number of stored entries, including the expired ones that were not dropped yet
+func (s *MemoryStore) Len() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return len(s.items)
+}
+
+// evict makes room for a new entry, dropping the expired entries first and the one closest to expire otherwise
+func (s *MemoryStore) evict(now time.Time) {
+	var (
+		oldestKey string
+		oldest    time.Time
+	)
+	for key, item := range s.items {
+		if !now.Before(item.expiresAt) {
+			delete(s.items, key)
+			continue
+		}
+
+		if oldestKey == "" || item.expiresAt.Before(oldest) {
+			oldestKey, oldest = key, item.expiresAt
+		}
+	}
+
+	if len(s.items) >= s.maxEntries && oldestKey != "" {
+		delete(s.items, oldestKey)
+	}
+}
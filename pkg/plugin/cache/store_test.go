@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"net/http"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is an in-process stand-in for the redis client
+type fakeRedis struct {
+	sync.Mutex
+	data map[string][]byte
+	ttls map[string]time.Duration
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{data: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (f *fakeRedis) Get(key string) *redis.StringCmd {
+	f.Lock()
+	defer f.Unlock()
+
+	value, ok := f.data[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(string(value), nil)
+}
+
+func (f *fakeRedis) Set(key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	f.Lock()
+	defer f.Unlock()
+
+	f.data[key] = value.([]byte)
+	f.ttls[key] = expiration
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeRedis) Del(keys ...string) *redis.IntCmd {
+	f.Lock()
+	defer f.Unlock()
+
+	var n int64
+	for _, key := range keys {
+		if _, ok := f.data[key]; ok {
+			delete(f.data, key)
+			n++
+		}
+	}
+	return redis.NewIntResult(n, nil)
+}
+
+// Scan returns one key per call to exercise the cursor handling
+func (f *fakeRedis) Scan(cursor uint64, match string, count int64) *redis.ScanCmd {
+	f.Lock()
+	defer f.Unlock()
+
+	var keys []string
+	for key := range f.data {
+		if ok, _ := path.Match(match, key); ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return redis.NewScanCmdResult(nil, 0, nil)
+	}
+	return redis.NewScanCmdResult(keys[:1], 1, nil)
+}
+
+func (f *fakeRedis) Close() error {
+	return nil
+}
+
+func TestStores(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scenario string
+		store    func() Store
+	}{
+		{
+			scenario: "memory store",
+			store:    func() Store { return NewMemoryStore(0) },
+		},
+		{
+			scenario: "redis store",
+			store:    func() Store { return NewRedisStore(newFakeRedis(), DefaultPrefix) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			store := test.store()
+
+			entry, err := store.Get("api:GET /a?")
+			require.NoError(t, err)
+			assert.Nil(t, entry)
+
+			stored := &Entry{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"text/plain"}}, Body: []byte("a")}
+			require.NoError(t, store.Set("api:GET /a?", stored, time.Minute))
+			require.NoError(t, store.Set("api:GET /a?x=1", stored, time.Minute))
+			require.NoError(t, store.Set("api:GET /b*?", stored, time.Minute))
+			require.NoError(t, store.Set("other:GET /a?", stored, time.Minute))
+
+			entry, err = store.Get("api:GET /a?")
+			require.NoError(t, err)
+			require.NotNil(t, entry)
+			assert.Equal(t, http.StatusOK, entry.StatusCode)
+			assert.Equal(t, []byte("a"), entry.Body)
+			assert.Equal(t, "text/plain", entry.Header.Get("Content-Type"))
+
+			purged, err := store.Purge("api:GET /a?")
+			require.NoError(t, err)
+			assert.Equal(t, 2, purged)
+
+			purged, err = store.Purge("api:GET /b*")
+			require.NoError(t, err)
+			assert.Equal(t, 1, purged)
+
+			entry, err = store.Get("other:GET /a?")
+			require.NoError(t, err)
+			assert.NotNil(t, entry)
+			require.NoError(t, store.Close())
+		})
+	}
+}
+
+func TestMemoryStoreExpiration(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	store := NewMemoryStore(2)
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Set("a", &Entry{}, time.Second))
+	require.NoError(t, store.Set("b", &Entry{}, time.Minute))
+	require.NoError(t, store.Set("c", &Entry{}, time.Hour))
+	assert.Equal(t, 2, store.Len(), "the entry closest to expire is evicted")
+
+	entry, _ := store.Get("a")
+	assert.Nil(t, entry)
+
+	now = now.Add(2 * time.Minute)
+	entry, _ = store.Get("b")
+	assert.Nil(t, entry, "expired entries are not returned")
+	entry, _ = store.Get("c")
+	assert.NotNil(t, entry)
+}
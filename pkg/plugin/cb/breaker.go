@@ -0,0 +1,222 @@
+package cb
+
+import (
+	"sync"
+	"time"
+)
+
+// Breaker states
+const (
+	StateClosed   = "closed"
+	StateHalfOpen = "half-open"
+	StateOpen     = "open"
+)
+
+const (
+	windowBuckets = 10
+	bucketSize    = time.Second
+)
+
+// BreakerConfig represents the configuration of the circuit breaker of a single target
+type BreakerConfig struct {
+	// ErrorPercentThreshold opens the circuit once the percentage of failed requests in the rolling window reaches it
+	ErrorPercentThreshold int
+	// RequestVolumeThreshold is the minimum number of requests in the rolling window to open the circuit
+	RequestVolumeThreshold int
+	// SleepWindow is the time the circuit stays open before it lets probe requests through
+	SleepWindow time.Duration
+	// HalfOpenProbes is the number of successful probe requests needed to close the circuit again
+	HalfOpenProbes int
+}
+
+// BreakerStatus represents the state of a single target circuit breaker
+type BreakerStatus struct {
+	Target   string    `json:"target"`
+	State    string    `json:"state"`
+	Requests int       `json:"requests"`
+	Errors   int       `json:"errors"`
+	OpenedAt time.Time `json:"opened_at,omitempty"`
+}
+
+type bucket struct {
+	start    time.Time
+	requests int
+	errors   int
+}
+
+// ticket identifies the request a breaker allowed, so its outcome is only counted for the state it was allowed in
+type ticket struct {
+	generation uint64
+	probe      bool
+}
+
+// Breaker is the circuit breaker of a single upstream target. It is closed while the target works, opens when
+// too many of the requests in the rolling window fail, and after the sleep window lets a few probe requests
+// through (half-open) to decide whether the target recovered.
+type Breaker struct {
+	sync.Mutex
+	config BreakerConfig
+	now    func() time.Time
+
+	state          string
+	generation     uint64
+	buckets        [windowBuckets]bucket
+	openedAt       time.Time
+	probesInFlight int
+	probeSuccesses int
+	onChange       func(state string)
+}
+
+// NewBreaker creates a new closed circuit breaker
+func NewBreaker(config BreakerConfig) *Breaker {
+	return &Breaker{config: config, now: time.Now, state: StateClosed}
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() string {
+	b.Lock()
+	defer b.Unlock()
+
+	b.tryHalfOpen()
+	return b.state
+}
+
+// IsAvailable checks if the breaker would allow a request right now
+func (b *Breaker) IsAvailable() bool {
+	b.Lock()
+	defer b.Unlock()
+
+	b.tryHalfOpen()
+	switch b.state {
+	case StateClosed:
+		return true
+	case StateHalfOpen:
+		return b.probesInFlight < b.config.HalfOpenProbes
+	default:
+		return false
+	}
+}
+
+// allow checks if a request can be sent to the target, in the half-open state it reserves one of the probes
+func (b *Breaker) allow() (ticket, bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.tryHalfOpen()
+	switch b.state {
+	case StateClosed:
+		return ticket{generation: b.generation}, true
+	case StateHalfOpen:
+		if b.probesInFlight >= b.config.HalfOpenProbes {
+			return ticket{}, false
+		}
+		b.probesInFlight++
+		return ticket{generation: b.generation, probe: true}, true
+	default:
+		return ticket{}, false
+	}
+}
+
+// done records the outcome of a request the breaker allowed
+func (b *Breaker) done(t ticket, success bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	// the state changed since the request was allowed
+	if t.generation != b.generation {
+		return
+	}
+
+	if t.probe {
+		b.probesInFlight--
+		if !success {
+			b.setState(StateOpen)
+			return
+		}
+
+		b.probeSuccesses++
+		if b.probeSuccesses >= b.config.HalfOpenProbes {
+			b.setState(StateClosed)
+		}
+		return
+	}
+
+	current := b.bucket()
+	current.requests++
+	if success {
+		return
+	}
+	current.errors++
+
+	requests, errors := b.counts()
+	if requests >= b.config.RequestVolumeThreshold && errors*100 >= b.config.ErrorPercentThreshold*requests {
+		b.setState(StateOpen)
+	}
+}
+
+// status returns a snapshot of the breaker state
+func (b *Breaker) status(target string) BreakerStatus {
+	b.Lock()
+	defer b.Unlock()
+
+	b.tryHalfOpen()
+	requests, errors := b.counts()
+	status := BreakerStatus{Target: target, State: b.state, Requests: requests, Errors: errors}
+	if b.state != StateClosed {
+		status.OpenedAt = b.openedAt
+	}
+
+	return status
+}
+
+// tryHalfOpen moves an open breaker to half-open once the sleep window is over, it must be called holding the lock
+func (b *Breaker) tryHalfOpen() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.config.SleepWindow {
+		b.setState(StateHalfOpen)
+	}
+}
+
+// setState must be called holding the lock
+func (b *Breaker) setState(state string) {
+	b.state = state
+	b.generation++
+	b.probesInFlight = 0
+	b.probeSuccesses = 0
+
+	switch state {
+	case StateOpen:
+		b.openedAt = b.now()
+	case StateClosed:
+		b.buckets = [windowBuckets]bucket{}
+	}
+
+	if b.onChange != nil {
+		b.onChange(state)
+	}
+}
+
+// bucket returns the bucket of the current second, it must be called holding the lock
+func (b *Breaker) bucket() *bucket {
+	now := b.now().Truncate(bucketSize)
+	current := &b.buckets[(now.Unix()/int64(bucketSize/time.Second))%windowBuckets]
+	if !current.start.Equal(now) {
+		*current = bucket{start: now}
+	}
+
+	return current
+}
+
+// counts sums the requests and errors in the rolling window, it must be called holding the lock
+func (b *Breaker) counts() (int, int) {
+	oldest := b.now().Truncate(bucketSize).Add(-(windowBuckets - 1) * bucketSize)
+
+	var requests, errors int
+	for _, bucket := range b.buckets {
+		if !bucket.start.Before(oldest) {
+			requests += bucket.requests
+			errors += bucket.errors
+		}
+	}
+
+	return requests, errors
+}
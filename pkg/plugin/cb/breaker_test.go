@@ -0,0 +1,123 @@
+package cb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBreaker() (*Breaker, *time.Time) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBreaker(BreakerConfig{
+		ErrorPercentThreshold:  50,
+		RequestVolumeThreshold: 4,
+		SleepWindow:            5 * time.Second,
+		HalfOpenProbes:         2,
+	})
+	b.now = func() time.Time { return now }
+
+	return b, &now
+}
+
+func request(t *testing.T, b *Breaker, success bool) {
+	tk, ok := b.allow()
+	require.True(t, ok)
+	b.done(tk, success)
+}
+
+func TestBreakerOpensOnErrors(t *testing.T) {
+	b, _ := newTestBreaker()
+
+	request(t, b, true)
+	request(t, b, false)
+	request(t, b, true)
+	assert.Equal(t, StateClosed, b.State(), "under the request volume threshold")
+
+	request(t, b, false)
+	assert.Equal(t, StateOpen, b.State())
+	assert.False(t, b.IsAvailable())
+
+	_, ok := b.allow()
+	assert.False(t, ok)
+}
+
+func TestBreakerRollingWindow(t *testing.T) {
+	b, now := newTestBreaker()
+
+	request(t, b, false)
+	request(t, b, false)
+	request(t, b, false)
+
+	*now = now.Add(windowBuckets * bucketSize)
+	request(t, b, false)
+	assert.Equal(t, StateClosed, b.State(), "the old errors left the window")
+
+	*now = now.Add(bucketSize)
+	request(t, b, true)
+	request(t, b, false)
+	request(t, b, false)
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	b, now := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		request(t, b, false)
+	}
+	require.Equal(t, StateOpen, b.State())
+
+	*now = now.Add(5 * time.Second)
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.True(t, b.IsAvailable())
+
+	first, ok := b.allow()
+	require.True(t, ok)
+	second, ok := b.allow()
+	require.True(t, ok)
+	_, ok = b.allow()
+	assert.False(t, ok, "only the probes are let through")
+	assert.False(t, b.IsAvailable())
+
+	b.done(first, true)
+	assert.Equal(t, StateHalfOpen, b.State())
+	b.done(second, true)
+	assert.Equal(t, StateClosed, b.State())
+
+	status := b.status("http://target")
+	assert.Equal(t, BreakerStatus{Target: "http://target", State: StateClosed}, status)
+}
+
+func TestBreakerFailedProbeReopens(t *testing.T) {
+	b, now := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		request(t, b, false)
+	}
+
+	*now = now.Add(5 * time.Second)
+	probe, ok := b.allow()
+	require.True(t, ok)
+	b.done(probe, false)
+
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, *now, b.status("http://target").OpenedAt)
+}
+
+func TestBreakerIgnoresStaleOutcomes(t *testing.T) {
+	b, now := newTestBreaker()
+
+	stale, ok := b.allow()
+	require.True(t, ok)
+	for i := 0; i < 4; i++ {
+		request(t, b, false)
+	}
+
+	*now = now.Add(5 * time.Second)
+	require.Equal(t, StateHalfOpen, b.State())
+
+	// the outcome of a request allowed while closed does not count as a probe
+	b.done(stale, true)
+	b.done(stale, true)
+	assert.Equal(t, StateHalfOpen, b.State())
+}
@@ -0,0 +1,195 @@
+package cb
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	obs "github.com/hellofresh/janus/pkg/observability"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+var stateValues = map[string]int64{
+	StateClosed:   0,
+	StateHalfOpen: 1,
+	StateOpen:     2,
+}
+
+// Breakers holds the circuit breakers of the upstream targets of an API
+type Breakers struct {
+	sync.Mutex
+	name     string
+	config   BreakerConfig
+	breakers map[string]*Breaker
+	// inFlight limits the number of concurrent requests, it is nil when there is no limit
+	inFlight chan struct{}
+}
+
+// NewBreakers creates the circuit breakers of the given API
+func NewBreakers(name string, config BreakerConfig, maxConcurrentRequests int) *Breakers {
+	b := &Breakers{
+		name:     name,
+		config:   config,
+		breakers: make(map[string]*Breaker),
+	}
+	if maxConcurrentRequests > 0 {
+		b.inFlight = make(chan struct{}, maxConcurrentRequests)
+	}
+
+	return b
+}
+
+// Get returns the circuit breaker of the given target, creating it if needed
+func (b *Breakers) Get(target string) *Breaker {
+	b.Lock()
+	defer b.Unlock()
+
+	breaker, ok := b.breakers[target]
+	if !ok {
+		breaker = NewBreaker(b.config)
+		breaker.onChange = func(state string) { b.stateChanged(target, state) }
+		b.breakers[target] = breaker
+	}
+
+	return breaker
+}
+
+// Statuses returns a snapshot of the targets circuit breakers state, sorted by target
+func (b *Breakers) Statuses() []BreakerStatus {
+	b.Lock()
+	breakers := make(map[string]*Breaker, len(b.breakers))
+	for target, breaker := range b.breakers {
+		breakers[target] = breaker
+	}
+	b.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(breakers))
+	for target, breaker := range breakers {
+		statuses = append(statuses, breaker.status(target))
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Target < statuses[j].Target
+	})
+
+	return statuses
+}
+
+// acquire takes one of the concurrent requests slots, it returns false when all of them are taken
+func (b *Breakers) acquire() bool {
+	if b.inFlight == nil {
+		return true
+	}
+
+	select {
+	case b.inFlight <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *Breakers) release() {
+	if b.inFlight != nil {
+		<-b.inFlight
+	}
+}
+
+func (b *Breakers) stateChanged(target string, state string) {
+	log.WithFields(log.Fields{
+		"api_name": b.name,
+		"target":   target,
+		"state":    state,
+	}).Warn("Circuit breaker state changed")
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(obs.KeyAPIName, b.name),
+		tag.Insert(obs.KeyUpstreamTarget, target),
+	)
+	if err != nil {
+		log.WithError(err).Debug("Failed to tag circuit breaker metrics")
+		return
+	}
+	stats.Record(ctx, obs.MCircuitBreakerState.M(stateValues[state]))
+}
+
+// Registry holds the circuit breakers by API name
+type Registry struct {
+	sync.RWMutex
+	breakers map[string]*Breakers
+	configs  map[string]registryConfig
+}
+
+type registryConfig struct {
+	breaker               BreakerConfig
+	maxConcurrentRequests int
+}
+
+// NewRegistry creates a new instance of Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		breakers: make(map[string]*Breakers),
+		configs:  make(map[string]registryConfig),
+	}
+}
+
+// Set returns the circuit breakers of the given API. The breakers of an API that did not change its
+// configuration are reused, so their state survives configuration reloads.
+func (r *Registry) Set(name string, config BreakerConfig, maxConcurrentRequests int) *Breakers {
+	r.Lock()
+	defer r.Unlock()
+
+	c := registryConfig{breaker: config, maxConcurrentRequests: maxConcurrentRequests}
+	if b, ok := r.breakers[name]; ok && r.configs[name] == c {
+		return b
+	}
+
+	b := NewBreakers(name, config, maxConcurrentRequests)
+	r.breakers[name] = b
+	r.configs[name] = c
+
+	return b
+}
+
+// Get returns the circuit breakers of the given API
+func (r *Registry) Get(name string) (*Breakers, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	b, ok := r.breakers[name]
+	return b, ok
+}
+
+// Retain removes the circuit breakers of all the APIs that are not in the given list
+func (r *Registry) Retain(names []string) {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for name := range r.breakers {
+		if !keep[name] {
+			delete(r.breakers, name)
+			delete(r.configs, name)
+		}
+	}
+}
+
+// Statuses returns the circuit breakers state of all the APIs
+func (r *Registry) Statuses() map[string][]BreakerStatus {
+	r.RLock()
+	defer r.RUnlock()
+
+	statuses := make(map[string][]BreakerStatus, len(r.breakers))
+	for name, b := range r.breakers {
+		statuses[name] = b.Statuses()
+	}
+
+	return statuses
+}
@@ -0,0 +1,29 @@
+package cb
+
+import (
+	"net/http"
+
+	janusErr "github.com/hellofresh/janus/pkg/errors"
+)
+
+// Fallback represents the response sent when a request is rejected by the circuit breaker
+type Fallback struct {
+	StatusCode int               `json:"status_code" valid:"range(0|599)"`
+	Body       string            `json:"body"`
+	Headers    map[string]string `json:"headers"`
+}
+
+// write sends the fallback response, or the given error if there is no fallback response configured
+func (f Fallback) write(w http.ResponseWriter, err *janusErr.Error) {
+	if f.StatusCode == 0 {
+		janusErr.Handler(w, err)
+		return
+	}
+
+	for name, value := range f.Headers {
+		w.Header().Set(name, value)
+	}
+
+	w.WriteHeader(f.StatusCode)
+	w.Write([]byte(f.Body))
+}
@@ -0,0 +1,32 @@
+package cb
+
+import (
+	"net/http"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/render"
+	"github.com/hellofresh/janus/pkg/router"
+)
+
+// ErrBreakersNotFound is used when the API does not use the circuit breaker plugin
+var ErrBreakersNotFound = errors.New(http.StatusNotFound, "circuit breaker is not enabled for this api")
+
+// NewStatusesHandler creates the handler of the circuit breakers state of all the APIs
+func NewStatusesHandler(registry *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, http.StatusOK, registry.Statuses())
+	}
+}
+
+// NewStatusHandler creates the handler of the circuit breakers state of the targets of an API
+func NewStatusHandler(registry *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		breakers, ok := registry.Get(router.URLParam(r, "name"))
+		if !ok {
+			errors.Handler(w, ErrBreakersNotFound)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, breakers.Statuses())
+	}
+}
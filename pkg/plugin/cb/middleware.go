@@ -1,65 +1,225 @@
 package cb
 
 import (
+	"context"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/Knetic/govaluate"
-	"github.com/afex/hystrix-go/hystrix"
 	"github.com/felixge/httpsnoop"
 	janusErr "github.com/hellofresh/janus/pkg/errors"
-	"github.com/pkg/errors"
+	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/hellofresh/janus/pkg/proxy"
 	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 )
 
 const (
 	defaultPredicate = "statusCode == 0 || statusCode >= 500"
+
+	rejectedOpen          = "open"
+	rejectedMaxConcurrent = "max_concurrent"
+)
+
+var (
+	// ErrCircuitOpen is used when the circuit breaker of the upstream target is open
+	ErrCircuitOpen = janusErr.New(http.StatusServiceUnavailable, "circuit breaker is open")
+	// ErrMaxConcurrentRequests is used when the API is already handling the maximum number of concurrent requests
+	ErrMaxConcurrentRequests = janusErr.New(http.StatusServiceUnavailable, "too many concurrent requests")
 )
 
-// NewCBMiddleware creates a new cb middleware
-func NewCBMiddleware(cfg Config) func(http.Handler) http.Handler {
+// NewCBMiddleware creates a new cb middleware. The requests are only sent to the upstream targets whose circuit
+// breaker allows it, when none does the fallback response is sent instead.
+func NewCBMiddleware(cfg Config, breakers *Breakers) func(http.Handler) http.Handler {
+	if cfg.Predicate == "" {
+		cfg.Predicate = defaultPredicate
+	}
+
+	expression, expressionErr := govaluate.NewEvaluableExpression(cfg.Predicate)
+
 	return func(handler http.Handler) http.Handler {
+		if expressionErr != nil {
+			log.WithError(expressionErr).Error("could not create an expression with this predicate")
+			return handler
+		}
+
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			logger := log.WithFields(log.Fields{
-				"name":                    cfg.Name,
-				"timeout":                 cfg.Timeout,
-				"max_concurrent_requests": cfg.MaxConcurrentRequests,
-				"error_percent_threshold": cfg.ErrorPercentThreshold,
-			})
-
-			logger.Debug("Starting cb middleware")
-			if cfg.Predicate == "" {
-				cfg.Predicate = defaultPredicate
+			if !breakers.acquire() {
+				log.WithField("api_name", breakers.name).Debug("Too many concurrent requests on the cb middleware")
+				recordRejected(breakers.name, rejectedMaxConcurrent)
+				cfg.Fallback.write(w, ErrMaxConcurrentRequests)
+				return
+			}
+			defer breakers.release()
+
+			ctx := r.Context()
+			if cfg.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Millisecond)
+				defer cancel()
+			}
+
+			guard := &requestGuard{breakers: breakers}
+			gw := &guardedWriter{guard: guard, code: http.StatusOK}
+			handler.ServeHTTP(gw.wrap(w), r.WithContext(proxy.WithTargetGuard(ctx, guard)))
+
+			if gw.suppressed {
+				log.WithField("api_name", breakers.name).Debug("Request rejected by the circuit breaker")
+				recordRejected(breakers.name, rejectedOpen)
+				cfg.Fallback.write(w, ErrCircuitOpen)
+				return
 			}
 
-			expression, err := govaluate.NewEvaluableExpression(cfg.Predicate)
+			params := make(map[string]interface{}, 8)
+			params["statusCode"] = gw.code
+			params["request"] = r
+
+			result, err := expression.Evaluate(params)
 			if err != nil {
-				log.WithError(err).Error("could not create an expression with this predicate")
-				handler.ServeHTTP(w, r)
+				log.WithError(err).Error("cannot evaluate the expression")
+				guard.finish(true)
 				return
 			}
 
-			err = hystrix.Do(cfg.Name, func() error {
-				m := httpsnoop.CaptureMetrics(handler, w, r)
-				params := make(map[string]interface{}, 8)
-				params["statusCode"] = m.Code
-				params["request"] = r
+			failed, _ := result.(bool)
+			guard.finish(!failed)
+		})
+	}
+}
 
-				result, err := expression.Evaluate(params)
-				if err != nil {
-					return errors.New("cannot evaluate the expression")
-				}
+// requestGuard guards the targets a single request is sent to with their circuit breakers
+type requestGuard struct {
+	sync.Mutex
+	breakers *Breakers
+	breaker  *Breaker
+	ticket   ticket
+	rejected bool
+}
 
-				if result.(bool) {
-					return errors.Errorf("%s %s request failed", r.Method, r.URL)
-				}
+// IsHealthy implements proxy.TargetGuard
+func (g *requestGuard) IsHealthy(target string) bool {
+	return g.breakers.Get(target).IsAvailable()
+}
 
-				return nil
-			}, nil)
+// Allow implements proxy.TargetGuard
+func (g *requestGuard) Allow(target string) error {
+	g.Lock()
+	defer g.Unlock()
 
-			if err != nil {
-				logger.WithError(err).Error("Request failed on the cb middleware")
-				janusErr.Handler(w, errors.Wrap(err, "request failed"))
+	// the request is sent again, e.g. by the retry plugin, so the previous attempt failed
+	g.finishLocked(false)
+
+	breaker := g.breakers.Get(target)
+	t, ok := breaker.allow()
+	if !ok {
+		g.rejected = true
+		return ErrCircuitOpen
+	}
+
+	g.breaker = breaker
+	g.ticket = t
+	g.rejected = false
+	return nil
+}
+
+func (g *requestGuard) isRejected() bool {
+	g.Lock()
+	defer g.Unlock()
+
+	return g.rejected
+}
+
+// finish records the outcome of the request in the breaker of the target it was sent to
+func (g *requestGuard) finish(success bool) {
+	g.Lock()
+	defer g.Unlock()
+
+	g.finishLocked(success)
+}
+
+func (g *requestGuard) finishLocked(success bool) {
+	if g.breaker != nil {
+		g.breaker.done(g.ticket, success)
+		g.breaker = nil
+	}
+}
+
+// guardedWriter captures the response status code and discards the response of the requests the guard rejected,
+// so the fallback response can be sent instead
+type guardedWriter struct {
+	guard       *requestGuard
+	code        int
+	wroteHeader bool
+	suppressed  bool
+}
+
+func (gw *guardedWriter) wrap(w http.ResponseWriter) http.ResponseWriter {
+	return httpsnoop.Wrap(w, httpsnoop.Hooks{
+		WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+			return func(code int) {
+				if gw.writeHeader(code) {
+					next(code)
+				}
 			}
-		})
+		},
+		Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+			return func(p []byte) (int, error) {
+				if !gw.wroteHeader {
+					gw.writeHeader(http.StatusOK)
+				}
+				if gw.suppressed {
+					return len(p), nil
+				}
+				return next(p)
+			}
+		},
+		ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+			return func(src io.Reader) (int64, error) {
+				if !gw.wroteHeader {
+					gw.writeHeader(http.StatusOK)
+				}
+				if gw.suppressed {
+					return io.Copy(ioutil.Discard, src)
+				}
+				return next(src)
+			}
+		},
+		Flush: func(next httpsnoop.FlushFunc) httpsnoop.FlushFunc {
+			return func() {
+				if !gw.suppressed {
+					next()
+				}
+			}
+		},
+	})
+}
+
+// writeHeader records the status code and checks if the response is sent to the client
+func (gw *guardedWriter) writeHeader(code int) bool {
+	if gw.wroteHeader {
+		return !gw.suppressed
+	}
+
+	gw.wroteHeader = true
+	gw.code = code
+	gw.suppressed = gw.guard.isRejected()
+
+	return !gw.suppressed
+}
+
+func recordRejected(name string, reason string) {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(obs.KeyAPIName, name),
+		tag.Insert(obs.KeyResult, reason),
+	)
+	if err != nil {
+		log.WithError(err).Debug("Failed to tag circuit breaker metrics")
+		return
 	}
+	stats.Record(ctx, obs.MCircuitBreakerRejected.M(1))
 }
@@ -3,10 +3,17 @@ package cb
 import (
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/hellofresh/janus/pkg/proxy/discovery"
 	"github.com/hellofresh/janus/pkg/test"
+	"github.com/hellofresh/stats-go/client"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMiddleware(t *testing.T) {
@@ -39,12 +46,21 @@ func TestMiddleware(t *testing.T) {
 	}
 }
 
+func newTestBreakers(cfg Config) *Breakers {
+	return NewBreakers("example", BreakerConfig{
+		ErrorPercentThreshold:  cfg.ErrorPercentThreshold,
+		RequestVolumeThreshold: cfg.RequestVolumeThreshold,
+		SleepWindow:            time.Duration(cfg.SleepWindow) * time.Millisecond,
+		HalfOpenProbes:         cfg.HalfOpenProbes,
+	}, cfg.MaxConcurrentRequests)
+}
+
 func testWrongPredicate(t *testing.T, r *http.Request, w *httptest.ResponseRecorder) {
 	cfg := Config{
 		Name:      "example",
 		Predicate: "this is wrong",
 	}
-	mw := NewCBMiddleware(cfg)
+	mw := NewCBMiddleware(cfg, newTestBreakers(cfg))
 
 	mw(http.HandlerFunc(test.Ping)).ServeHTTP(w, r)
 
@@ -52,7 +68,8 @@ func testWrongPredicate(t *testing.T, r *http.Request, w *httptest.ResponseRecor
 }
 
 func testSuccessfulUpstreamRetry(t *testing.T, r *http.Request, w *httptest.ResponseRecorder) {
-	mw := NewCBMiddleware(Config{Name: "example"})
+	cfg := Config{Name: "example"}
+	mw := NewCBMiddleware(cfg, newTestBreakers(cfg))
 
 	mw(http.HandlerFunc(test.Ping)).ServeHTTP(w, r)
 
@@ -60,9 +77,123 @@ func testSuccessfulUpstreamRetry(t *testing.T, r *http.Request, w *httptest.Resp
 }
 
 func testFailedUpstreamRetry(t *testing.T, r *http.Request, w *httptest.ResponseRecorder) {
-	mw := NewCBMiddleware(Config{Name: "example"})
+	cfg := Config{Name: "example"}
+	mw := NewCBMiddleware(cfg, newTestBreakers(cfg))
 
 	mw(test.FailWith(http.StatusBadGateway)).ServeHTTP(w, r)
 
 	assert.Equal(t, http.StatusBadGateway, w.Code)
 }
+
+func newTestProxy(targets ...string) http.Handler {
+	def := proxy.NewDefinition()
+	def.Upstreams.Balancing = "roundrobin"
+
+	var source discovery.Static
+	for _, target := range targets {
+		source = append(source, &balancer.Target{Target: target})
+	}
+
+	return proxy.NewBalancedReverseProxy(def, source, balancer.NewRoundrobinBalancer(), client.NewNoop(), nil)
+}
+
+func TestMiddlewareOpensPerTarget(t *testing.T) {
+	failing := httptest.NewServer(test.FailWith(http.StatusInternalServerError))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(test.Ping))
+	defer healthy.Close()
+
+	cfg := Config{ErrorPercentThreshold: 50, RequestVolumeThreshold: 2, SleepWindow: 60000, HalfOpenProbes: 1}
+	breakers := newTestBreakers(cfg)
+	handler := NewCBMiddleware(cfg, breakers)(newTestProxy(failing.URL, healthy.URL))
+
+	for i := 0; i < 4; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	assert.Equal(t, StateOpen, breakers.Get(failing.URL).State())
+	assert.Equal(t, StateClosed, breakers.Get(healthy.URL).State())
+
+	// the open target is not elected anymore
+	for i := 0; i < 4; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	states := make(map[string]string)
+	for _, status := range breakers.Statuses() {
+		states[status.Target] = status.State
+	}
+	assert.Equal(t, map[string]string{failing.URL: StateOpen, healthy.URL: StateClosed}, states)
+}
+
+func TestMiddlewareFallback(t *testing.T) {
+	failing := httptest.NewServer(test.FailWith(http.StatusInternalServerError))
+	defer failing.Close()
+
+	tests := []struct {
+		scenario string
+		fallback Fallback
+		code     int
+		body     string
+	}{
+		{
+			scenario: "default fallback",
+			code:     http.StatusServiceUnavailable,
+			body:     `{"error":"circuit breaker is open"}`,
+		},
+		{
+			scenario: "configured fallback",
+			fallback: Fallback{StatusCode: http.StatusOK, Body: `{"items":[]}`, Headers: map[string]string{"Content-Type": "application/json"}},
+			code:     http.StatusOK,
+			body:     `{"items":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			cfg := Config{ErrorPercentThreshold: 50, RequestVolumeThreshold: 1, SleepWindow: 60000, HalfOpenProbes: 1, Fallback: tt.fallback}
+			handler := NewCBMiddleware(cfg, newTestBreakers(cfg))(newTestProxy(failing.URL))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			require.Equal(t, http.StatusInternalServerError, w.Code)
+
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, tt.code, w.Code)
+			assert.JSONEq(t, tt.body, w.Body.String())
+			for name, value := range tt.fallback.Headers {
+				assert.Equal(t, value, w.Header().Get(name))
+			}
+		})
+	}
+}
+
+func TestMiddlewareMaxConcurrentRequests(t *testing.T) {
+	cfg := Config{MaxConcurrentRequests: 1}
+	mw := NewCBMiddleware(cfg, newTestBreakers(cfg))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	close(release)
+	wg.Wait()
+}
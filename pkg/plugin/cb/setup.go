@@ -1,9 +1,8 @@
 package cb
 
 import (
-	"github.com/afex/hystrix-go/hystrix"
-	"github.com/afex/hystrix-go/hystrix/metric_collector"
-	"github.com/afex/hystrix-go/plugins"
+	"time"
+
 	"github.com/asaskevich/govalidator"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
@@ -12,20 +11,37 @@ import (
 )
 
 const (
-	statsdPrefix = "hystrix"
-	pluginName   = "cb"
+	pluginName = "cb"
+
+	defaultTimeout                = 1000
+	defaultMaxConcurrentRequests  = 10
+	defaultErrorPercentThreshold  = 50
+	defaultRequestVolumeThreshold = 20
+	defaultSleepWindow            = 5000
+	defaultHalfOpenProbes         = 1
 )
 
-// Config represents the Body Limit configuration
+var registry = NewRegistry()
+
+// Config represents the Circuit Breaker configuration
 type Config struct {
-	hystrix.CommandConfig
-	Name      string `json:"name"`
-	Predicate string `json:"predicate"`
+	// Timeout is the time in milliseconds the requests are given to respond
+	Timeout               int `json:"timeout"`
+	MaxConcurrentRequests int `json:"max_concurrent_requests"`
+	// ErrorPercentThreshold opens the circuit of a target once the percentage of failed requests reaches it
+	ErrorPercentThreshold  int `json:"error_percent_threshold" valid:"range(0|100)"`
+	RequestVolumeThreshold int `json:"request_volume_threshold"`
+	// SleepWindow is the time in milliseconds a circuit stays open before it lets probe requests through
+	SleepWindow    int      `json:"sleep_window"`
+	HalfOpenProbes int      `json:"half_open_probes"`
+	Name           string   `json:"name"`
+	Predicate      string   `json:"predicate"`
+	Fallback       Fallback `json:"fallback"`
 }
 
 func init() {
-	plugin.RegisterEventHook(plugin.StartupEvent, onStartup)
 	plugin.RegisterEventHook(plugin.AdminAPIStartupEvent, onAdminAPIStartup)
+	plugin.RegisterEventHook(plugin.ReloadEvent, onReload)
 	plugin.RegisterPlugin(pluginName, plugin.Plugin{
 		Action:   setupCB,
 		Validate: validateConfig,
@@ -33,8 +49,7 @@ func init() {
 }
 
 func setupCB(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
-	var c Config
-	err := plugin.Decode(rawConfig, &c)
+	c, err := decodeConfig(rawConfig)
 	if err != nil {
 		return err
 	}
@@ -42,23 +57,29 @@ func setupCB(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
 	log.WithFields(log.Fields{
 		"plugin_event": plugin.SetupEvent,
 		"plugin":       pluginName,
-		"name":         c.Name,
+		"api_name":     def.Name,
 	}).Debug("Configuring cb plugin")
 
-	hystrix.ConfigureCommand(c.Name, hystrix.CommandConfig{
-		Timeout:               c.Timeout,
-		MaxConcurrentRequests: c.MaxConcurrentRequests,
-		ErrorPercentThreshold: c.ErrorPercentThreshold,
-		SleepWindow:           c.SleepWindow,
-	})
+	breakerConfig := BreakerConfig{
+		ErrorPercentThreshold:  c.ErrorPercentThreshold,
+		RequestVolumeThreshold: c.RequestVolumeThreshold,
+		SleepWindow:            time.Duration(c.SleepWindow) * time.Millisecond,
+		HalfOpenProbes:         c.HalfOpenProbes,
+	}
+
+	var breakers *Breakers
+	if def.Name == "" {
+		breakers = NewBreakers(def.Name, breakerConfig, c.MaxConcurrentRequests)
+	} else {
+		breakers = registry.Set(def.Name, breakerConfig, c.MaxConcurrentRequests)
+	}
 
-	def.AddMiddleware(NewCBMiddleware(c))
+	def.AddMiddleware(NewCBMiddleware(c, breakers))
 	return nil
 }
 
 func validateConfig(rawConfig plugin.Config) (bool, error) {
-	var config Config
-	err := plugin.Decode(rawConfig, &config)
+	config, err := decodeConfig(rawConfig)
 	if err != nil {
 		return false, err
 	}
@@ -66,6 +87,20 @@ func validateConfig(rawConfig plugin.Config) (bool, error) {
 	return govalidator.ValidateStruct(config)
 }
 
+func decodeConfig(rawConfig plugin.Config) (Config, error) {
+	config := Config{
+		Timeout:                defaultTimeout,
+		MaxConcurrentRequests:  defaultMaxConcurrentRequests,
+		ErrorPercentThreshold:  defaultErrorPercentThreshold,
+		RequestVolumeThreshold: defaultRequestVolumeThreshold,
+		SleepWindow:            defaultSleepWindow,
+		HalfOpenProbes:         defaultHalfOpenProbes,
+	}
+	err := plugin.Decode(rawConfig, &config)
+
+	return config, err
+}
+
 func onAdminAPIStartup(event interface{}) error {
 	logger := log.WithFields(log.Fields{
 		"plugin_event": plugin.AdminAPIStartupEvent,
@@ -77,36 +112,24 @@ func onAdminAPIStartup(event interface{}) error {
 		return errors.New("Could not convert event to admin startup type")
 	}
 
-	logger.Debug("Registering hystrix stream endpoint")
-	hystrixStreamHandler := hystrix.NewStreamHandler()
-	hystrixStreamHandler.Start()
-
-	e.Router.GET("/hystrix", hystrixStreamHandler.ServeHTTP)
+	logger.Debug("Registering circuit breakers endpoints")
+	e.Router.GET("/circuit-breakers", NewStatusesHandler(registry))
+	e.Router.GET("/circuit-breakers/{name}", NewStatusHandler(registry))
 	return nil
 }
 
-func onStartup(event interface{}) error {
-	logger := log.WithFields(log.Fields{
-		"plugin_event": plugin.StartupEvent,
-		"plugin":       pluginName,
-	})
-
-	e, ok := event.(plugin.OnStartup)
+// onReload drops the circuit breakers of the APIs that were removed
+func onReload(event interface{}) error {
+	e, ok := event.(plugin.OnReload)
 	if !ok {
-		return errors.New("Could not convert event to startup type")
+		return errors.New("Could not convert event to reload type")
 	}
 
-	logger.WithField("metrics_dsn", e.Config.Stats.DSN).Debug("Statsd metrics enabled")
-	c, err := plugins.InitializeStatsdCollector(&plugins.StatsdCollectorConfig{
-		StatsdAddr: e.Config.Stats.DSN,
-		Prefix:     statsdPrefix,
-	})
-	if err != nil {
-		return errors.Wrap(err, "could not initialize statsd client")
+	names := make([]string, 0, len(e.Configurations))
+	for _, def := range e.Configurations {
+		names = append(names, def.Name)
 	}
-
-	metricCollector.Registry.Register(c.NewStatsdCollector)
-	logger.Debug("Metrics enabled")
+	registry.Retain(names)
 
 	return nil
 }
@@ -1,9 +1,12 @@
 package cb
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
-	"github.com/hellofresh/janus/pkg/config"
+	"github.com/hellofresh/janus/pkg/api"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/hellofresh/janus/pkg/router"
@@ -35,12 +38,8 @@ func TestCbPlugin(t *testing.T) {
 			function: testAdminStartupSuccess,
 		},
 		{
-			scenario: "when the plugin startup is successful",
-			function: testStartupSuccess,
-		},
-		{
-			scenario: "when the plugin startup is not successful",
-			function: testStartupNoSuccess,
+			scenario: "when the configuration is reloaded",
+			function: testReload,
 		},
 	}
 
@@ -51,32 +50,35 @@ func TestCbPlugin(t *testing.T) {
 	}
 }
 
-func testStartupNoSuccess(t *testing.T) {
-	event2 := plugin.OnStartup{
-		Register: proxy.NewRegister(proxy.WithRouter(router.NewChiRouter())),
-		Config: &config.Specification{
-			Stats: config.Stats{
-				DSN: "statsd:8080",
-			},
-		},
-	}
-	err := onStartup(event2)
-	require.Error(t, err)
-}
-
-func testStartupSuccess(t *testing.T) {
-	event2 := plugin.OnStartup{
-		Register: proxy.NewRegister(proxy.WithRouter(router.NewChiRouter())),
-		Config:   &config.Specification{},
-	}
-	err := onStartup(event2)
-	require.NoError(t, err)
-}
-
 func testAdminStartupSuccess(t *testing.T) {
-	event1 := plugin.OnAdminAPIStartup{Router: router.NewChiRouter()}
+	r := router.NewChiRouter()
+	event1 := plugin.OnAdminAPIStartup{Router: r}
 	err := onAdminAPIStartup(event1)
 	require.NoError(t, err)
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	def.Name = "admin-example"
+	require.NoError(t, setupCB(def, make(plugin.Config)))
+	breakers, ok := registry.Get("admin-example")
+	require.True(t, ok)
+	breakers.Get("http://target")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/circuit-breakers/admin-example", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var statuses []BreakerStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &statuses))
+	assert.Equal(t, []BreakerStatus{{Target: "http://target", State: StateClosed}}, statuses)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/circuit-breakers/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/circuit-breakers", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "admin-example")
 }
 
 func testSetupSuccess(t *testing.T) {
@@ -86,6 +88,29 @@ func testSetupSuccess(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func testReload(t *testing.T) {
+	for _, name := range []string{"reload-kept", "reload-removed"} {
+		def := proxy.NewRouterDefinition(proxy.NewDefinition())
+		def.Name = name
+		require.NoError(t, setupCB(def, make(plugin.Config)))
+	}
+	kept, _ := registry.Get("reload-kept")
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	def.Name = "reload-kept"
+	require.NoError(t, setupCB(def, make(plugin.Config)))
+	reused, _ := registry.Get("reload-kept")
+	assert.True(t, kept == reused, "the breakers of an unchanged API are reused")
+
+	err := onReload(plugin.OnReload{Configurations: []*api.Definition{{Name: "reload-kept"}}})
+	require.NoError(t, err)
+
+	_, ok := registry.Get("reload-kept")
+	assert.True(t, ok)
+	_, ok = registry.Get("reload-removed")
+	assert.False(t, ok)
+}
+
 func testSetupWithCorrectConfig(t *testing.T) {
 	rawConfig := map[string]interface{}{
 		"timeout":                 1000,
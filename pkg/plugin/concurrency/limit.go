@@ -0,0 +1,148 @@
+package concurrency
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	algorithmFixed    = "fixed"
+	algorithmAIMD     = "aimd"
+	algorithmGradient = "gradient"
+
+	// gradientWindow is the number of samples the long term latency average spans
+	gradientWindow = 600
+	// gradientTolerance is how much slower than the long term average the requests can get before the
+	// limit is lowered
+	gradientTolerance = 1.5
+)
+
+// Limit is the number of requests allowed in flight, it is updated with the outcome of every request. The
+// Limiter serialises the calls, so the implementations do not need to be safe for concurrent use.
+type Limit interface {
+	// Limit returns the current limit
+	Limit() int
+	// Update is called when a request finishes, inFlight is the number of requests that were in flight
+	// together with it and dropped is set when the request failed in a way that signals overload
+	Update(rtt time.Duration, inFlight int, dropped bool)
+}
+
+// FixedLimit is a Limit that never changes
+type FixedLimit int
+
+// Limit returns the fixed limit
+func (l FixedLimit) Limit() int {
+	return int(l)
+}
+
+// Update does nothing for fixed limits
+func (l FixedLimit) Update(rtt time.Duration, inFlight int, dropped bool) {}
+
+// AIMDLimit is a Limit that grows by one while the requests succeed and shrinks by a ratio once one is dropped
+// or slower than the latency threshold, like the TCP congestion window
+type AIMDLimit struct {
+	limit            int
+	min              int
+	max              int
+	backoffRatio     float64
+	latencyThreshold time.Duration
+}
+
+// NewAIMDLimit creates a new instance of AIMDLimit, a zero latency threshold only backs off on drops
+func NewAIMDLimit(initial, min, max int, backoffRatio float64, latencyThreshold time.Duration) *AIMDLimit {
+	return &AIMDLimit{
+		limit:            clamp(initial, min, max),
+		min:              min,
+		max:              max,
+		backoffRatio:     backoffRatio,
+		latencyThreshold: latencyThreshold,
+	}
+}
+
+// Limit returns the current limit
+func (l *AIMDLimit) Limit() int {
+	return l.limit
+}
+
+// Update grows or shrinks the limit
+func (l *AIMDLimit) Update(rtt time.Duration, inFlight int, dropped bool) {
+	if dropped || (l.latencyThreshold > 0 && rtt > l.latencyThreshold) {
+		l.limit = clamp(int(float64(l.limit)*l.backoffRatio), l.min, l.max)
+		return
+	}
+
+	// the limit only grows while it is actually used, otherwise it would grow without bounds when idle
+	if inFlight*2 >= l.limit {
+		l.limit = clamp(l.limit+1, l.min, l.max)
+	}
+}
+
+// GradientLimit is a Limit following the latency gradient: it compares every request latency with the long term
+// average and lowers the limit as the requests queue up in the upstream, while leaving room for a small queue
+// so it keeps probing for a higher limit
+type GradientLimit struct {
+	limit     float64
+	min       int
+	max       int
+	smoothing float64
+	longRTT   float64
+}
+
+// NewGradientLimit creates a new instance of GradientLimit, smoothing is how fast the limit moves to the
+// computed one, from 0 to 1
+func NewGradientLimit(initial, min, max int, smoothing float64) *GradientLimit {
+	return &GradientLimit{
+		limit:     float64(clamp(initial, min, max)),
+		min:       min,
+		max:       max,
+		smoothing: smoothing,
+	}
+}
+
+// Limit returns the current limit
+func (l *GradientLimit) Limit() int {
+	return int(l.limit)
+}
+
+// Update moves the limit following the latency gradient
+func (l *GradientLimit) Update(rtt time.Duration, inFlight int, dropped bool) {
+	shortRTT := float64(rtt)
+	if shortRTT <= 0 {
+		return
+	}
+
+	if l.longRTT == 0 {
+		l.longRTT = shortRTT
+	} else {
+		l.longRTT += (shortRTT - l.longRTT) / gradientWindow
+	}
+
+	// the upstream recovered from a slow period, let the long term average catch up faster
+	if l.longRTT/shortRTT > 2 {
+		l.longRTT *= 0.95
+	}
+
+	// the latency of an under used limit says nothing about the upstream capacity
+	if !dropped && float64(inFlight)*2 < l.limit {
+		return
+	}
+
+	gradient := math.Max(0.5, math.Min(1, gradientTolerance*l.longRTT/shortRTT))
+	if dropped {
+		gradient = 0.5
+	}
+
+	newLimit := l.limit*gradient + math.Sqrt(l.limit)
+	l.limit = math.Max(float64(l.min), math.Min(float64(l.max), l.limit*(1-l.smoothing)+newLimit*l.smoothing))
+}
+
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if max > 0 && value > max {
+		return max
+	}
+
+	return value
+}
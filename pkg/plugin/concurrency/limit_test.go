@@ -0,0 +1,66 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAIMDLimit(t *testing.T) {
+	t.Parallel()
+
+	l := NewAIMDLimit(10, 2, 12, 0.5, 100*time.Millisecond)
+	assert.Equal(t, 10, l.Limit())
+
+	l.Update(10*time.Millisecond, 1, false)
+	assert.Equal(t, 10, l.Limit(), "the limit does not grow while it is under used")
+
+	for i := 0; i < 5; i++ {
+		l.Update(10*time.Millisecond, 10, false)
+	}
+	assert.Equal(t, 12, l.Limit(), "the limit grows up to the max")
+
+	l.Update(10*time.Millisecond, 10, true)
+	assert.Equal(t, 6, l.Limit(), "the limit backs off on a drop")
+
+	l.Update(200*time.Millisecond, 6, false)
+	assert.Equal(t, 3, l.Limit(), "the limit backs off on a slow request")
+
+	l.Update(10*time.Millisecond, 3, true)
+	assert.Equal(t, 2, l.Limit(), "the limit does not go under the min")
+}
+
+func TestGradientLimit(t *testing.T) {
+	t.Parallel()
+
+	l := NewGradientLimit(20, 5, 200, 1)
+
+	for i := 0; i < 50; i++ {
+		l.Update(10*time.Millisecond, l.Limit(), false)
+	}
+	grown := l.Limit()
+	assert.True(t, grown > 20, "the limit grows while the latency is steady, got %d", grown)
+
+	for i := 0; i < 5; i++ {
+		l.Update(100*time.Millisecond, l.Limit(), false)
+	}
+	assert.True(t, l.Limit() < grown, "the limit shrinks as the latency goes up, got %d", l.Limit())
+
+	shrunk := l.Limit()
+	l.Update(10*time.Millisecond, 0, true)
+	assert.True(t, l.Limit() < shrunk, "the limit shrinks on a drop")
+
+	for i := 0; i < 100; i++ {
+		l.Update(time.Second, l.Limit(), true)
+	}
+	assert.Equal(t, 5, l.Limit(), "the limit does not go under the min")
+}
+
+func TestFixedLimit(t *testing.T) {
+	t.Parallel()
+
+	l := FixedLimit(5)
+	l.Update(time.Second, 5, true)
+	assert.Equal(t, 5, l.Limit())
+}
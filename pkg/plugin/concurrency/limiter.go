@@ -0,0 +1,154 @@
+package concurrency
+
+import (
+	"container/heap"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/errors"
+)
+
+var (
+	// ErrQueueFull is used when a request finds the limit reached and the queue full
+	ErrQueueFull = errors.New(http.StatusServiceUnavailable, "too many requests in flight")
+	// ErrQueueTimeout is used when a request waited in the queue for too long
+	ErrQueueTimeout = errors.New(http.StatusServiceUnavailable, "too many requests in flight, timed out waiting")
+)
+
+// Limiter limits the requests in flight. Requests over the limit wait in a bounded queue, the ones with the
+// highest priority first and in arrival order within the same priority.
+type Limiter struct {
+	sync.Mutex
+	limit     Limit
+	inFlight  int
+	queue     waitQueue
+	queueSize int
+	seq       uint64
+}
+
+// Release is called once a request admitted by the limiter finishes
+type Release func(rtt time.Duration, dropped bool)
+
+type waiter struct {
+	priority int
+	seq      uint64
+	index    int
+	granted  bool
+	ready    chan struct{}
+}
+
+// NewLimiter creates a new instance of Limiter
+func NewLimiter(limit Limit, queueSize int) *Limiter {
+	return &Limiter{limit: limit, queueSize: queueSize}
+}
+
+// Acquire admits a request, waiting in the queue up to the timeout when the limit is reached
+func (l *Limiter) Acquire(ctx context.Context, priority int, timeout time.Duration) (Release, error) {
+	l.Lock()
+	if len(l.queue) == 0 && l.inFlight < l.limit.Limit() {
+		l.inFlight++
+		l.Unlock()
+		return l.release, nil
+	}
+
+	if len(l.queue) >= l.queueSize {
+		l.Unlock()
+		return nil, ErrQueueFull
+	}
+
+	l.seq++
+	w := &waiter{priority: priority, seq: l.seq, ready: make(chan struct{})}
+	heap.Push(&l.queue, w)
+	l.dispatch()
+	l.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-w.ready:
+		return l.release, nil
+	case <-timer.C:
+		err = ErrQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	// the request could have been admitted while it was timing out
+	if w.granted {
+		return l.release, nil
+	}
+	heap.Remove(&l.queue, w.index)
+
+	return nil, err
+}
+
+// State returns the current limit and the number of requests in flight and queued
+func (l *Limiter) State() (limit int, inFlight int, queued int) {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.limit.Limit(), l.inFlight, len(l.queue)
+}
+
+func (l *Limiter) release(rtt time.Duration, dropped bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.limit.Update(rtt, l.inFlight, dropped)
+	l.inFlight--
+	l.dispatch()
+}
+
+// dispatch admits the queued requests while the limit allows it
+func (l *Limiter) dispatch() {
+	for len(l.queue) > 0 && l.inFlight < l.limit.Limit() {
+		w := heap.Pop(&l.queue).(*waiter)
+		w.granted = true
+		l.inFlight++
+		close(w.ready)
+	}
+}
+
+// waitQueue is a heap of waiters ordered by priority and arrival
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int {
+	return len(q)
+}
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+
+	return w
+}
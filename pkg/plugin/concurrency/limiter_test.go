@@ -0,0 +1,113 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterQueuesOverTheLimit(t *testing.T) {
+	t.Parallel()
+
+	l := NewLimiter(FixedLimit(1), 1)
+	ctx := context.Background()
+
+	release, err := l.Acquire(ctx, 0, time.Second)
+	require.NoError(t, err)
+
+	acquired := make(chan Release)
+	go func() {
+		r, err := l.Acquire(ctx, 0, time.Second)
+		assert.NoError(t, err)
+		acquired <- r
+	}()
+	waitQueued(t, l, 1)
+
+	_, err = l.Acquire(ctx, 0, time.Second)
+	assert.Equal(t, ErrQueueFull, err)
+
+	release(time.Millisecond, false)
+	queuedRelease := <-acquired
+
+	limit, inFlight, queued := l.State()
+	assert.Equal(t, 1, limit)
+	assert.Equal(t, 1, inFlight, "the queued request takes the released slot")
+	assert.Equal(t, 0, queued)
+
+	queuedRelease(time.Millisecond, false)
+	_, inFlight, _ = l.State()
+	assert.Equal(t, 0, inFlight)
+}
+
+func TestLimiterPriority(t *testing.T) {
+	t.Parallel()
+
+	l := NewLimiter(FixedLimit(1), 10)
+	ctx := context.Background()
+
+	release, err := l.Acquire(ctx, 0, time.Second)
+	require.NoError(t, err)
+
+	order := make(chan int, 3)
+	for i, priority := range []int{0, 10, 5} {
+		go func(priority int) {
+			r, err := l.Acquire(ctx, priority, time.Second)
+			if assert.NoError(t, err) {
+				order <- priority
+				r(time.Millisecond, false)
+			}
+		}(priority)
+		waitQueued(t, l, i+1)
+	}
+
+	release(time.Millisecond, false)
+	assert.Equal(t, 10, <-order)
+	assert.Equal(t, 5, <-order)
+	assert.Equal(t, 0, <-order)
+}
+
+func TestLimiterQueueTimeout(t *testing.T) {
+	t.Parallel()
+
+	l := NewLimiter(FixedLimit(1), 1)
+	release, err := l.Acquire(context.Background(), 0, time.Second)
+	require.NoError(t, err)
+	defer release(time.Millisecond, false)
+
+	_, err = l.Acquire(context.Background(), 0, 10*time.Millisecond)
+	assert.Equal(t, ErrQueueTimeout, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = l.Acquire(ctx, 0, time.Second)
+	assert.Equal(t, context.Canceled, err)
+
+	_, inFlight, queued := l.State()
+	assert.Equal(t, 1, inFlight)
+	assert.Equal(t, 0, queued, "the requests that gave up leave the queue")
+}
+
+func TestLimiterWithoutQueue(t *testing.T) {
+	t.Parallel()
+
+	l := NewLimiter(FixedLimit(1), 0)
+	_, err := l.Acquire(context.Background(), 0, time.Second)
+	require.NoError(t, err)
+
+	_, err = l.Acquire(context.Background(), 0, time.Second)
+	assert.Equal(t, ErrQueueFull, err)
+}
+
+func waitQueued(t *testing.T, l *Limiter, expected int) {
+	for i := 0; i < 100; i++ {
+		if _, _, queued := l.State(); queued == expected {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("expected %d queued requests", expected)
+}
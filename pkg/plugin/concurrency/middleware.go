@@ -0,0 +1,180 @@
+package concurrency
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/hellofresh/janus/pkg/consumer"
+	"github.com/hellofresh/janus/pkg/errors"
+	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/hellofresh/janus/pkg/proxy"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const (
+	priorityConsumer      = "consumer"
+	priorityConsumerGroup = "consumer_group"
+	priorityConsumerTag   = "consumer_tag"
+	priorityHeader        = "header"
+	priorityClaim         = "claim"
+	priorityBasicUser     = "basic_user"
+
+	resultQueueFull = "queue_full"
+	resultTimeout   = "timeout"
+	resultCanceled  = "canceled"
+)
+
+// Priority tells which requests go first when they wait in the queue
+type Priority struct {
+	// Source is the request value the priority is looked up by
+	Source PrioritySource `json:"source"`
+	// Values maps the source values to priorities, the higher the sooner
+	Values map[string]int `json:"values"`
+	// Default is the priority of the requests whose value is missing or not mapped
+	Default int `json:"default"`
+	// AllowUnverified allows the sources whose values are not verified, they can be spoofed by the clients
+	AllowUnverified bool `json:"allow_unverified"`
+}
+
+// PrioritySource represents a request value the priority is looked up by
+type PrioritySource struct {
+	// Type is one of consumer, consumer_group, consumer_tag, header, claim or basic_user
+	Type string `json:"type" valid:"in(consumer|consumer_group|consumer_tag|header|claim|basic_user)"`
+	// Name is the name of the header or JWT claim
+	Name string `json:"name"`
+}
+
+// isVerified checks if the source values can be trusted. The consumer is the one the auth plugins resolved, the
+// header, claim and basic_user values are sent by the client and not checked.
+func (s PrioritySource) isVerified() bool {
+	switch s.Type {
+	case "", priorityConsumer, priorityConsumerGroup, priorityConsumerTag:
+		return true
+	default:
+		return false
+	}
+}
+
+// of returns the priority of the request, the highest one mapped when the source has several values
+func (p Priority) of(r *http.Request) int {
+	priority, found := p.Default, false
+	for _, value := range p.Source.values(r) {
+		if v, ok := p.Values[value]; ok && value != "" && (!found || v > priority) {
+			priority, found = v, true
+		}
+	}
+
+	return priority
+}
+
+func (s PrioritySource) values(r *http.Request) []string {
+	switch s.Type {
+	case priorityConsumer, priorityConsumerGroup, priorityConsumerTag:
+		c, ok := consumer.FromContext(r.Context())
+		if !ok {
+			return nil
+		}
+
+		switch s.Type {
+		case priorityConsumerGroup:
+			return c.Groups
+		case priorityConsumerTag:
+			return c.Tags
+		default:
+			return []string{c.ID}
+		}
+	case priorityHeader:
+		return []string{r.Header.Get(s.Name)}
+	case priorityClaim:
+		return []string{proxy.ClaimFromRequest(r, s.Name)}
+	case priorityBasicUser:
+		username, _, _ := r.BasicAuth()
+		return []string{username}
+	default:
+		return nil
+	}
+}
+
+// NewConcurrencyLimitMiddleware creates a new concurrency limit middleware. Requests over the limit wait in the
+// queue up to the queue timeout, the ones that can not be queued or time out are shed with 503 and Retry-After.
+// Every response with a 5xx status counts as a drop for the adaptive limits.
+func NewConcurrencyLimitMiddleware(name string, l *Limiter, priority Priority, queueTimeout, retryAfter time.Duration) func(handler http.Handler) http.Handler {
+	retryAfterValue := strconv.FormatInt(int64((retryAfter+time.Second-1)/time.Second), 10)
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			release, err := l.Acquire(r.Context(), priority.of(r), queueTimeout)
+			if err != nil {
+				shed(w, r, name, err, retryAfterValue)
+				recordState(name, l)
+				return
+			}
+			recordState(name, l)
+
+			start := time.Now()
+			// a panicking handler is counted as a drop
+			dropped := true
+			defer func() {
+				release(time.Since(start), dropped)
+				recordState(name, l)
+			}()
+
+			m := httpsnoop.CaptureMetrics(handler, w, r)
+			dropped = m.Code >= http.StatusInternalServerError
+		})
+	}
+}
+
+func shed(w http.ResponseWriter, r *http.Request, name string, err error, retryAfter string) {
+	var result string
+	switch err {
+	case ErrQueueFull:
+		result = resultQueueFull
+	case ErrQueueTimeout:
+		result = resultTimeout
+	default:
+		// the client went away while waiting, there is no one to answer to
+		recordShed(name, resultCanceled)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"api_name":    name,
+		"reason":      result,
+		"request_uri": r.RequestURI,
+	}).Warning("Request shed by the concurrency limit")
+	recordShed(name, result)
+
+	w.Header().Set("Retry-After", retryAfter)
+	errors.Handler(w, err)
+}
+
+func recordShed(name string, result string) {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(obs.KeyAPIName, name),
+		tag.Insert(obs.KeyResult, result),
+	)
+	if err != nil {
+		log.WithError(err).Debug("Failed to tag concurrency limit metrics")
+		return
+	}
+
+	stats.Record(ctx, obs.MConcurrencyShed.M(1))
+}
+
+func recordState(name string, l *Limiter) {
+	ctx, err := tag.New(context.Background(), tag.Insert(obs.KeyAPIName, name))
+	if err != nil {
+		log.WithError(err).Debug("Failed to tag concurrency limit metrics")
+		return
+	}
+
+	limit, inFlight, _ := l.State()
+	stats.Record(ctx, obs.MConcurrencyLimit.M(int64(limit)), obs.MConcurrencyInFlight.M(int64(inFlight)))
+}
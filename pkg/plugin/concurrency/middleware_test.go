@@ -0,0 +1,102 @@
+package concurrency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/consumer"
+	"github.com/hellofresh/janus/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	t.Parallel()
+
+	l := NewLimiter(FixedLimit(1), 0)
+	release, err := l.Acquire(context.Background(), 0, time.Second)
+	require.NoError(t, err)
+
+	handler := NewConcurrencyLimitMiddleware("example", l, Priority{}, time.Second, 1500*time.Millisecond)(http.HandlerFunc(test.Ping))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	release(time.Millisecond, false)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	_, inFlight, _ := l.State()
+	assert.Equal(t, 0, inFlight, "the request releases its slot once served")
+}
+
+func TestConcurrencyLimitMiddlewareReportsDrops(t *testing.T) {
+	t.Parallel()
+
+	l := NewLimiter(NewAIMDLimit(10, 1, 10, 0.5, 0), 0)
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	handler := NewConcurrencyLimitMiddleware("example", l, Priority{}, time.Second, time.Second)(failing)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	limit, _, _ := l.State()
+	assert.Equal(t, 5, limit, "a 5xx response makes the adaptive limit back off")
+}
+
+func TestPriority(t *testing.T) {
+	t.Parallel()
+
+	p := Priority{
+		Source:  PrioritySource{Type: priorityHeader, Name: "X-Tier"},
+		Values:  map[string]int{"gold": 10, "silver": 5},
+		Default: 1,
+	}
+
+	tests := []struct {
+		scenario string
+		tier     string
+		expected int
+	}{
+		{scenario: "when the value is mapped", tier: "gold", expected: 10},
+		{scenario: "when the value is not mapped", tier: "bronze", expected: 1},
+		{scenario: "when the value is missing", expected: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.tier != "" {
+				r.Header.Set("X-Tier", test.tier)
+			}
+
+			assert.Equal(t, test.expected, p.of(r))
+		})
+	}
+
+	p = Priority{Source: PrioritySource{Type: priorityBasicUser}, Values: map[string]int{"admin": 3}}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("admin", "secret")
+	assert.Equal(t, 3, p.of(r))
+
+	p = Priority{Source: PrioritySource{Type: priorityConsumerGroup}, Values: map[string]int{"partners": 5, "staff": 8}, Default: 1}
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Tier", "staff")
+	assert.Equal(t, 1, p.of(r), "without a consumer the default priority is used")
+
+	c := &consumer.Consumer{ID: "mobile-app", Groups: []string{"partners", "staff", "beta"}}
+	r = r.WithContext(consumer.WithConsumer(r.Context(), c))
+	assert.Equal(t, 8, p.of(r), "the highest priority of the consumer groups is used")
+
+	p = Priority{Source: PrioritySource{Type: priorityConsumer}, Values: map[string]int{"mobile-app": 4}}
+	assert.Equal(t, 4, p.of(r))
+}
@@ -0,0 +1,72 @@
+package concurrency
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Registry holds the limiters by API name
+type Registry struct {
+	sync.Mutex
+	limiters map[string]*registered
+}
+
+type registered struct {
+	config  Config
+	limiter *Limiter
+}
+
+// NewRegistry creates a new instance of Registry
+func NewRegistry() *Registry {
+	return &Registry{limiters: make(map[string]*registered)}
+}
+
+// Set returns the limiter of the given API. The limiter of an API that did not change its configuration is
+// reused, so the requests in flight and the adaptive limit survive configuration reloads.
+func (r *Registry) Set(name string, config Config) *Limiter {
+	if name == "" {
+		return NewLimiter(newLimit(config), config.QueueSize)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if l, ok := r.limiters[name]; ok && reflect.DeepEqual(l.config, config) {
+		return l.limiter
+	}
+
+	l := NewLimiter(newLimit(config), config.QueueSize)
+	r.limiters[name] = &registered{config: config, limiter: l}
+
+	return l
+}
+
+// Get returns the limiter of the given API
+func (r *Registry) Get(name string) (*Limiter, bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	l, ok := r.limiters[name]
+	if !ok {
+		return nil, false
+	}
+
+	return l.limiter, true
+}
+
+// Retain removes the limiters of all the APIs that are not in the given list
+func (r *Registry) Retain(names []string) {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for name := range r.limiters {
+		if !keep[name] {
+			delete(r.limiters, name)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package concurrency
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	pluginName = "concurrency_limit"
+
+	defaultLimit        = 100
+	defaultMinLimit     = 1
+	defaultMaxLimit     = 1000
+	defaultBackoffRatio = 0.9
+	defaultSmoothing    = 0.2
+	defaultQueueSize    = 100
+	defaultQueueTimeout = proxy.Duration(time.Second)
+	defaultRetryAfter   = proxy.Duration(time.Second)
+)
+
+var (
+	registry = NewRegistry()
+
+	// ErrInvalidLimits is used when the limits are not ordered or not positive
+	ErrInvalidLimits = errors.New(http.StatusBadRequest, "the limits must be positive and min_limit <= limit <= max_limit")
+	// ErrInvalidBackoffRatio is used when the backoff ratio is not between 0 and 1
+	ErrInvalidBackoffRatio = errors.New(http.StatusBadRequest, "backoff_ratio must be between 0 and 1")
+	// ErrInvalidSmoothing is used when the smoothing is not between 0 and 1
+	ErrInvalidSmoothing = errors.New(http.StatusBadRequest, "smoothing must be between 0 and 1")
+	// ErrUnverifiedPriority is used when the priority source is sent by the client and it is not allowed
+	ErrUnverifiedPriority = errors.New(http.StatusBadRequest, "the header, claim and basic_user priority sources require allow_unverified")
+)
+
+// Config represents the concurrency limit configuration
+type Config struct {
+	// Algorithm is fixed, aimd or gradient
+	Algorithm string `json:"algorithm" valid:"in(fixed|aimd|gradient)"`
+	// Limit is the fixed limit, or the initial one for the adaptive algorithms
+	Limit    int `json:"limit"`
+	MinLimit int `json:"min_limit"`
+	MaxLimit int `json:"max_limit"`
+	// BackoffRatio is what the aimd limit is multiplied by on a drop
+	BackoffRatio float64 `json:"backoff_ratio"`
+	// LatencyThreshold makes the aimd limit back off on requests slower than it
+	LatencyThreshold proxy.Duration `json:"latency_threshold"`
+	// Smoothing is how fast the gradient limit moves, from 0 to 1
+	Smoothing float64 `json:"smoothing"`
+	// QueueSize is the number of requests that can wait for the limit, zero sheds them at once
+	QueueSize    int            `json:"queue_size"`
+	QueueTimeout proxy.Duration `json:"queue_timeout"`
+	RetryAfter   proxy.Duration `json:"retry_after"`
+	Priority     Priority       `json:"priority"`
+}
+
+func init() {
+	plugin.RegisterEventHook(plugin.ReloadEvent, onReload)
+	plugin.RegisterPlugin(pluginName, plugin.Plugin{
+		Action:   setupConcurrencyLimit,
+		Validate: validateConfig,
+	})
+}
+
+func setupConcurrencyLimit(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
+	config, err := decodeConfig(rawConfig)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"plugin_event": plugin.SetupEvent,
+		"plugin":       pluginName,
+		"api_name":     def.Name,
+		"algorithm":    config.Algorithm,
+	}).Debug("Configuring concurrency limit plugin")
+
+	l := registry.Set(def.Name, config)
+	def.AddMiddleware(NewConcurrencyLimitMiddleware(
+		def.Name,
+		l,
+		config.Priority,
+		time.Duration(config.QueueTimeout),
+		time.Duration(config.RetryAfter),
+	))
+
+	return nil
+}
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	config, err := decodeConfig(rawConfig)
+	if err != nil {
+		return false, err
+	}
+
+	return govalidator.ValidateStruct(config)
+}
+
+func decodeConfig(rawConfig plugin.Config) (Config, error) {
+	config := Config{
+		Algorithm:    algorithmFixed,
+		Limit:        defaultLimit,
+		MinLimit:     defaultMinLimit,
+		MaxLimit:     defaultMaxLimit,
+		BackoffRatio: defaultBackoffRatio,
+		Smoothing:    defaultSmoothing,
+		QueueSize:    defaultQueueSize,
+		QueueTimeout: defaultQueueTimeout,
+		RetryAfter:   defaultRetryAfter,
+	}
+	if err := plugin.Decode(rawConfig, &config); err != nil {
+		return config, err
+	}
+
+	if config.MinLimit < 1 || config.Limit < config.MinLimit || config.MaxLimit < config.Limit {
+		return config, ErrInvalidLimits
+	}
+	if config.BackoffRatio <= 0 || config.BackoffRatio >= 1 {
+		return config, ErrInvalidBackoffRatio
+	}
+	if config.Smoothing <= 0 || config.Smoothing > 1 {
+		return config, ErrInvalidSmoothing
+	}
+	if !config.Priority.Source.isVerified() && !config.Priority.AllowUnverified {
+		return config, ErrUnverifiedPriority
+	}
+
+	return config, nil
+}
+
+func newLimit(config Config) Limit {
+	switch config.Algorithm {
+	case algorithmAIMD:
+		return NewAIMDLimit(config.Limit, config.MinLimit, config.MaxLimit, config.BackoffRatio, time.Duration(config.LatencyThreshold))
+	case algorithmGradient:
+		return NewGradientLimit(config.Limit, config.MinLimit, config.MaxLimit, config.Smoothing)
+	default:
+		return FixedLimit(config.Limit)
+	}
+}
+
+// onReload drops the limiters of the APIs that were removed
+func onReload(event interface{}) error {
+	e, ok := event.(plugin.OnReload)
+	if !ok {
+		return errors.New(http.StatusInternalServerError, "Could not convert event to reload type")
+	}
+
+	names := make([]string, 0, len(e.Configurations))
+	for _, def := range e.Configurations {
+		names = append(names, def.Name)
+	}
+	registry.Retain(names)
+
+	return nil
+}
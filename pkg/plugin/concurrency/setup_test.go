@@ -0,0 +1,137 @@
+package concurrency
+
+import (
+	"testing"
+
+	"github.com/hellofresh/janus/pkg/api"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimitPlugin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scenario string
+		function func(*testing.T)
+	}{
+		{
+			scenario: "when the correct concurrency limit configuration is given",
+			function: testSetupWithCorrectConfig,
+		},
+		{
+			scenario: "when an unverified priority source is allowed",
+			function: testSetupWithUnverifiedPriority,
+		},
+		{
+			scenario: "when an incorrect concurrency limit configuration is given",
+			function: testSetupWithIncorrectConfig,
+		},
+		{
+			scenario: "when the plugin setup is successful",
+			function: testSetupSuccess,
+		},
+		{
+			scenario: "when the configuration is reloaded",
+			function: testReload,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			test.function(t)
+		})
+	}
+}
+
+func testSetupWithCorrectConfig(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"algorithm":         "aimd",
+		"limit":             20,
+		"max_limit":         200,
+		"latency_threshold": "500ms",
+		"queue_size":        50,
+		"queue_timeout":     "2s",
+		"priority": map[string]interface{}{
+			"source": map[string]interface{}{"type": "consumer_group"},
+			"values": map[string]interface{}{"gold": 10},
+		},
+	}
+
+	result, err := validateConfig(rawConfig)
+	assert.True(t, result)
+	require.NoError(t, err)
+
+	config, err := decodeConfig(rawConfig)
+	require.NoError(t, err)
+	assert.IsType(t, &AIMDLimit{}, newLimit(config))
+}
+
+func testSetupWithUnverifiedPriority(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"priority": map[string]interface{}{
+			"source":           map[string]interface{}{"type": "header", "name": "X-Tier"},
+			"values":           map[string]interface{}{"gold": 10},
+			"allow_unverified": true,
+		},
+	}
+
+	result, err := validateConfig(rawConfig)
+	assert.True(t, result)
+	require.NoError(t, err)
+}
+
+func testSetupWithIncorrectConfig(t *testing.T) {
+	for _, rawConfig := range []map[string]interface{}{
+		{"algorithm": "vegas"},
+		{"limit": 0},
+		{"limit": 2000},
+		{"min_limit": 50, "limit": 10},
+		{"backoff_ratio": 1.5},
+		{"smoothing": 0},
+		{"queue_timeout": "wrong"},
+		{"priority": map[string]interface{}{"source": map[string]interface{}{"type": "ip"}}},
+		{"priority": map[string]interface{}{"source": map[string]interface{}{"type": "header", "name": "X-Tier"}}},
+	} {
+		result, err := validateConfig(rawConfig)
+		assert.False(t, result, "%v", rawConfig)
+		assert.Error(t, err)
+	}
+}
+
+func testSetupSuccess(t *testing.T) {
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+
+	err := setupConcurrencyLimit(def, make(plugin.Config))
+	require.NoError(t, err)
+	assert.Len(t, def.Middleware(), 1)
+}
+
+func testReload(t *testing.T) {
+	for _, name := range []string{"reload-kept", "reload-removed"} {
+		def := proxy.NewRouterDefinition(proxy.NewDefinition())
+		def.Name = name
+		require.NoError(t, setupConcurrencyLimit(def, make(plugin.Config)))
+	}
+	kept, _ := registry.Get("reload-kept")
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	def.Name = "reload-kept"
+	require.NoError(t, setupConcurrencyLimit(def, make(plugin.Config)))
+	reused, _ := registry.Get("reload-kept")
+	assert.True(t, kept == reused, "the limiter of an unchanged API is reused")
+
+	require.NoError(t, setupConcurrencyLimit(def, map[string]interface{}{"limit": 10}))
+	changed, _ := registry.Get("reload-kept")
+	assert.False(t, kept == changed, "the limiter of a changed API is replaced")
+
+	err := onReload(plugin.OnReload{Configurations: []*api.Definition{{Name: "reload-kept"}}})
+	require.NoError(t, err)
+
+	_, ok := registry.Get("reload-kept")
+	assert.True(t, ok)
+	_, ok = registry.Get("reload-removed")
+	assert.False(t, ok)
+}
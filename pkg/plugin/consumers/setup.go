@@ -0,0 +1,72 @@
+// Package consumers loads the consumers and registers their admin API endpoints on the Janus startup events
+package consumers
+
+import (
+	"github.com/hellofresh/janus/pkg/consumer"
+	"github.com/hellofresh/janus/pkg/jwt"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/router"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var adminRouter router.Router
+
+func init() {
+	plugin.RegisterEventHook(plugin.StartupEvent, onStartup)
+	plugin.RegisterEventHook(plugin.AdminAPIStartupEvent, onAdminAPIStartup)
+}
+
+func onAdminAPIStartup(event interface{}) error {
+	e, ok := event.(plugin.OnAdminAPIStartup)
+	if !ok {
+		return errors.New("could not convert event to admin startup type")
+	}
+
+	adminRouter = e.Router
+	return nil
+}
+
+func onStartup(event interface{}) error {
+	e, ok := event.(plugin.OnStartup)
+	if !ok {
+		return errors.New("could not convert event to startup type")
+	}
+
+	if adminRouter == nil {
+		return errors.New("invalid admin router given")
+	}
+
+	if e.Config == nil {
+		return errors.New("the database configuration is needed to load the consumers")
+	}
+
+	repo, err := consumer.BuildRepository(e.Config.Database.DSN, e.MongoSession)
+	if err != nil {
+		return err
+	}
+
+	if mongoRepo, ok := repo.(*consumer.MongoRepository); ok {
+		if err := mongoRepo.EnsureIndexes(); err != nil {
+			return errors.Wrap(err, "Failed to create indexes for the consumers repository")
+		}
+	}
+
+	consumer.SetRepository(repo)
+	log.Debug("Registering consumers endpoints")
+
+	handlers := consumer.NewHandler(repo)
+	guard := jwt.NewGuard(e.Config.Web.Credentials)
+	group := adminRouter.Group("/consumers")
+	group.Use(jwt.NewMiddleware(guard).Handler)
+	{
+		group.GET("/", handlers.Index())
+		group.POST("/", handlers.Create())
+		group.GET("/{id}", handlers.Show())
+		group.PUT("/{id}", handlers.Update())
+		group.DELETE("/{id}", handlers.Delete())
+		group.GET("/{id}/credentials", handlers.Credentials())
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package consumers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hellofresh/janus/pkg/config"
+	"github.com/hellofresh/janus/pkg/consumer"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnStartup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "janus")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "consumers"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "consumers", "mobile-app.json"), []byte(`{"id": "mobile-app", "groups": ["partners"]}`), 0600))
+
+	r := router.NewChiRouter()
+	require.NoError(t, onAdminAPIStartup(plugin.OnAdminAPIStartup{Router: r}))
+
+	event := plugin.OnStartup{Config: &config.Specification{Database: config.Database{DSN: "file://" + dir}}}
+	require.NoError(t, onStartup(event))
+	defer consumer.SetRepository(nil)
+
+	req := consumer.Resolve(httptest.NewRequest(http.MethodGet, "/", nil), "mobile-app")
+	c, ok := consumer.FromContext(req.Context())
+	require.True(t, ok)
+	assert.True(t, c.InGroup("partners"), "the consumers are looked up in the loaded repository")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/consumers/", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "the consumers endpoints need an admin token")
+}
+
+func TestOnStartupWrongEvent(t *testing.T) {
+	require.Error(t, onStartup(plugin.OnAdminAPIStartup{}))
+	require.Error(t, onAdminAPIStartup(plugin.OnStartup{}))
+}
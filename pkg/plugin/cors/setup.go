@@ -1,7 +1,10 @@
 package cors
 
 import (
+	"net/http"
+
 	"github.com/asaskevich/govalidator"
+	"github.com/hellofresh/janus/pkg/consumer"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/rs/cors"
@@ -24,11 +27,21 @@ func init() {
 }
 
 func setupCors(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
+	mw, err := consumer.NewOverrideMiddleware("cors", rawConfig, newCorsMiddleware)
+	if err != nil {
+		return err
+	}
+
+	def.AddMiddleware(mw)
+	return nil
+}
+
+func newCorsMiddleware(rawConfig map[string]interface{}) (func(http.Handler) http.Handler, error) {
 	var config Config
 
 	err := plugin.Decode(rawConfig, &config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	mw := cors.New(cors.Options{
@@ -40,8 +53,7 @@ func setupCors(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
 		AllowCredentials:   true,
 	})
 
-	def.AddMiddleware(mw.Handler)
-	return nil
+	return mw.Handler, nil
 }
 
 func validateConfig(rawConfig plugin.Config) (bool, error) {
@@ -0,0 +1,24 @@
+package keyauth
+
+import (
+	"net/http"
+
+	"github.com/hellofresh/janus/pkg/errors"
+)
+
+var (
+	// ErrNotAuthorized is used when the key is missing, unknown or expired
+	ErrNotAuthorized = errors.New(http.StatusUnauthorized, "not authorized")
+	// ErrKeyNotFound is used when a key is not found
+	ErrKeyNotFound = errors.New(http.StatusNotFound, "key not found")
+	// ErrKeyExists is used when a key already exists
+	ErrKeyExists = errors.New(http.StatusConflict, "key already exists")
+	// ErrConsumerRequired is used when a key is created without consumer
+	ErrConsumerRequired = errors.New(http.StatusBadRequest, "consumer is required")
+	// ErrKeyTooShort is used when a provided key is too short to be safe
+	ErrKeyTooShort = errors.New(http.StatusBadRequest, "key must be at least 16 characters long")
+	// ErrInvalidMongoDBSession is used when mongodb is not being used
+	ErrInvalidMongoDBSession = errors.New(http.StatusNotFound, "invalid mongodb session given")
+	// ErrInvalidAdminRouter is used when an invalid admin router is given
+	ErrInvalidAdminRouter = errors.New(http.StatusNotFound, "invalid admin router given")
+)
@@ -0,0 +1,57 @@
+package keyauth
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// FileSystemRepository represents a key repository loaded from the JSON files of a directory. Every file holds
+// a list of keys, with the SHA-256 of the key in the hash field. The keys managed on the admin API are kept in
+// memory only.
+type FileSystemRepository struct {
+	*InMemoryRepository
+}
+
+// NewFileSystemRepository creates a file based key repository
+func NewFileSystemRepository(dir string) (*FileSystemRepository, error) {
+	repo := &FileSystemRepository{InMemoryRepository: NewInMemoryRepository()}
+
+	files, err := ioutil.ReadDir(dir)
+	if nil != err {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+
+		filePath := filepath.Join(dir, f.Name())
+		raw, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			log.WithError(err).WithField("path", filePath).Error("Couldn't load the API keys file")
+			return nil, err
+		}
+
+		var keys []*Key
+		if err := json.Unmarshal(raw, &keys); err != nil {
+			return nil, errors.Wrapf(err, "could not parse the API keys file %s", filePath)
+		}
+
+		for _, key := range keys {
+			if key.ID == "" || key.Consumer == "" || len(key.Hash) != 64 {
+				return nil, errors.Errorf("the API keys in %s need an id, a consumer and a SHA-256 hash", filePath)
+			}
+
+			if err := repo.Add(key); err != nil {
+				return nil, errors.Wrapf(err, "could not add the API key %s", key.ID)
+			}
+		}
+	}
+
+	return repo, nil
+}
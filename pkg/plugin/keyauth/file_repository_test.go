@@ -0,0 +1,37 @@
+package keyauth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFileSystemRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "key_auth")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	content := `[{"id": "mobile", "consumer": "mobile-app", "hash": "` + HashKey("key-of-the-mobile-app") + `"}]`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "mobile.json"), []byte(content), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not a key"), 0600))
+
+	repo, err := NewFileSystemRepository(dir)
+	require.NoError(t, err)
+
+	key, err := repo.FindByHash(HashKey("key-of-the-mobile-app"))
+	require.NoError(t, err)
+	assert.Equal(t, "mobile-app", key.Consumer)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plain.json"), []byte(`[{"id": "plain", "consumer": "c", "hash": "plain"}]`), 0600))
+	_, err = NewFileSystemRepository(dir)
+	assert.Error(t, err, "the keys must be stored hashed")
+}
+
+func TestNewFileSystemRepositoryMissingDir(t *testing.T) {
+	_, err := NewFileSystemRepository("/does/not/exist")
+	assert.Error(t, err)
+}
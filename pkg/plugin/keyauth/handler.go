@@ -0,0 +1,264 @@
+package keyauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/render"
+	"github.com/hellofresh/janus/pkg/router"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+)
+
+// Handler is the api rest handlers
+type Handler struct {
+	repo Repository
+	now  func() time.Time
+}
+
+// NewHandler creates a new instance of Handler
+func NewHandler(repo Repository) *Handler {
+	return &Handler{repo: repo, now: time.Now}
+}
+
+// keyRequest is the body of the create, update and rotate requests
+type keyRequest struct {
+	Consumer string `json:"consumer"`
+	// Key is the key to store, a random one is generated when empty
+	Key       string     `json:"key"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	// TTL sets the expiry relative to now, it wins over ExpiresAt
+	TTL proxy.Duration `json:"ttl"`
+	// GracePeriod is how long the rotated key keeps working
+	GracePeriod proxy.Duration `json:"grace_period"`
+}
+
+// issuedKey is the response of the create and rotate requests, the only ones holding the key
+type issuedKey struct {
+	*Key
+	Plain string `json:"key"`
+}
+
+// Index is the find all handler, the keys can be filtered by consumer
+func (c *Handler) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			keys []*Key
+			err  error
+		)
+
+		_, span := trace.StartSpan(r.Context(), "repo.FindAll")
+		if consumer := r.URL.Query().Get("consumer"); consumer != "" {
+			keys, err = c.repo.FindByConsumer(consumer)
+		} else {
+			keys, err = c.repo.FindAll()
+		}
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		data := make([]*Key, 0, len(keys))
+		for _, key := range keys {
+			data = append(data, key.public())
+		}
+
+		render.JSON(w, http.StatusOK, data)
+	}
+}
+
+// Show is the find by handler
+func (c *Handler) Show() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := trace.StartSpan(r.Context(), "repo.FindByID")
+		key, err := c.repo.FindByID(router.URLParam(r, "id"))
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, key.public())
+	}
+}
+
+// Create is the create handler
+func (c *Handler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body keyRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			errors.Handler(w, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		if body.Consumer == "" {
+			errors.Handler(w, ErrConsumerRequired)
+			return
+		}
+
+		c.issue(w, r, body)
+	}
+}
+
+// Update is the update handler, it changes the consumer and expiry of a key
+func (c *Handler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := trace.StartSpan(r.Context(), "repo.FindByID")
+		key, err := c.repo.FindByID(router.URLParam(r, "id"))
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		var body keyRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			errors.Handler(w, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		if body.Consumer == "" {
+			errors.Handler(w, ErrConsumerRequired)
+			return
+		}
+
+		key.Consumer = body.Consumer
+		key.ExpiresAt = c.expiry(body)
+
+		_, span = trace.StartSpan(r.Context(), "repo.Save")
+		err = c.repo.Save(key)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, key.public())
+	}
+}
+
+// Rotate is the rotate handler, it issues a new key for the consumer of the given one. The rotated key keeps
+// working during the grace period, so the consumer can switch to the new key without downtime. The rotated key
+// is only shortened once the new one is stored.
+func (c *Handler) Rotate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := trace.StartSpan(r.Context(), "repo.FindByID")
+		key, err := c.repo.FindByID(router.URLParam(r, "id"))
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		var body keyRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				errors.Handler(w, errors.New(http.StatusBadRequest, err.Error()))
+				return
+			}
+		}
+		body.Consumer = key.Consumer
+
+		issued, plain, err := c.add(r, body)
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		expiresAt := c.now().Add(time.Duration(body.GracePeriod)).UTC()
+		if key.ExpiresAt == nil || expiresAt.Before(*key.ExpiresAt) {
+			key.ExpiresAt = &expiresAt
+		}
+
+		_, span = trace.StartSpan(r.Context(), "repo.Save")
+		err = c.repo.Save(key)
+		span.End()
+
+		if err != nil {
+			// the rotated key keeps working, the new one is dropped so the rotation can be retried
+			if removeErr := c.repo.Remove(issued.ID); removeErr != nil {
+				log.WithError(removeErr).WithField("id", issued.ID).Error("Could not remove the key of a failed rotation")
+			}
+			errors.Handler(w, err)
+			return
+		}
+
+		c.created(w, issued, plain)
+	}
+}
+
+// Delete is the delete handler
+func (c *Handler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := trace.StartSpan(r.Context(), "repo.Remove")
+		err := c.repo.Remove(router.URLParam(r, "id"))
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (c *Handler) issue(w http.ResponseWriter, r *http.Request, body keyRequest) {
+	key, plain, err := c.add(r, body)
+	if err != nil {
+		errors.Handler(w, err)
+		return
+	}
+
+	c.created(w, key, plain)
+}
+
+// add stores a new key for the consumer of the request, it returns the key and its plain value
+func (c *Handler) add(r *http.Request, body keyRequest) (*Key, string, error) {
+	plain := body.Key
+	if plain == "" {
+		var err error
+		if plain, err = GenerateKey(); err != nil {
+			return nil, "", err
+		}
+	} else if len(plain) < minKeyLength {
+		return nil, "", ErrKeyTooShort
+	}
+
+	key := NewKey(body.Consumer, plain)
+	key.ExpiresAt = c.expiry(body)
+
+	_, span := trace.StartSpan(r.Context(), "repo.Add")
+	err := c.repo.Add(key)
+	span.End()
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, plain, nil
+}
+
+func (c *Handler) created(w http.ResponseWriter, key *Key, plain string) {
+	w.Header().Add("Location", fmt.Sprintf("/credentials/key_auth/%s", key.ID))
+	render.JSON(w, http.StatusCreated, issuedKey{Key: key.public(), Plain: plain})
+}
+
+func (c *Handler) expiry(body keyRequest) *time.Time {
+	if body.TTL > 0 {
+		expiresAt := c.now().Add(time.Duration(body.TTL)).UTC()
+		return &expiresAt
+	}
+
+	return body.ExpiresAt
+}
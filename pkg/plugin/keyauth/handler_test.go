@@ -0,0 +1,148 @@
+package keyauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(repo Repository, now time.Time) router.Router {
+	handlers := NewHandler(repo)
+	handlers.now = func() time.Time { return now }
+
+	r := router.NewChiRouter()
+	r.GET("/credentials/key_auth/", handlers.Index())
+	r.POST("/credentials/key_auth/", handlers.Create())
+	r.GET("/credentials/key_auth/{id}", handlers.Show())
+	r.PUT("/credentials/key_auth/{id}", handlers.Update())
+	r.DELETE("/credentials/key_auth/{id}", handlers.Delete())
+	r.POST("/credentials/key_auth/{id}/rotate", handlers.Rotate())
+
+	return r
+}
+
+func doAdminRequest(r router.Router, method, url, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+
+	return w
+}
+
+func TestHandlerLifecycle(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	repo := NewInMemoryRepository()
+	r := newTestRouter(repo, now)
+
+	w := doAdminRequest(r, http.MethodPost, "/credentials/key_auth/", `{"consumer": "mobile-app", "ttl": "24h"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created issuedKey
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.Plain)
+	assert.Empty(t, created.Hash, "the hash is not exposed")
+	assert.Equal(t, created.Plain[:hintLength], created.Hint)
+	assert.Equal(t, now.Add(24*time.Hour), *created.ExpiresAt)
+	assert.Equal(t, "/credentials/key_auth/"+created.ID, w.Header().Get("Location"))
+
+	stored, err := repo.FindByHash(HashKey(created.Plain))
+	require.NoError(t, err, "the key is stored hashed")
+
+	w = doAdminRequest(r, http.MethodGet, "/credentials/key_auth/"+created.ID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Plain)
+	assert.NotContains(t, w.Body.String(), stored.Hash)
+
+	w = doAdminRequest(r, http.MethodPut, "/credentials/key_auth/"+created.ID, `{"consumer": "web-app"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	stored, _ = repo.FindByID(created.ID)
+	assert.Equal(t, "web-app", stored.Consumer)
+	assert.Nil(t, stored.ExpiresAt)
+
+	w = doAdminRequest(r, http.MethodPost, "/credentials/key_auth/"+created.ID+"/rotate", `{"grace_period": "1h"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var rotated issuedKey
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.NotEqual(t, created.Plain, rotated.Plain)
+	assert.Equal(t, "web-app", rotated.Consumer)
+
+	stored, _ = repo.FindByID(created.ID)
+	assert.Equal(t, now.Add(time.Hour), *stored.ExpiresAt, "the rotated key works during the grace period")
+
+	w = doAdminRequest(r, http.MethodGet, "/credentials/key_auth/?consumer=web-app", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var keys []*Key
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &keys))
+	assert.Len(t, keys, 2)
+
+	w = doAdminRequest(r, http.MethodDelete, "/credentials/key_auth/"+created.ID, "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = doAdminRequest(r, http.MethodGet, "/credentials/key_auth/"+created.ID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlerRotateFailureKeepsTheKey(t *testing.T) {
+	t.Parallel()
+
+	repo := NewInMemoryRepository()
+	r := newTestRouter(repo, time.Now())
+
+	w := doAdminRequest(r, http.MethodPost, "/credentials/key_auth/", `{"consumer": "mobile-app"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created issuedKey
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = doAdminRequest(r, http.MethodPost, "/credentials/key_auth/", `{"consumer": "web-app", "key": "a-long-enough-key"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	tests := []struct {
+		scenario string
+		body     string
+		expected int
+	}{
+		{scenario: "when the given key already exists", body: `{"key": "a-long-enough-key"}`, expected: http.StatusConflict},
+		{scenario: "when the given key is too short", body: `{"key": "short"}`, expected: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		w = doAdminRequest(r, http.MethodPost, "/credentials/key_auth/"+created.ID+"/rotate", test.body)
+		assert.Equal(t, test.expected, w.Code, test.scenario)
+
+		stored, err := repo.FindByID(created.ID)
+		require.NoError(t, err)
+		assert.Nil(t, stored.ExpiresAt, test.scenario)
+	}
+}
+
+func TestHandlerCreateErrors(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRouter(NewInMemoryRepository(), time.Now())
+
+	tests := []struct {
+		scenario string
+		body     string
+		expected int
+	}{
+		{scenario: "when the body is malformed", body: `{`, expected: http.StatusBadRequest},
+		{scenario: "when the consumer is missing", body: `{}`, expected: http.StatusBadRequest},
+		{scenario: "when the given key is too short", body: `{"consumer": "c", "key": "short"}`, expected: http.StatusBadRequest},
+		{scenario: "when the given key is long enough", body: `{"consumer": "c", "key": "a-long-enough-key"}`, expected: http.StatusCreated},
+		{scenario: "when the given key already exists", body: `{"consumer": "d", "key": "a-long-enough-key"}`, expected: http.StatusConflict},
+	}
+
+	for _, test := range tests {
+		w := doAdminRequest(r, http.MethodPost, "/credentials/key_auth/", test.body)
+		assert.Equal(t, test.expected, w.Code, test.scenario)
+	}
+}
@@ -0,0 +1,131 @@
+package keyauth
+
+import (
+	"sort"
+	"sync"
+)
+
+// InMemoryRepository represents a in memory repository
+type InMemoryRepository struct {
+	sync.RWMutex
+	keys   map[string]*Key
+	hashes map[string]string
+}
+
+// NewInMemoryRepository creates a in memory repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{keys: make(map[string]*Key), hashes: make(map[string]string)}
+}
+
+// FindAll fetches all the keys available
+func (r *InMemoryRepository) FindAll() ([]*Key, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.filter(func(*Key) bool { return true }), nil
+}
+
+// FindByConsumer fetches the keys of a consumer
+func (r *InMemoryRepository) FindByConsumer(consumer string) ([]*Key, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.filter(func(k *Key) bool { return k.Consumer == consumer }), nil
+}
+
+// FindByID finds a key by id
+func (r *InMemoryRepository) FindByID(id string) (*Key, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	c := *key
+	return &c, nil
+}
+
+// FindByHash finds a key by the hash of its value
+func (r *InMemoryRepository) FindByHash(hash string) (*Key, error) {
+	r.RLock()
+	id, ok := r.hashes[hash]
+	r.RUnlock()
+
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return r.FindByID(id)
+}
+
+// Add adds a new key to the repository
+func (r *InMemoryRepository) Add(key *Key) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.keys[key.ID]; ok {
+		return ErrKeyExists
+	}
+	if _, ok := r.hashes[key.Hash]; ok {
+		return ErrKeyExists
+	}
+
+	r.set(key)
+	return nil
+}
+
+// Save saves a key to the repository
+func (r *InMemoryRepository) Save(key *Key) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if id, ok := r.hashes[key.Hash]; ok && id != key.ID {
+		return ErrKeyExists
+	}
+	if old, ok := r.keys[key.ID]; ok {
+		delete(r.hashes, old.Hash)
+	}
+
+	r.set(key)
+	return nil
+}
+
+// Remove removes a key from the repository
+func (r *InMemoryRepository) Remove(id string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	delete(r.hashes, key.Hash)
+	delete(r.keys, id)
+
+	return nil
+}
+
+func (r *InMemoryRepository) set(key *Key) {
+	c := *key
+	r.keys[key.ID] = &c
+	r.hashes[key.Hash] = key.ID
+}
+
+func (r *InMemoryRepository) filter(match func(*Key) bool) []*Key {
+	keys := make([]*Key, 0, len(r.keys))
+	for _, key := range r.keys {
+		if match(key) {
+			c := *key
+			keys = append(keys, &c)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys
+}
@@ -0,0 +1,78 @@
+package keyauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newInMemoryRepo() *InMemoryRepository {
+	repo := NewInMemoryRepository()
+	repo.Add(NewKey("consumer1", "key-of-consumer-1"))
+	repo.Add(NewKey("consumer2", "key-of-consumer-2"))
+
+	return repo
+}
+
+func TestAdd(t *testing.T) {
+	repo := newInMemoryRepo()
+
+	err := repo.Add(NewKey("consumer1", "another-key-of-consumer-1"))
+	assert.NoError(t, err)
+
+	err = repo.Add(NewKey("consumer3", "key-of-consumer-1"))
+	assert.Equal(t, ErrKeyExists, err, "the same key can not belong to two consumers")
+}
+
+func TestFindByHash(t *testing.T) {
+	repo := newInMemoryRepo()
+
+	key, err := repo.FindByHash(HashKey("key-of-consumer-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "consumer1", key.Consumer)
+
+	_, err = repo.FindByHash(HashKey("invalid"))
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestFindByConsumer(t *testing.T) {
+	repo := newInMemoryRepo()
+	repo.Add(NewKey("consumer1", "another-key-of-consumer-1"))
+
+	keys, err := repo.FindByConsumer("consumer1")
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	keys, err = repo.FindAll()
+	require.NoError(t, err)
+	assert.Len(t, keys, 3)
+}
+
+func TestSave(t *testing.T) {
+	repo := newInMemoryRepo()
+	key, err := repo.FindByHash(HashKey("key-of-consumer-1"))
+	require.NoError(t, err)
+
+	key.Consumer = "renamed"
+	require.NoError(t, repo.Save(key))
+
+	found, err := repo.FindByID(key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", found.Consumer)
+
+	other := NewKey("consumer3", "key-of-consumer-2")
+	assert.Equal(t, ErrKeyExists, repo.Save(other))
+}
+
+func TestRemove(t *testing.T) {
+	repo := newInMemoryRepo()
+	key, err := repo.FindByHash(HashKey("key-of-consumer-1"))
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Remove(key.ID))
+	_, err = repo.FindByHash(key.Hash)
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	assert.Equal(t, ErrKeyNotFound, repo.Remove("invalid"))
+}
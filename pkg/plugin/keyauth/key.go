@@ -0,0 +1,79 @@
+package keyauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+const (
+	// keyLength is the number of random bytes of the generated keys
+	keyLength = 32
+	// minKeyLength is the shortest key accepted when the key is provided instead of generated
+	minKeyLength = 16
+	// hintLength is the number of characters of the key kept in clear to tell the keys apart
+	hintLength = 4
+)
+
+// Key represents an API key of a consumer. Only the hash of the key is stored, the key itself is returned once
+// when it is created or rotated.
+type Key struct {
+	ID       string `json:"id" bson:"id"`
+	Consumer string `json:"consumer" bson:"consumer"`
+	// Hash is the hex encoded SHA-256 of the key
+	Hash string `json:"hash,omitempty" bson:"hash"`
+	// Hint holds the first characters of the key
+	Hint      string     `json:"hint,omitempty" bson:"hint"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+}
+
+// NewKey creates a new Key of the consumer for the given plain key
+func NewKey(consumer string, plain string) *Key {
+	hint := plain
+	if len(hint) > hintLength {
+		hint = hint[:hintLength]
+	}
+
+	return &Key{
+		ID:        uuid.NewV4().String(),
+		Consumer:  consumer,
+		Hash:      HashKey(plain),
+		Hint:      hint,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// HashKey returns the hash a key is stored and looked up by. The keys are long random strings, so a fast hash
+// is enough and lets the keys be looked up by their hash.
+func HashKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateKey returns a new random key
+func GenerateKey() (string, error) {
+	b := make([]byte, keyLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Expired tells if the key is expired at the given time
+func (k *Key) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// public returns a copy of the key without its hash, to be shown on the admin API
+func (k *Key) public() *Key {
+	c := *k
+	c.Hash = ""
+
+	return &c
+}
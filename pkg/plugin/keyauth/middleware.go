@@ -0,0 +1,86 @@
+package keyauth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/consumer"
+	"github.com/hellofresh/janus/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// NewKeyAuth is an API key auth middleware. The key is read from the configured header, or query parameter,
+// and looked up by its hash. The consumer owning the key is set on the request context and its id is sent
+// upstream in the consumer header, whatever value the client sent in it.
+func NewKeyAuth(repo Repository, config Config) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := log.WithFields(log.Fields{
+				"path":   r.RequestURI,
+				"origin": r.RemoteAddr,
+			})
+
+			r.Header.Del(config.ConsumerHeader)
+
+			plain := requestKey(r, config)
+			if plain == "" {
+				logger.Debug("No API key provided")
+				errors.Handler(w, ErrNotAuthorized)
+				return
+			}
+
+			key, err := repo.FindByHash(HashKey(plain))
+			if err == ErrKeyNotFound {
+				logger.Debug("Invalid API key provided")
+				errors.Handler(w, ErrNotAuthorized)
+				return
+			}
+			if err != nil {
+				logger.WithError(err).Error("Error when looking for the API key")
+				errors.Handler(w, errors.New(http.StatusInternalServerError, "there was an error when looking for the API key"))
+				return
+			}
+
+			if key.Expired(time.Now()) {
+				logger.WithField("key_id", key.ID).Debug("Expired API key provided")
+				errors.Handler(w, ErrNotAuthorized)
+				return
+			}
+
+			if config.HideCredentials {
+				hideKey(r, config)
+			}
+			r.Header.Set(config.ConsumerHeader, key.Consumer)
+
+			handler.ServeHTTP(w, consumer.Resolve(r, key.Consumer))
+		})
+	}
+}
+
+func requestKey(r *http.Request, config Config) string {
+	if config.Header != "" {
+		if key := r.Header.Get(config.Header); key != "" {
+			return key
+		}
+	}
+
+	if config.QueryParam != "" {
+		return r.URL.Query().Get(config.QueryParam)
+	}
+
+	return ""
+}
+
+func hideKey(r *http.Request, config Config) {
+	if config.Header != "" {
+		r.Header.Del(config.Header)
+	}
+
+	if config.QueryParam != "" {
+		query := r.URL.Query()
+		if _, ok := query[config.QueryParam]; ok {
+			query.Del(config.QueryParam)
+			r.URL.RawQuery = query.Encode()
+		}
+	}
+}
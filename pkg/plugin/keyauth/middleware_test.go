@@ -0,0 +1,100 @@
+package keyauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/consumer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyAuth(t *testing.T) {
+	t.Parallel()
+
+	repo := NewInMemoryRepository()
+	require.NoError(t, repo.Add(NewKey("consumer1", "valid-key-of-consumer-1")))
+	expired := NewKey("consumer1", "expired-key-of-consumer-1")
+	expiresAt := time.Now().Add(-time.Minute)
+	expired.ExpiresAt = &expiresAt
+	require.NoError(t, repo.Add(expired))
+
+	config := Config{Header: "X-Api-Key", QueryParam: "apikey", ConsumerHeader: "X-Consumer-ID", HideCredentials: true}
+
+	tests := []struct {
+		scenario string
+		url      string
+		headers  map[string]string
+		expected int
+		consumer string
+	}{
+		{
+			scenario: "when the key is in the header",
+			url:      "/",
+			headers:  map[string]string{"X-Api-Key": "valid-key-of-consumer-1"},
+			expected: http.StatusOK,
+			consumer: "consumer1",
+		},
+		{
+			scenario: "when the key is in the query",
+			url:      "/?apikey=valid-key-of-consumer-1&page=2",
+			expected: http.StatusOK,
+			consumer: "consumer1",
+		},
+		{
+			scenario: "when no key is given",
+			url:      "/",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			scenario: "when an unknown key is given",
+			url:      "/",
+			headers:  map[string]string{"X-Api-Key": "unknown"},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			scenario: "when an expired key is given",
+			url:      "/",
+			headers:  map[string]string{"X-Api-Key": "expired-key-of-consumer-1"},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			scenario: "when the client sends a consumer header",
+			url:      "/",
+			headers:  map[string]string{"X-Api-Key": "valid-key-of-consumer-1", "X-Consumer-ID": "admin"},
+			expected: http.StatusOK,
+			consumer: "consumer1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			var upstream *http.Request
+			var resolved *consumer.Consumer
+			handler := NewKeyAuth(repo, config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstream = r
+				resolved, _ = consumer.FromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, test.url, nil)
+			for k, v := range test.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			require.Equal(t, test.expected, w.Code)
+			if test.expected != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, test.consumer, upstream.Header.Get("X-Consumer-ID"))
+			require.NotNil(t, resolved)
+			assert.Equal(t, test.consumer, resolved.ID)
+			assert.Empty(t, upstream.Header.Get("X-Api-Key"), "the key is not sent upstream")
+			assert.Empty(t, upstream.URL.Query().Get("apikey"), "the key is not sent upstream")
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package keyauth
+
+import (
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	collectionName string = "key_auth"
+)
+
+// MongoRepository represents a mongodb repository
+type MongoRepository struct {
+	session *mgo.Session
+}
+
+// NewMongoRepository creates a mongo key repository
+func NewMongoRepository(session *mgo.Session) (*MongoRepository, error) {
+	if session == nil {
+		return nil, ErrInvalidMongoDBSession
+	}
+
+	return &MongoRepository{session}, nil
+}
+
+// EnsureIndexes creates the unique indexes on the key id and hash and the consumer index
+func (r *MongoRepository) EnsureIndexes() error {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	for _, index := range []mgo.Index{
+		{Key: []string{"id"}, Unique: true, Background: true},
+		{Key: []string{"hash"}, Unique: true, Background: true},
+		{Key: []string{"consumer"}, Background: true},
+	} {
+		if err := coll.EnsureIndex(index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindAll fetches all the keys available
+func (r *MongoRepository) FindAll() ([]*Key, error) {
+	return r.findByQuery(nil)
+}
+
+// FindByConsumer fetches the keys of a consumer
+func (r *MongoRepository) FindByConsumer(consumer string) ([]*Key, error) {
+	return r.findByQuery(bson.M{"consumer": consumer})
+}
+
+// FindByID finds a key by id
+func (r *MongoRepository) FindByID(id string) (*Key, error) {
+	return r.findOneByQuery(bson.M{"id": id})
+}
+
+// FindByHash finds a key by the hash of its value
+func (r *MongoRepository) FindByHash(hash string) (*Key, error) {
+	return r.findOneByQuery(bson.M{"hash": hash})
+}
+
+// Add adds a new key to the repository
+func (r *MongoRepository) Add(key *Key) error {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	if err := coll.Insert(key); err != nil {
+		if mgo.IsDup(err) {
+			return ErrKeyExists
+		}
+		log.WithError(err).WithField("id", key.ID).Error("There was an error adding the key")
+		return err
+	}
+
+	log.WithField("id", key.ID).Debug("Key added")
+	return nil
+}
+
+// Save saves a key to the repository
+func (r *MongoRepository) Save(key *Key) error {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	if _, err := coll.Upsert(bson.M{"id": key.ID}, key); err != nil {
+		if mgo.IsDup(err) {
+			return ErrKeyExists
+		}
+		log.WithError(err).WithField("id", key.ID).Error("There was an error saving the key")
+		return err
+	}
+
+	log.WithField("id", key.ID).Debug("Key saved")
+	return nil
+}
+
+// Remove removes a key from the repository
+func (r *MongoRepository) Remove(id string) error {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	if err := coll.Remove(bson.M{"id": id}); err != nil {
+		if err == mgo.ErrNotFound {
+			return ErrKeyNotFound
+		}
+		log.WithError(err).WithField("id", id).Error("There was an error removing the key")
+		return err
+	}
+
+	log.WithField("id", id).Debug("Key removed")
+	return nil
+}
+
+func (r *MongoRepository) findByQuery(query interface{}) ([]*Key, error) {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	result := []*Key{}
+	if err := coll.Find(query).Sort("created_at").All(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *MongoRepository) findOneByQuery(query interface{}) (*Key, error) {
+	session, coll := r.getSession()
+	defer session.Close()
+
+	var result Key
+	if err := coll.Find(query).One(&result); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *MongoRepository) getSession() (*mgo.Session, *mgo.Collection) {
+	session := r.session.Copy()
+	coll := session.DB("").C(collectionName)
+
+	return session, coll
+}
@@ -0,0 +1,84 @@
+package keyauth
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/globalsign/mgo"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	mongodb = "mongodb"
+	file    = "file"
+)
+
+// Repository represents a key repository
+type Repository interface {
+	FindAll() ([]*Key, error)
+	FindByConsumer(consumer string) ([]*Key, error)
+	FindByID(id string) (*Key, error)
+	FindByHash(hash string) (*Key, error)
+	Add(key *Key) error
+	Save(key *Key) error
+	Remove(id string) error
+}
+
+// BuildRepository creates the key repository for the given database DSN
+func BuildRepository(dsn string, session *mgo.Session) (Repository, error) {
+	dsnURL, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing the DSN")
+	}
+
+	switch dsnURL.Scheme {
+	case mongodb:
+		return NewMongoRepository(session)
+	case file:
+		keysPath := fmt.Sprintf("%s/key_auth", dsnURL.Path)
+		log.WithField("path", keysPath).Debug("Trying to load API key files")
+
+		repo, err := NewFileSystemRepository(keysPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not create a file based repository for the API keys")
+		}
+		return repo, nil
+	default:
+		return nil, errors.New("The selected scheme is not supported to load API keys")
+	}
+}
+
+// credentialSource lists and removes the keys of the consumers for the consumers admin API
+type credentialSource struct {
+	repo Repository
+}
+
+func (s credentialSource) FindByConsumer(id string) (interface{}, error) {
+	keys, err := s.repo.FindByConsumer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	public := make([]*Key, 0, len(keys))
+	for _, key := range keys {
+		public = append(public, key.public())
+	}
+
+	return public, nil
+}
+
+func (s credentialSource) RemoveByConsumer(id string) error {
+	keys, err := s.repo.FindByConsumer(id)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := s.repo.Remove(key.ID); err != nil && err != ErrKeyNotFound {
+			return err
+		}
+	}
+
+	return nil
+}
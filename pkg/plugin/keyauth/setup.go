@@ -0,0 +1,139 @@
+package keyauth
+
+import (
+	"github.com/asaskevich/govalidator"
+	"github.com/hellofresh/janus/pkg/consumer"
+	"github.com/hellofresh/janus/pkg/jwt"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/router"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	pluginName = "key_auth"
+
+	defaultHeader         = "X-Api-Key"
+	defaultConsumerHeader = "X-Consumer-ID"
+)
+
+var (
+	repo        Repository
+	adminRouter router.Router
+)
+
+// Config represents the key auth configuration
+type Config struct {
+	// Header is the request header the key is read from
+	Header string `json:"header"`
+	// QueryParam is the query parameter the key is read from when the header is missing
+	QueryParam string `json:"query_param"`
+	// HideCredentials removes the key from the request sent upstream
+	HideCredentials bool `json:"hide_credentials"`
+	// ConsumerHeader is the header the consumer id is sent upstream in
+	ConsumerHeader string `json:"consumer_header" valid:"required"`
+}
+
+func init() {
+	plugin.RegisterEventHook(plugin.StartupEvent, onStartup)
+	plugin.RegisterEventHook(plugin.AdminAPIStartupEvent, onAdminAPIStartup)
+	plugin.RegisterPlugin(pluginName, plugin.Plugin{
+		Action:   setupKeyAuth,
+		Validate: validateConfig,
+	})
+}
+
+func setupKeyAuth(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
+	if repo == nil {
+		return errors.New("the repository was not set by onStartup event")
+	}
+
+	config, err := decodeConfig(rawConfig)
+	if err != nil {
+		return err
+	}
+
+	def.AddMiddleware(NewKeyAuth(repo, config))
+	return nil
+}
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	config, err := decodeConfig(rawConfig)
+	if err != nil {
+		return false, err
+	}
+
+	return govalidator.ValidateStruct(config)
+}
+
+func decodeConfig(rawConfig plugin.Config) (Config, error) {
+	config := Config{
+		Header:         defaultHeader,
+		ConsumerHeader: defaultConsumerHeader,
+	}
+	if err := plugin.Decode(rawConfig, &config); err != nil {
+		return config, err
+	}
+
+	if config.Header == "" && config.QueryParam == "" {
+		return config, errors.New("either a header or a query parameter is needed to read the key from")
+	}
+
+	return config, nil
+}
+
+func onAdminAPIStartup(event interface{}) error {
+	e, ok := event.(plugin.OnAdminAPIStartup)
+	if !ok {
+		return errors.New("could not convert event to admin startup type")
+	}
+
+	adminRouter = e.Router
+	return nil
+}
+
+func onStartup(event interface{}) error {
+	e, ok := event.(plugin.OnStartup)
+	if !ok {
+		return errors.New("could not convert event to startup type")
+	}
+
+	if adminRouter == nil {
+		return ErrInvalidAdminRouter
+	}
+
+	if e.Config == nil {
+		return errors.New("the database configuration is needed to load the API keys")
+	}
+
+	r, err := BuildRepository(e.Config.Database.DSN, e.MongoSession)
+	if err != nil {
+		return err
+	}
+
+	if mongoRepo, ok := r.(*MongoRepository); ok {
+		if err := mongoRepo.EnsureIndexes(); err != nil {
+			return errors.Wrap(err, "Failed to create indexes for the API keys repository")
+		}
+	}
+
+	repo = r
+	consumer.RegisterCredentialSource(pluginName, credentialSource{repo})
+	log.WithField("plugin", pluginName).Debug("Registering API keys endpoints")
+
+	handlers := NewHandler(repo)
+	guard := jwt.NewGuard(e.Config.Web.Credentials)
+	group := adminRouter.Group("/credentials/key_auth")
+	group.Use(jwt.NewMiddleware(guard).Handler)
+	{
+		group.GET("/", handlers.Index())
+		group.POST("/", handlers.Create())
+		group.GET("/{id}", handlers.Show())
+		group.PUT("/{id}", handlers.Update())
+		group.DELETE("/{id}", handlers.Delete())
+		group.POST("/{id}/rotate", handlers.Rotate())
+	}
+
+	return nil
+}
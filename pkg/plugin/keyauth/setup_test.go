@@ -0,0 +1,71 @@
+package keyauth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hellofresh/janus/pkg/config"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "janus")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "key_auth"), 0700))
+
+	r := router.NewChiRouter()
+	err = onAdminAPIStartup(plugin.OnAdminAPIStartup{Router: r})
+	require.NoError(t, err)
+
+	event := plugin.OnStartup{Config: &config.Specification{Database: config.Database{DSN: "file://" + dir}}}
+	err = onStartup(event)
+	require.NoError(t, err)
+	assert.IsType(t, &FileSystemRepository{}, repo)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/credentials/key_auth/", strings.NewReader(`{"consumer": "mobile-app"}`)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "the API keys endpoints need an admin token")
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	err = setupKeyAuth(def, map[string]interface{}{"query_param": "apikey"})
+	require.NoError(t, err)
+	assert.Len(t, def.Middleware(), 1)
+}
+
+func TestValidateConfig(t *testing.T) {
+	result, err := validateConfig(map[string]interface{}{"header": "Api-Key", "consumer_header": "X-Consumer"})
+	assert.True(t, result)
+	assert.NoError(t, err)
+
+	result, err = validateConfig(map[string]interface{}{"header": ""})
+	assert.False(t, result)
+	assert.Error(t, err)
+}
+
+func TestOnStartupWithMongoWithoutSession(t *testing.T) {
+	require.NoError(t, onAdminAPIStartup(plugin.OnAdminAPIStartup{Router: router.NewChiRouter()}))
+
+	event := plugin.OnStartup{Config: &config.Specification{Database: config.Database{DSN: "mongodb://localhost:27017/janus"}}}
+	err := onStartup(event)
+	assert.Equal(t, ErrInvalidMongoDBSession, err)
+}
+
+func TestOnStartupWrongEvent(t *testing.T) {
+	err := onStartup(plugin.OnAdminAPIStartup{})
+	require.Error(t, err)
+}
+
+func TestOnAdminAPIStartupWrongEvent(t *testing.T) {
+	err := onAdminAPIStartup(plugin.OnStartup{})
+	require.Error(t, err)
+}
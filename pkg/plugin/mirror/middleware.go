@@ -0,0 +1,118 @@
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
+	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/hellofresh/janus/pkg/request"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+	resultSkipped = "skipped"
+	resultDropped = "dropped"
+)
+
+// NewMirrorMiddleware creates a new mirror middleware. The sampled requests are copied to the mirror target in
+// background, the mirror response is discarded and never affects the primary one.
+func NewMirrorMiddleware(name string, cfg Config) func(http.Handler) http.Handler {
+	limit, err := bytefmt.ToBytes(cfg.BodyLimit)
+	if err != nil {
+		log.WithError(err).WithField("body_limit", cfg.BodyLimit).Error("invalid mirror body_limit")
+	}
+
+	target := strings.TrimSuffix(cfg.Target, "/")
+	client := &http.Client{Timeout: time.Duration(cfg.Timeout)}
+	inFlight := make(chan struct{}, cfg.MaxConcurrent)
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Percentage <= 0 || rand.Intn(100) >= cfg.Percentage {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			body, ok := request.BufferBody(r, int64(limit))
+			if !ok {
+				log.WithField("api_name", name).Debug("Request body is too big to be mirrored")
+				record(name, resultSkipped, 0)
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			req, err := http.NewRequest(r.Method, target+r.URL.RequestURI(), bytes.NewReader(body))
+			if err != nil {
+				log.WithError(err).WithField("api_name", name).Error("Could not create the mirror request")
+				record(name, resultFailure, 0)
+				handler.ServeHTTP(w, r)
+				return
+			}
+			req.Header = request.CloneHeader(r.Header)
+
+			select {
+			case inFlight <- struct{}{}:
+				go func() {
+					defer func() { <-inFlight }()
+					send(name, client, req)
+				}()
+			default:
+				log.WithField("api_name", name).Debug("Too many mirror requests in flight, dropping")
+				record(name, resultDropped, 0)
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+func send(name string, client *http.Client, req *http.Request) {
+	start := time.Now()
+
+	resp, err := client.Do(req)
+	latency := float64(time.Since(start)) / float64(time.Millisecond)
+	if err != nil {
+		log.WithError(err).WithField("api_name", name).Debug("Mirror request failed")
+		record(name, resultFailure, latency)
+		return
+	}
+	defer resp.Body.Close()
+
+	// drain the body so the connection can be reused
+	io.Copy(ioutil.Discard, resp.Body)
+
+	result := resultSuccess
+	if resp.StatusCode >= http.StatusInternalServerError {
+		result = resultFailure
+	}
+	record(name, result, latency)
+}
+
+func record(name string, result string, latency float64) {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(obs.KeyAPIName, name),
+		tag.Insert(obs.KeyResult, result),
+	)
+	if err != nil {
+		log.WithError(err).Debug("Failed to tag mirror metrics")
+		return
+	}
+
+	measurements := []stats.Measurement{obs.MMirrorRequests.M(1)}
+	if latency > 0 {
+		measurements = append(measurements, obs.MMirrorLatency.M(latency))
+	}
+	stats.Record(ctx, measurements...)
+}
@@ -0,0 +1,199 @@
+package mirror
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mirrored struct {
+	method string
+	uri    string
+	header string
+	body   string
+}
+
+func newShadow(t *testing.T, handler func(w http.ResponseWriter)) (*httptest.Server, chan mirrored) {
+	requests := make(chan mirrored, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		requests <- mirrored{method: r.Method, uri: r.URL.RequestURI(), header: r.Header.Get("X-Test"), body: string(body)}
+		handler(w)
+	}))
+
+	return server, requests
+}
+
+func echo(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Write(body)
+}
+
+func newConfig(target string) Config {
+	return Config{
+		Target:        target,
+		Percentage:    100,
+		BodyLimit:     "1K",
+		Timeout:       proxy.Duration(time.Second),
+		MaxConcurrent: 10,
+	}
+}
+
+func TestMirrorCopiesRequest(t *testing.T) {
+	shadow, requests := newShadow(t, func(w http.ResponseWriter) {})
+	defer shadow.Close()
+
+	mw := NewMirrorMiddleware("test", newConfig(shadow.URL))
+
+	r := httptest.NewRequest(http.MethodPost, "/orders?id=1", strings.NewReader("payload"))
+	r.Header.Set("X-Test", "value")
+	w := httptest.NewRecorder()
+
+	mw(http.HandlerFunc(echo)).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "payload", w.Body.String())
+
+	select {
+	case m := <-requests:
+		assert.Equal(t, mirrored{method: http.MethodPost, uri: "/orders?id=1", header: "value", body: "payload"}, m)
+	case <-time.After(time.Second):
+		t.Fatal("request was not mirrored")
+	}
+}
+
+func TestMirrorSkipsBigBodies(t *testing.T) {
+	shadow, requests := newShadow(t, func(w http.ResponseWriter) {})
+	defer shadow.Close()
+
+	mw := NewMirrorMiddleware("test", newConfig(shadow.URL))
+
+	content := bytes.Repeat([]byte("a"), 2048)
+	r := httptest.NewRequest(http.MethodPost, "/", ioutil.NopCloser(bytes.NewReader(content)))
+	r.ContentLength = -1
+	w := httptest.NewRecorder()
+
+	mw(http.HandlerFunc(echo)).ServeHTTP(w, r)
+
+	assert.Equal(t, content, w.Body.Bytes())
+
+	select {
+	case <-requests:
+		t.Fatal("request with a body over the limit was mirrored")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMirrorDoesNotAffectPrimary(t *testing.T) {
+	tests := []struct {
+		scenario string
+		target   func() (string, func())
+	}{
+		{
+			scenario: "slow mirror",
+			target: func() (string, func()) {
+				release := make(chan struct{})
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					<-release
+				}))
+				return server.URL, func() {
+					close(release)
+					server.Close()
+				}
+			},
+		},
+		{
+			scenario: "failing mirror",
+			target: func() (string, func()) {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+				}))
+				return server.URL, server.Close
+			},
+		},
+		{
+			scenario: "unreachable mirror",
+			target: func() (string, func()) {
+				server := httptest.NewServer(http.NotFoundHandler())
+				server.Close()
+				return server.URL, func() {}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			target, cleanup := tt.target()
+			defer cleanup()
+
+			mw := NewMirrorMiddleware("test", newConfig(target))
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload"))
+			w := httptest.NewRecorder()
+
+			start := time.Now()
+			mw(http.HandlerFunc(echo)).ServeHTTP(w, r)
+
+			assert.True(t, time.Since(start) < 500*time.Millisecond)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "payload", w.Body.String())
+		})
+	}
+}
+
+func TestMirrorPercentage(t *testing.T) {
+	shadow, requests := newShadow(t, func(w http.ResponseWriter) {})
+	defer shadow.Close()
+
+	config := newConfig(shadow.URL)
+	config.Percentage = 0
+	mw := NewMirrorMiddleware("test", config)
+
+	for i := 0; i < 10; i++ {
+		mw(http.HandlerFunc(echo)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	select {
+	case <-requests:
+		t.Fatal("request was mirrored with a zero percentage")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMirrorDropsWhenSaturated(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan struct{}, 10)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer shadow.Close()
+	defer close(release)
+
+	config := newConfig(shadow.URL)
+	config.MaxConcurrent = 1
+	mw := NewMirrorMiddleware("test", config)
+
+	mw(http.HandlerFunc(echo)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		require.FailNow(t, "request was not mirrored")
+	}
+
+	mw(http.HandlerFunc(echo)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	select {
+	case <-received:
+		t.Fatal("request was mirrored over the concurrency limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
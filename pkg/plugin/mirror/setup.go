@@ -0,0 +1,77 @@
+package mirror
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
+	"github.com/asaskevich/govalidator"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultBodyLimit     = "1M"
+	defaultTimeout       = 5 * time.Second
+	defaultMaxConcurrent = 100
+)
+
+// Config represents the mirror configuration
+type Config struct {
+	// Target is the shadow upstream the requests are copied to
+	Target     string `json:"target" valid:"url,required"`
+	Percentage int    `json:"percentage" valid:"range(0|100)"`
+	// BodyLimit is the biggest request body that is mirrored, requests with bigger bodies are not mirrored
+	BodyLimit string         `json:"body_limit"`
+	Timeout   proxy.Duration `json:"timeout"`
+	// MaxConcurrent is the number of mirrored requests in flight, at least one
+	MaxConcurrent int `json:"max_concurrent"`
+}
+
+func init() {
+	plugin.RegisterPlugin("mirror", plugin.Plugin{
+		Action:   setupMirror,
+		Validate: validateConfig,
+	})
+}
+
+func setupMirror(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
+	config, err := decodeConfig(rawConfig)
+	if err != nil {
+		return err
+	}
+
+	def.AddMiddleware(NewMirrorMiddleware(def.Name, config))
+	return nil
+}
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	config, err := decodeConfig(rawConfig)
+	if err != nil {
+		return false, err
+	}
+
+	return govalidator.ValidateStruct(config)
+}
+
+func decodeConfig(rawConfig plugin.Config) (Config, error) {
+	config := Config{
+		Percentage:    100,
+		BodyLimit:     defaultBodyLimit,
+		Timeout:       proxy.Duration(defaultTimeout),
+		MaxConcurrent: defaultMaxConcurrent,
+	}
+	if err := plugin.Decode(rawConfig, &config); err != nil {
+		return config, err
+	}
+
+	if _, err := bytefmt.ToBytes(config.BodyLimit); err != nil {
+		return config, errors.Wrap(err, "invalid mirror body_limit")
+	}
+
+	if config.MaxConcurrent < 1 {
+		return config, errors.Errorf("invalid mirror max_concurrent %d, at least one request must be allowed", config.MaxConcurrent)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,75 @@
+package mirror
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup(t *testing.T) {
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	err := setupMirror(def, plugin.Config{"target": "http://shadow.local"})
+	require.NoError(t, err)
+
+	assert.Len(t, def.Middleware(), 1)
+}
+
+func TestDecodeConfigDefaults(t *testing.T) {
+	config, err := decodeConfig(plugin.Config{"target": "http://shadow.local"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 100, config.Percentage)
+	assert.Equal(t, defaultBodyLimit, config.BodyLimit)
+	assert.Equal(t, defaultTimeout, time.Duration(config.Timeout))
+	assert.Equal(t, defaultMaxConcurrent, config.MaxConcurrent)
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		scenario string
+		config   plugin.Config
+		valid    bool
+	}{
+		{
+			scenario: "valid config",
+			config:   plugin.Config{"target": "http://shadow.local", "percentage": 10, "timeout": "1s"},
+			valid:    true,
+		},
+		{
+			scenario: "missing target",
+			config:   plugin.Config{"percentage": 10},
+		},
+		{
+			scenario: "percentage out of range",
+			config:   plugin.Config{"target": "http://shadow.local", "percentage": 120},
+		},
+		{
+			scenario: "invalid body limit",
+			config:   plugin.Config{"target": "http://shadow.local", "body_limit": "lots"},
+		},
+		{
+			scenario: "no concurrent mirrored requests",
+			config:   plugin.Config{"target": "http://shadow.local", "max_concurrent": 0},
+		},
+		{
+			scenario: "negative concurrent mirrored requests",
+			config:   plugin.Config{"target": "http://shadow.local", "max_concurrent": -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			valid, err := validateConfig(tt.config)
+			assert.Equal(t, tt.valid, valid)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
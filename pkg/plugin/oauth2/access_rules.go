@@ -0,0 +1,169 @@
+package oauth2
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Knetic/govaluate"
+	"github.com/pkg/errors"
+)
+
+const (
+	accessAllow = "allow"
+	accessDeny  = "deny"
+	accessError = "error"
+
+	headerParameterPrefix = "header."
+)
+
+// accessRuleFunctions are the functions the access rule predicates can call
+var accessRuleFunctions = map[string]govaluate.ExpressionFunction{
+	// cidr checks the IP, the first argument, is in the CIDR block, the second argument
+	"cidr": func(args ...interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, errors.New("cidr expects an IP and a CIDR block")
+		}
+
+		ip, _ := args[0].(string)
+		block, _ := args[1].(string)
+		_, network, err := net.ParseCIDR(block)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed := net.ParseIP(ip)
+		return parsed != nil && network.Contains(parsed), nil
+	},
+}
+
+// AccessRule represents a rule that allows or denies the requests matching its predicate
+type AccessRule struct {
+	Predicate string `bson:"predicate" json:"predicate"`
+	Action    string `bson:"action" json:"action"`
+}
+
+// AccessRules are the compiled access rules of an oauth server. The rules are evaluated in order, the first rule
+// whose predicate matches decides and the default action decides when none does.
+//
+// The predicates are govaluate expressions referencing the token claims by name, nested claims with a dot separated
+// path in brackets, e.g. `[realm.roles]`, and the request with `method`, `path`, `ip` and the headers as
+// `[header.X-Name]`. The request parameters take precedence over the claims with the same name.
+type AccessRules struct {
+	rules         []*compiledAccessRule
+	defaultAction string
+}
+
+type compiledAccessRule struct {
+	action     string
+	expression *govaluate.EvaluableExpression
+}
+
+// AccessDecision is the decision of the access rules for a request
+type AccessDecision struct {
+	Allowed bool
+	// Rule is the index of the matching rule, -1 when the default action decided
+	Rule int
+	// Errors are the errors of the predicates that could not be evaluated, by rule index
+	Errors map[int]error
+}
+
+// NewAccessRules compiles the access rules. The default action is allow when empty.
+func NewAccessRules(rules []*AccessRule, defaultAction string) (*AccessRules, error) {
+	if defaultAction == "" {
+		defaultAction = accessAllow
+	}
+	if defaultAction != accessAllow && defaultAction != accessDeny {
+		return nil, fmt.Errorf("unknown default access rules action %q", defaultAction)
+	}
+
+	compiled := make([]*compiledAccessRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Action != accessAllow && rule.Action != accessDeny {
+			return nil, fmt.Errorf("unknown action %q of the access rule %d", rule.Action, i)
+		}
+		if strings.TrimSpace(rule.Predicate) == "" {
+			return nil, fmt.Errorf("the access rule %d has no predicate", i)
+		}
+
+		expression, err := govaluate.NewEvaluableExpressionWithFunctions(rule.Predicate, accessRuleFunctions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not compile the predicate of the access rule %d", i)
+		}
+
+		compiled = append(compiled, &compiledAccessRule{action: rule.Action, expression: expression})
+	}
+
+	return &AccessRules{rules: compiled, defaultAction: defaultAction}, nil
+}
+
+// Len returns the number of rules
+func (a *AccessRules) Len() int {
+	return len(a.rules)
+}
+
+// Decide evaluates the rules against the request and its token claims. A predicate that can not be evaluated,
+// for instance because it references a claim the token does not have, fails closed: a deny rule matches and an
+// allow rule does not.
+func (a *AccessRules) Decide(r *http.Request, claims map[string]interface{}) AccessDecision {
+	var errs map[int]error
+	params := accessParameters{r: r, claims: claims}
+	for i, rule := range a.rules {
+		result, err := rule.expression.Eval(params)
+		if err != nil {
+			if errs == nil {
+				errs = make(map[int]error)
+			}
+			errs[i] = err
+
+			if rule.action == accessDeny {
+				return AccessDecision{Allowed: false, Rule: i, Errors: errs}
+			}
+			continue
+		}
+
+		if matched, ok := result.(bool); ok && matched {
+			return AccessDecision{Allowed: rule.action == accessAllow, Rule: i, Errors: errs}
+		}
+	}
+
+	return AccessDecision{Allowed: a.defaultAction == accessAllow, Rule: -1, Errors: errs}
+}
+
+// accessParameters resolves the parameters of the access rule predicates
+type accessParameters struct {
+	r      *http.Request
+	claims map[string]interface{}
+}
+
+func (p accessParameters) Get(name string) (interface{}, error) {
+	switch name {
+	case "method":
+		return p.r.Method, nil
+	case "path":
+		return p.r.URL.Path, nil
+	case "ip":
+		return clientIP(p.r), nil
+	}
+
+	if strings.HasPrefix(name, headerParameterPrefix) {
+		return p.r.Header.Get(strings.TrimPrefix(name, headerParameterPrefix)), nil
+	}
+
+	if value := lookupClaim(p.claims, name); value != nil {
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("no parameter %s found", name)
+}
+
+// clientIP returns the IP the request comes from. The forwarding headers are not used, they are set by the client.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,186 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultClaimHeadersPrefix is the prefix of the claim headers when none is configured
+	DefaultClaimHeadersPrefix = "X-Jwt-Claim-"
+
+	// encodingAuto sends scalar claims as they are, lists joined by the separator and objects as JSON
+	encodingAuto = "auto"
+	// encodingJSON sends the claims JSON encoded
+	encodingJSON = "json"
+	// encodingJoin sends the scalar items of list claims joined by the separator
+	encodingJoin = "join"
+
+	defaultClaimHeaderSeparator = ","
+)
+
+var (
+	headerNameRegexp = regexp.MustCompile("^[A-Za-z0-9-]+$")
+
+	// reservedHeaders can not be set from claims, they are used by the proxy or the upstreams to route and
+	// authenticate the requests
+	reservedHeaders = map[string]bool{
+		"Authorization":       true,
+		"Connection":          true,
+		"Content-Length":      true,
+		"Content-Type":        true,
+		"Cookie":              true,
+		"Forwarded":           true,
+		"Host":                true,
+		"Proxy-Authorization": true,
+		"Te":                  true,
+		"Trailer":             true,
+		"Transfer-Encoding":   true,
+		"Upgrade":             true,
+		"X-Forwarded-For":     true,
+		"X-Forwarded-Host":    true,
+		"X-Forwarded-Proto":   true,
+		"X-Real-Ip":           true,
+	}
+)
+
+// ClaimHeaders maps the claims of the JWT to the headers sent upstream. All the headers are named with the prefix,
+// the incoming headers starting with it are removed so the upstreams can trust them.
+type ClaimHeaders struct {
+	// Prefix of the header names, DefaultClaimHeadersPrefix when empty
+	Prefix string `json:"prefix"`
+	// Claims are the mapped claims
+	Claims []*ClaimHeader `json:"claims"`
+}
+
+// ClaimHeader maps a claim to a header. The claim is a dot separated path for nested claims, the header is
+// named after the prefix.
+type ClaimHeader struct {
+	Claim string `json:"claim"`
+	// Header is the header name, without the prefix
+	Header string `json:"header"`
+	// Encoding is either auto, json or join, auto when empty
+	Encoding string `json:"encoding"`
+	// Separator joins the items of list claims, a comma when empty
+	Separator string `json:"separator"`
+
+	name string
+}
+
+// IsEmpty checks if no claim is mapped
+func (c *ClaimHeaders) IsEmpty() bool {
+	return c == nil || len(c.Claims) == 0
+}
+
+// Compile checks the mapping and sets the defaults
+func (c *ClaimHeaders) Compile() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.Prefix == "" {
+		c.Prefix = DefaultClaimHeadersPrefix
+	}
+	if !headerNameRegexp.MatchString(c.Prefix) {
+		return fmt.Errorf("invalid claim headers prefix %q", c.Prefix)
+	}
+
+	names := make(map[string]bool, len(c.Claims))
+	for _, h := range c.Claims {
+		if h.Claim == "" {
+			return fmt.Errorf("claim header %s without claim", h.Header)
+		}
+		if !headerNameRegexp.MatchString(h.Header) {
+			return fmt.Errorf("invalid header name %q of the claim %s", h.Header, h.Claim)
+		}
+
+		h.name = http.CanonicalHeaderKey(c.Prefix + h.Header)
+		if reservedHeaders[h.name] {
+			return fmt.Errorf("the header %s of the claim %s is reserved", h.name, h.Claim)
+		}
+		if names[h.name] {
+			return fmt.Errorf("the header %s is mapped more than once", h.name)
+		}
+		names[h.name] = true
+
+		switch h.Encoding {
+		case "":
+			h.Encoding = encodingAuto
+		case encodingAuto, encodingJSON, encodingJoin:
+		default:
+			return fmt.Errorf("unknown encoding %q of the claim %s", h.Encoding, h.Claim)
+		}
+		if h.Separator == "" {
+			h.Separator = defaultClaimHeaderSeparator
+		}
+	}
+
+	return nil
+}
+
+// Strip removes the request headers named with the prefix
+func (c *ClaimHeaders) Strip(header http.Header) {
+	prefix := http.CanonicalHeaderKey(c.Prefix)
+	for name := range header {
+		if strings.HasPrefix(http.CanonicalHeaderKey(name), prefix) {
+			delete(header, name)
+		}
+	}
+}
+
+// Set sets the headers of the mapped claims. Missing claims and values that can not be sent in a header are skipped.
+func (c *ClaimHeaders) Set(header http.Header, claims map[string]interface{}) {
+	for _, h := range c.Claims {
+		value, ok := h.encode(lookupClaim(claims, h.Claim))
+		if ok && isValidHeaderValue(value) {
+			header.Set(h.name, value)
+		}
+	}
+}
+
+func (h *ClaimHeader) encode(value interface{}) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+
+	if h.Encoding == encodingJSON {
+		return marshalClaim(value)
+	}
+
+	if s, ok := scalarValue(value); ok {
+		return s, true
+	}
+
+	if _, ok := value.([]interface{}); ok {
+		return strings.Join(claimValues(value), h.Separator), true
+	}
+
+	if h.Encoding == encodingAuto {
+		return marshalClaim(value)
+	}
+
+	return "", false
+}
+
+func marshalClaim(value interface{}) (string, bool) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+
+	return string(encoded), true
+}
+
+// isValidHeaderValue checks the value has no control characters, so a claim can not add headers to the request
+func isValidHeaderValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if b := value[i]; b < ' ' && b != '\t' || b == 0x7f {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,33 @@
+package oauth2
+
+import (
+	"context"
+
+	"github.com/hellofresh/janus/pkg/jwt"
+)
+
+// claimsKey holds the verified claims of the JWT of the request
+var claimsKey = ContextKey("claims")
+
+// withTokenClaims verifies the JWT and returns a context holding its claims, the middleware after the key exists
+// one read them from there instead of verifying the token again. Opaque and invalid tokens leave the context
+// without claims.
+func withTokenClaims(ctx context.Context, parser *jwt.Parser, accessToken string) context.Context {
+	token, err := parser.Parse(accessToken)
+	if err != nil || !token.Valid {
+		return ctx
+	}
+
+	claims, ok := parser.GetMapClaims(token)
+	if !ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, claimsKey, map[string]interface{}(claims))
+}
+
+// claimsFromContext returns the verified claims of the JWT of the request
+func claimsFromContext(ctx context.Context) (map[string]interface{}, bool) {
+	claims, ok := ctx.Value(claimsKey).(map[string]interface{})
+	return claims, ok
+}
@@ -0,0 +1,43 @@
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	basejwt "github.com/dgrijalva/jwt-go"
+	"github.com/hellofresh/janus/pkg/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// withRequestClaims verifies the JWT of the requests like the key exists middleware does, for the tests of the
+// middleware reading the claims from the context
+func withRequestClaims(parser *jwt.Parser, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		next := mw(handler)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if accessToken, err := parser.ParseRequest(r); err == nil {
+				r = r.WithContext(withTokenClaims(r.Context(), parser, accessToken))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestWithTokenClaims(t *testing.T) {
+	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: "secret"}))
+
+	ctx := withTokenClaims(context.Background(), parser, policyToken(t, basejwt.MapClaims{"sub": "user-42"}))
+	claims, ok := claimsFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "user-42", claims["sub"])
+
+	forged, err := basejwt.NewWithClaims(basejwt.SigningMethodHS256, basejwt.MapClaims{"sub": "admin"}).SignedString([]byte("other"))
+	assert.NoError(t, err)
+
+	for _, accessToken := range []string{forged, "opaque-token"} {
+		_, ok := claimsFromContext(withTokenClaims(context.Background(), parser, accessToken))
+		assert.False(t, ok, accessToken)
+	}
+}
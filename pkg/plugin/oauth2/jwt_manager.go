@@ -35,6 +35,11 @@ func (m *JWTManager) IsKeyAuthorized(ctx context.Context, accessToken string) bo
 		return false
 	}
 
+	// the token was already verified by the key exists middleware
+	if _, ok := claimsFromContext(ctx); ok {
+		return true
+	}
+
 	if _, err := m.parser.Parse(accessToken); err != nil {
 		log.WithError(err).Info("Failed to parse and validate the JWT")
 
@@ -73,9 +73,14 @@ func (f *ManagerFactory) Build(t ManagerType) (Manager, error) {
 		for i, signingMethod := range signingMethods {
 			logEntry = logEntry.WithField(fmt.Sprintf("alg_%d", i), signingMethod.Alg)
 		}
+		parserConfig := jwt.NewParserConfig(f.oAuthServer.TokenStrategy.Leeway, signingMethods...)
+		parserConfig.JWKS = f.oAuthServer.TokenStrategy.GetJWKS()
+		if parserConfig.JWKS != nil {
+			logEntry = logEntry.WithField("jwks", parserConfig.JWKS.URL())
+		}
 		logEntry.Debug("Building JWT token parser")
 
-		return NewJWTManager(jwt.NewParser(jwt.NewParserConfig(f.oAuthServer.TokenStrategy.Leeway, signingMethods...))), nil
+		return NewJWTManager(jwt.NewParser(parserConfig)), nil
 	case Introspection:
 		settings, err := f.oAuthServer.TokenStrategy.GetIntrospectionSettings()
 		if nil != err {
@@ -37,7 +37,8 @@ func (c ContextKey) String() string {
 	return "janus." + string(c)
 }
 
-// NewKeyExistsMiddleware creates a new instance of KeyExistsMiddleware
+// NewKeyExistsMiddleware creates a new instance of KeyExistsMiddleware. The claims of a valid JWT are put on the
+// request context for the oauth2 middleware after it.
 func NewKeyExistsMiddleware(manager Manager, parser *jwt.Parser) func(http.Handler) http.Handler {
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -84,7 +85,8 @@ func NewKeyExistsMiddleware(manager Manager, parser *jwt.Parser) func(http.Handl
 
 			//accessToken := token.Raw
 			//accessToken := parts[1]
-			keyExists := manager.IsKeyAuthorized(r.Context(), accessToken)
+			ctx := withTokenClaims(r.Context(), parser, accessToken)
+			keyExists := manager.IsKeyAuthorized(ctx, accessToken)
 			statsClient.TrackOperation(tokensSection, bucket.MetricOperation{"key-exists", "authorized"}, nil, keyExists)
 			if keyExists {
 				stats.Record(r.Context(), obs.MOAuth2Authorized.M(1))
@@ -102,7 +104,7 @@ func NewKeyExistsMiddleware(manager Manager, parser *jwt.Parser) func(http.Handl
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), AuthHeaderValue, accessToken)
+			ctx = context.WithValue(ctx, AuthHeaderValue, accessToken)
 			handler.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
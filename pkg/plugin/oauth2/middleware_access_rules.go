@@ -2,72 +2,75 @@ package oauth2
 
 import (
 	"net/http"
-	"strings"
+	"strconv"
 
-	"github.com/hellofresh/janus/pkg/jwt"
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/metrics"
+	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/hellofresh/stats-go/bucket"
 	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 )
 
-// NewRevokeRulesMiddleware creates a new revoke rules middleware
-func NewRevokeRulesMiddleware(parser *jwt.Parser, accessRules []*AccessRule) func(http.Handler) http.Handler {
+// ErrAccessRuleDenied is used when the access rules deny the request
+var ErrAccessRuleDenied = errors.New(http.StatusUnauthorized, "access denied")
+
+// NewRevokeRulesMiddleware creates a new middleware rejecting the requests the access rules deny. Requests without
+// verified claims on their context are decided without claims.
+func NewRevokeRulesMiddleware(accessRules *AccessRules) func(http.Handler) http.Handler {
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			log.WithField("rules", len(accessRules)).Debug("Starting revoke rules middleware")
-
-			// If no rules are set then lets not parse the token to avoid performance issues
-			/*
-				if len(accessRules) < 1 {
-					handler.ServeHTTP(w, r)
-					return
-				}
-			*/
-
-			token, err := parser.ParseFromRequest(r)
-			if err != nil {
-				log.WithError(err).Debug("Could not parse the JWT")
+			if accessRules.Len() == 0 {
 				handler.ServeHTTP(w, r)
 				return
 			}
 
-			if claims, ok := parser.GetMapClaims(token); ok && token.Valid {
-				for k, v := range claims {
-					val, ok := v.(string)
-					if !ok {
-						continue
-					}
-					if strings.ToLower(k) == "sub" {
-						r.Header.Set("subject", val)
-					} else if strings.ToLower(k) == "aud" {
-						r.Header.Set("audience", val)
-					} else if strings.ToLower(k) == "iss" {
-						r.Header.Set("issuer", val)
-					} else {
-						r.Header.Set(k, v.(string))
-					}
-				}
-
-				if len(accessRules) < 1 {
-					handler.ServeHTTP(w, r)
-					return
-				}
+			claims, _ := claimsFromContext(r.Context())
+			decision := accessRules.Decide(r, claims)
+			reportAccessDecision(r, decision)
 
-				for _, rule := range accessRules {
-					allowed, err := rule.IsAllowed(claims)
-					if err != nil {
-						log.WithError(err).Debug("Rule is not allowed")
-						continue
-					}
-
-					if allowed {
-						handler.ServeHTTP(w, r)
-					} else {
-						w.WriteHeader(http.StatusUnauthorized)
-						return
-					}
-				}
+			if !decision.Allowed {
+				errors.Handler(w, ErrAccessRuleDenied)
+				return
 			}
 
 			handler.ServeHTTP(w, r)
 		})
 	}
 }
+
+func reportAccessDecision(r *http.Request, decision AccessDecision) {
+	for i, err := range decision.Errors {
+		logger := log.WithError(err).WithFields(log.Fields{"path": r.RequestURI, "rule": i})
+		if i == decision.Rule {
+			logger.Warn("Could not evaluate the access rule, the request is denied")
+		} else {
+			logger.Debug("Could not evaluate the access rule, the rule is skipped")
+		}
+		recordAccessDecision(r, accessError, strconv.Itoa(i))
+	}
+
+	result, rule := accessDeny, "default"
+	if decision.Allowed {
+		result = accessAllow
+	}
+	if decision.Rule >= 0 {
+		rule = strconv.Itoa(decision.Rule)
+	}
+
+	log.WithFields(log.Fields{
+		"path":   r.RequestURI,
+		"origin": r.RemoteAddr,
+		"result": result,
+		"rule":   rule,
+	}).Debug("Access rules decision")
+
+	recordAccessDecision(r, result, rule)
+}
+
+func recordAccessDecision(r *http.Request, result, rule string) {
+	metrics.WithContext(r.Context()).TrackMetric(tokensSection, bucket.MetricOperation{"access-rules", result, rule})
+	ctx, _ := tag.New(r.Context(), tag.Insert(obs.KeyResult, result), tag.Insert(obs.KeyAccessRule, rule))
+	stats.Record(ctx, obs.MOAuth2AccessRuleDecisions.M(1))
+}
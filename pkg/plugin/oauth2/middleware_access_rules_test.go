@@ -3,6 +3,7 @@ package oauth2
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -24,7 +25,7 @@ func TestBlockJWTByCountry(t *testing.T) {
 
 	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: secret}))
 
-	mw := NewRevokeRulesMiddleware(parser, revokeRules)
+	mw := withRequestClaims(parser, NewRevokeRulesMiddleware(compileAccessRules(t, revokeRules, "")))
 	token, err := generateToken(signingAlg, secret)
 	require.NoError(t, err)
 
@@ -50,7 +51,7 @@ func TestBlockJWTByUsername(t *testing.T) {
 
 	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: secret}))
 
-	mw := NewRevokeRulesMiddleware(parser, revokeRules)
+	mw := withRequestClaims(parser, NewRevokeRulesMiddleware(compileAccessRules(t, revokeRules, "")))
 	token, err := generateToken(signingAlg, secret)
 	require.NoError(t, err)
 
@@ -76,7 +77,7 @@ func TestBlockJWTByIssueDate(t *testing.T) {
 
 	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: secret}))
 
-	mw := NewRevokeRulesMiddleware(parser, revokeRules)
+	mw := withRequestClaims(parser, NewRevokeRulesMiddleware(compileAccessRules(t, revokeRules, "")))
 	token, err := generateToken(signingAlg, secret)
 	require.NoError(t, err)
 
@@ -102,7 +103,7 @@ func TestBlockJWTByCountryAndIssueDate(t *testing.T) {
 
 	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: secret}))
 
-	mw := NewRevokeRulesMiddleware(parser, revokeRules)
+	mw := withRequestClaims(parser, NewRevokeRulesMiddleware(compileAccessRules(t, revokeRules, "")))
 	token, err := generateToken(signingAlg, secret)
 	require.NoError(t, err)
 
@@ -136,7 +137,7 @@ func TestEmptyAccessRules(t *testing.T) {
 
 	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: secret}))
 
-	mw := NewRevokeRulesMiddleware(parser, revokeRules)
+	mw := withRequestClaims(parser, NewRevokeRulesMiddleware(compileAccessRules(t, revokeRules, "")))
 
 	w, err := test.Record(
 		"GET",
@@ -155,7 +156,7 @@ func TestWrongJWT(t *testing.T) {
 
 	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: "wrong secret"}))
 
-	mw := NewRevokeRulesMiddleware(parser, revokeRules)
+	mw := withRequestClaims(parser, NewRevokeRulesMiddleware(compileAccessRules(t, revokeRules, "")))
 	token, err := generateToken(signingAlg, "secret")
 	require.NoError(t, err)
 
@@ -169,7 +170,7 @@ func TestWrongJWT(t *testing.T) {
 		mw(http.HandlerFunc(test.Ping)),
 	)
 	assert.NoError(t, err)
-	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "the deny rule can not be evaluated without claims and fails closed")
 }
 
 func TestWrongRule(t *testing.T) {
@@ -181,7 +182,7 @@ func TestWrongRule(t *testing.T) {
 
 	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: secret}))
 
-	mw := NewRevokeRulesMiddleware(parser, revokeRules)
+	mw := withRequestClaims(parser, NewRevokeRulesMiddleware(compileAccessRules(t, revokeRules, "")))
 	token, err := generateToken(signingAlg, secret)
 	require.NoError(t, err)
 
@@ -197,3 +198,110 @@ func TestWrongRule(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func compileAccessRules(t *testing.T, rules []*AccessRule, defaultAction string) *AccessRules {
+	accessRules, err := NewAccessRules(rules, defaultAction)
+	require.NoError(t, err)
+
+	return accessRules
+}
+
+func TestAccessRulesDecide(t *testing.T) {
+	rules := []*AccessRule{
+		{Predicate: "ip == '10.0.0.1'", Action: "deny"},
+		{Predicate: "cidr(ip, '10.0.0.0/8') && method == 'GET'", Action: "allow"},
+		{Predicate: "path =~ '^/admin' && !('admin' IN [realm.roles])", Action: "deny"},
+		{Predicate: "[header.X-Tenant] == 'acme'", Action: "allow"},
+		{Predicate: "country == 'de'", Action: "allow"},
+	}
+
+	tests := []struct {
+		scenario      string
+		defaultAction string
+		method        string
+		path          string
+		remoteAddr    string
+		tenant        string
+		claims        map[string]interface{}
+		allowed       bool
+		rule          int
+	}{
+		{scenario: "first matching rule wins", method: "GET", path: "/", remoteAddr: "10.0.0.1:1234", claims: map[string]interface{}{"country": "de"}, allowed: false, rule: 0},
+		{scenario: "client IP in a CIDR block", method: "GET", path: "/admin", remoteAddr: "10.1.2.3:1234", allowed: true, rule: 1},
+		{scenario: "client IP out of the CIDR block", method: "GET", path: "/", remoteAddr: "192.168.0.1:1234", allowed: true, rule: -1},
+		{scenario: "nested claim", method: "POST", path: "/admin/users", remoteAddr: "192.168.0.1:1234", claims: map[string]interface{}{"realm": map[string]interface{}{"roles": []interface{}{"customer"}}}, allowed: false, rule: 2},
+		{scenario: "nested claim of an admin", method: "POST", path: "/admin/users", remoteAddr: "192.168.0.1:1234", claims: map[string]interface{}{"realm": map[string]interface{}{"roles": []interface{}{"admin"}}, "country": "de"}, allowed: true, rule: 4},
+		{scenario: "header", defaultAction: "deny", method: "POST", path: "/", remoteAddr: "192.168.0.1:1234", tenant: "acme", allowed: true, rule: 3},
+		{scenario: "claim", defaultAction: "deny", method: "POST", path: "/", remoteAddr: "192.168.0.1:1234", claims: map[string]interface{}{"country": "de"}, allowed: true, rule: 4},
+		{scenario: "claims can not override the request", defaultAction: "deny", method: "POST", path: "/", remoteAddr: "192.168.0.1:1234", claims: map[string]interface{}{"ip": "10.0.0.2"}, allowed: false, rule: -1},
+		{scenario: "default action", defaultAction: "deny", method: "POST", path: "/", remoteAddr: "192.168.0.1:1234", allowed: false, rule: -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, test.path, nil)
+			r.RemoteAddr = test.remoteAddr
+			if test.tenant != "" {
+				r.Header.Set("X-Tenant", test.tenant)
+			}
+
+			decision := compileAccessRules(t, rules, test.defaultAction).Decide(r, test.claims)
+			assert.Equal(t, test.allowed, decision.Allowed)
+			assert.Equal(t, test.rule, decision.Rule)
+		})
+	}
+}
+
+func TestAccessRulesDecideFailsClosed(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	deny := compileAccessRules(t, []*AccessRule{{Predicate: "country == 'de'", Action: "deny"}}, "allow")
+	decision := deny.Decide(r, map[string]interface{}{"username": "test@hellofresh.com"})
+	assert.False(t, decision.Allowed, "a deny rule that can not be evaluated matches")
+	assert.Equal(t, 0, decision.Rule)
+	assert.Error(t, decision.Errors[0])
+
+	decision = deny.Decide(r, map[string]interface{}{"country": "us"})
+	assert.True(t, decision.Allowed)
+	assert.Empty(t, decision.Errors)
+
+	allow := compileAccessRules(t, []*AccessRule{{Predicate: "country == 'de'", Action: "allow"}}, "deny")
+	decision = allow.Decide(r, nil)
+	assert.False(t, decision.Allowed, "an allow rule that can not be evaluated does not match")
+	assert.Equal(t, -1, decision.Rule)
+	assert.Error(t, decision.Errors[0])
+}
+
+func TestRevokeRulesMiddlewareServesOnce(t *testing.T) {
+	secret := "secret"
+
+	revokeRules := []*AccessRule{
+		{Predicate: "country == 'de'", Action: "allow"},
+		{Predicate: "username == 'test@hellofresh.com'", Action: "allow"},
+	}
+
+	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: secret}))
+	token, err := generateToken(signingAlg, secret)
+	require.NoError(t, err)
+
+	calls := 0
+	mw := withRequestClaims(parser, NewRevokeRulesMiddleware(compileAccessRules(t, revokeRules, "deny")))
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.Equal(t, 3, calls, "the rules are evaluated on every request and the handler is served once per request")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "requests without token are decided by the default action")
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,18 @@
+package oauth2
+
+import "net/http"
+
+// NewClaimHeadersMiddleware creates a new middleware sending the mapped claims of the JWT upstream as headers. The
+// incoming headers named with the prefix are always removed, even when the request has no valid token.
+func NewClaimHeadersMiddleware(claimHeaders *ClaimHeaders) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claimHeaders.Strip(r.Header)
+			if claims, ok := claimsFromContext(r.Context()); ok {
+				claimHeaders.Set(r.Header, claims)
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
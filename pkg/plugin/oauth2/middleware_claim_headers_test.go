@@ -0,0 +1,131 @@
+package oauth2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	basejwt "github.com/dgrijalva/jwt-go"
+	"github.com/hellofresh/janus/pkg/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimHeadersMiddleware(t *testing.T) {
+	claimHeaders := &ClaimHeaders{
+		Claims: []*ClaimHeader{
+			{Claim: "sub", Header: "Subject"},
+			{Claim: "exp", Header: "Expires"},
+			{Claim: "realm.roles", Header: "Roles"},
+			{Claim: "realm.roles", Header: "Roles-Piped", Encoding: "join", Separator: "|"},
+			{Claim: "realm", Header: "Realm"},
+			{Claim: "address", Header: "Address", Encoding: "json"},
+			{Claim: "name", Header: "Name"},
+			{Claim: "missing", Header: "Missing"},
+		},
+	}
+	require.NoError(t, claimHeaders.Compile())
+
+	token := basejwt.MapClaims{
+		"sub":     "user-42",
+		"realm":   map[string]interface{}{"roles": []interface{}{"customer", "admin"}},
+		"address": "Berlin",
+		"name":    "injected\r\nHost: evil.example.com",
+		"Host":    "evil.example.com",
+	}
+
+	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: "secret"}))
+	mw := withRequestClaims(parser, NewClaimHeadersMiddleware(claimHeaders))
+
+	var upstream http.Header
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstream = r.Header
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+policyToken(t, token))
+	req.Header.Set("X-Jwt-Claim-Subject", "admin")
+	req.Header.Set("x-jwt-claim-missing", "spoofed")
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "user-42", upstream.Get("X-Jwt-Claim-Subject"))
+	assert.NotEmpty(t, upstream.Get("X-Jwt-Claim-Expires"))
+	assert.Equal(t, "customer,admin", upstream.Get("X-Jwt-Claim-Roles"))
+	assert.Equal(t, "customer|admin", upstream.Get("X-Jwt-Claim-Roles-Piped"))
+	assert.Equal(t, `{"roles":["customer","admin"]}`, upstream.Get("X-Jwt-Claim-Realm"))
+	assert.Equal(t, `"Berlin"`, upstream.Get("X-Jwt-Claim-Address"))
+	assert.Empty(t, upstream.Get("X-Jwt-Claim-Name"), "values with control characters are not sent")
+	assert.Empty(t, upstream.Get("X-Jwt-Claim-Missing"), "the incoming headers of the namespace are stripped")
+	assert.Equal(t, "10.0.0.1", upstream.Get("X-Forwarded-For"), "the claims do not overwrite other headers")
+}
+
+func TestClaimHeadersMiddlewareStripsWithoutToken(t *testing.T) {
+	claimHeaders := &ClaimHeaders{Prefix: "X-User-", Claims: []*ClaimHeader{{Claim: "sub", Header: "Id"}}}
+	require.NoError(t, claimHeaders.Compile())
+
+	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: "secret"}))
+
+	var upstream http.Header
+	handler := withRequestClaims(parser, NewClaimHeadersMiddleware(claimHeaders))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstream = r.Header
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-User-Id", "admin")
+	req.Header.Set("X-User-Role", "admin")
+	req.Header.Set("X-Request-Id", "42")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Empty(t, upstream.Get("X-User-Id"))
+	assert.Empty(t, upstream.Get("X-User-Role"))
+	assert.Equal(t, "42", upstream.Get("X-Request-Id"))
+}
+
+func TestClaimHeadersMiddlewareStripsWithoutMapping(t *testing.T) {
+	claimHeaders := &ClaimHeaders{}
+	require.NoError(t, claimHeaders.Compile())
+
+	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: "secret"}))
+
+	var upstream http.Header
+	handler := withRequestClaims(parser, NewClaimHeadersMiddleware(claimHeaders))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstream = r.Header
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+policyToken(t, basejwt.MapClaims{"sub": "user-42"}))
+	req.Header.Set("X-Jwt-Claim-Subject", "admin")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Empty(t, upstream.Get("X-Jwt-Claim-Subject"))
+	assert.Empty(t, upstream.Get("Subject"), "the claims are not sent without a mapping")
+}
+
+func TestClaimHeadersCompile(t *testing.T) {
+	tests := []struct {
+		scenario     string
+		claimHeaders *ClaimHeaders
+		valid        bool
+	}{
+		{scenario: "nil mapping", claimHeaders: nil, valid: true},
+		{scenario: "default prefix", claimHeaders: &ClaimHeaders{Claims: []*ClaimHeader{{Claim: "sub", Header: "Subject"}}}, valid: true},
+		{scenario: "invalid prefix", claimHeaders: &ClaimHeaders{Prefix: "X Jwt", Claims: []*ClaimHeader{{Claim: "sub", Header: "Subject"}}}, valid: false},
+		{scenario: "invalid header name", claimHeaders: &ClaimHeaders{Claims: []*ClaimHeader{{Claim: "sub", Header: "Sub\r\nHost"}}}, valid: false},
+		{scenario: "reserved header", claimHeaders: &ClaimHeaders{Prefix: "X-", Claims: []*ClaimHeader{{Claim: "sub", Header: "Forwarded-For"}}}, valid: false},
+		{scenario: "header mapped twice", claimHeaders: &ClaimHeaders{Claims: []*ClaimHeader{{Claim: "sub", Header: "Id"}, {Claim: "uid", Header: "id"}}}, valid: false},
+		{scenario: "unknown encoding", claimHeaders: &ClaimHeaders{Claims: []*ClaimHeader{{Claim: "sub", Header: "Id", Encoding: "xml"}}}, valid: false},
+		{scenario: "mapping without claim", claimHeaders: &ClaimHeaders{Claims: []*ClaimHeader{{Header: "Id"}}}, valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			err := test.claimHeaders.Compile()
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
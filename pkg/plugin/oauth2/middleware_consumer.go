@@ -0,0 +1,24 @@
+package oauth2
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hellofresh/janus/pkg/consumer"
+)
+
+// NewConsumerMiddleware creates a new middleware setting the consumer named by the given claim of the JWT on
+// the request context. Opaque tokens and tokens without the claim leave the request without consumer.
+func NewConsumerMiddleware(claim string) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := claimsFromContext(r.Context())
+			if !ok || claims[claim] == nil {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			handler.ServeHTTP(w, consumer.Resolve(r, fmt.Sprint(claims[claim])))
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package oauth2
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/metrics"
+	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/hellofresh/stats-go/bucket"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// NewPolicyMiddleware creates a new middleware rejecting the requests whose token claims do not comply with the
+// policy. The policy must be compiled. Requests without verified claims on their context are rejected.
+func NewPolicyMiddleware(policy *Policy) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := log.WithFields(log.Fields{
+				"path":   r.RequestURI,
+				"origin": r.RemoteAddr,
+			})
+
+			reason, err := validatePolicy(policy, r)
+			if err == nil {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			logger.WithError(err).WithField("reason", reason).Debug("The token does not comply with the policy")
+			metrics.WithContext(r.Context()).TrackMetric(tokensSection, bucket.MetricOperation{"jwt-policy", "violation", reason})
+			ctx, _ := tag.New(r.Context(), tag.Insert(obs.KeyJWTValidationErrorType, reason))
+			stats.Record(ctx, obs.MJWTPolicyViolations.M(1))
+
+			if err == ErrInsufficientScope {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(policy.requiredScopes(r), " ")))
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			}
+			errors.Handler(w, err)
+		})
+	}
+}
+
+func validatePolicy(policy *Policy, r *http.Request) (string, error) {
+	claims, ok := claimsFromContext(r.Context())
+	if !ok {
+		return "unreadable", ErrTokenClaimsUnreadable
+	}
+
+	return policy.Validate(r, claims)
+}
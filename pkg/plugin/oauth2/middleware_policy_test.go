@@ -0,0 +1,132 @@
+package oauth2
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	basejwt "github.com/dgrijalva/jwt-go"
+	"github.com/hellofresh/janus/pkg/jwt"
+	"github.com/hellofresh/janus/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func policyToken(t *testing.T, claims basejwt.MapClaims) string {
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	token, err := basejwt.NewWithClaims(basejwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestPolicyMiddleware(t *testing.T) {
+	policy := &Policy{
+		Issuers:   []string{"https://idp.example.com"},
+		Audiences: []string{"orders"},
+		Claims: []*ClaimRequirement{
+			{Claim: "email_verified", Equals: "true"},
+			{Claim: "realm.roles", OneOf: []string{"customer", "admin"}},
+			{Claim: "sub", Pattern: "^user-[0-9]+$"},
+		},
+		Scopes: []*ScopeRequirement{
+			{Methods: []string{"POST", "PUT"}, Path: "/orders*", Scopes: []string{"orders:write"}},
+			{Path: "/admin", Scopes: []string{"admin"}},
+		},
+	}
+	require.NoError(t, policy.Compile())
+
+	valid := func() basejwt.MapClaims {
+		return basejwt.MapClaims{
+			"iss":            "https://idp.example.com",
+			"aud":            []interface{}{"billing", "orders"},
+			"email_verified": true,
+			"realm":          map[string]interface{}{"roles": []interface{}{"customer"}},
+			"sub":            "user-42",
+			"scope":          "orders:read orders:write",
+		}
+	}
+	with := func(key string, value interface{}) basejwt.MapClaims {
+		claims := valid()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	scp := with("scope", nil)
+	scp["scp"] = []interface{}{"orders:write"}
+
+	tests := []struct {
+		scenario string
+		method   string
+		path     string
+		token    string
+		code     int
+	}{
+		{scenario: "compliant token", method: "POST", path: "/orders/1", token: policyToken(t, valid()), code: http.StatusOK},
+		{scenario: "path without scope requirement", method: "GET", path: "/orders", token: policyToken(t, with("scope", nil)), code: http.StatusOK},
+		{scenario: "scopes as a list", method: "POST", path: "/orders", token: policyToken(t, with("scope", []interface{}{"orders:write"})), code: http.StatusOK},
+		{scenario: "scopes in the scp claim", method: "PUT", path: "/orders", token: policyToken(t, scp), code: http.StatusOK},
+		{scenario: "single audience", method: "GET", path: "/", token: policyToken(t, with("aud", "orders")), code: http.StatusOK},
+		{scenario: "issuer not allowed", method: "GET", path: "/", token: policyToken(t, with("iss", "https://evil.example.com")), code: http.StatusUnauthorized},
+		{scenario: "missing issuer", method: "GET", path: "/", token: policyToken(t, with("iss", nil)), code: http.StatusUnauthorized},
+		{scenario: "audience not allowed", method: "GET", path: "/", token: policyToken(t, with("aud", []interface{}{"billing"})), code: http.StatusUnauthorized},
+		{scenario: "required claim missing", method: "GET", path: "/", token: policyToken(t, with("email_verified", nil)), code: http.StatusUnauthorized},
+		{scenario: "required claim not equal", method: "GET", path: "/", token: policyToken(t, with("email_verified", false)), code: http.StatusUnauthorized},
+		{scenario: "nested claim not one of", method: "GET", path: "/", token: policyToken(t, with("realm", map[string]interface{}{"roles": []interface{}{"guest"}})), code: http.StatusUnauthorized},
+		{scenario: "claim not matching the pattern", method: "GET", path: "/", token: policyToken(t, with("sub", "service-1")), code: http.StatusUnauthorized},
+		{scenario: "insufficient scope", method: "POST", path: "/orders", token: policyToken(t, with("scope", "orders:read")), code: http.StatusForbidden},
+		{scenario: "insufficient scope with a trailing slash", method: "POST", path: "/orders/", token: policyToken(t, with("scope", "orders:read")), code: http.StatusForbidden},
+		{scenario: "insufficient scope with a double slash", method: "POST", path: "http://janus.local//orders", token: policyToken(t, with("scope", "orders:read")), code: http.StatusForbidden},
+		{scenario: "insufficient scope with dot segments", method: "GET", path: "/orders/../admin", token: policyToken(t, valid()), code: http.StatusForbidden},
+		{scenario: "prefix matching on segment boundaries", method: "POST", path: "/orders-admin", token: policyToken(t, with("scope", "orders:read")), code: http.StatusOK},
+		{scenario: "insufficient scope of any method", method: "GET", path: "/admin", token: policyToken(t, valid()), code: http.StatusForbidden},
+		{scenario: "unreadable token", method: "GET", path: "/", token: "invalid.token", code: http.StatusUnauthorized},
+	}
+
+	parser := jwt.NewParser(jwt.NewParserConfig(0, jwt.SigningMethod{Alg: signingAlg, Key: "secret"}))
+	mw := withRequestClaims(parser, NewPolicyMiddleware(policy))
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			w, err := test.Record(tt.method, tt.path, map[string]string{"Authorization": "Bearer " + tt.token}, mw(http.HandlerFunc(test.Ping)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.code, w.Code)
+
+			switch tt.code {
+			case http.StatusForbidden:
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="insufficient_scope"`)
+			case http.StatusUnauthorized:
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+			}
+		})
+	}
+}
+
+func TestPolicyCompile(t *testing.T) {
+	tests := []struct {
+		scenario string
+		policy   *Policy
+		valid    bool
+	}{
+		{scenario: "nil policy", policy: nil, valid: true},
+		{scenario: "valid pattern", policy: &Policy{Claims: []*ClaimRequirement{{Claim: "sub", Pattern: "^user-"}}}, valid: true},
+		{scenario: "invalid pattern", policy: &Policy{Claims: []*ClaimRequirement{{Claim: "sub", Pattern: "("}}}, valid: false},
+		{scenario: "claim requirement without claim", policy: &Policy{Claims: []*ClaimRequirement{{Equals: "x"}}}, valid: false},
+		{scenario: "scope requirement without scopes", policy: &Policy{Scopes: []*ScopeRequirement{{Path: "/"}}}, valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			err := test.policy.Compile()
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
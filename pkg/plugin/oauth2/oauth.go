@@ -2,12 +2,18 @@ package oauth2
 
 import (
 	"sync"
+	"time"
 
-	"github.com/Knetic/govaluate"
 	"github.com/hellofresh/janus/pkg/jwt"
 	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	jwksMu    sync.Mutex
+	jwksCache = make(map[JWKSSettings]*jwt.JWKS)
 )
 
 // AccessRequestType is the type for OAuth param `grant_type`
@@ -35,6 +41,7 @@ type OAuth struct {
 	RateLimit              rateLimitMeta          `bson:"rate_limit" json:"rate_limit"`
 	TokenStrategy          TokenStrategy          `bson:"token_strategy" json:"token_strategy" mapstructure:"token_strategy"`
 	AccessRules            []*AccessRule          `bson:"access_rules" json:"access_rules"`
+	AccessRulesDefault     string                 `bson:"access_rules_default" json:"access_rules_default"`
 }
 
 // Endpoints defines the oauth endpoints that wil be proxied
@@ -84,6 +91,15 @@ type TokenStrategy struct {
 	// into object/dictionary and make leeway one of the settings.
 	Leeway      int64  `bson:"leeway" json:"leeway"`
 	TokenLookup string `bson:"lookup" json:"lookup"`
+	// JWKS configures the key set of the identity provider the "jwt" strategy verifies the tokens with
+	JWKS *JWKSSettings `bson:"jwks,omitempty" json:"jwks,omitempty"`
+}
+
+// JWKSSettings represents the settings of the JWKS URL the token signing keys are fetched from
+type JWKSSettings struct {
+	URL                string         `bson:"url" json:"url"`
+	RefreshInterval    proxy.Duration `bson:"refresh_interval" json:"refresh_interval"`
+	MinRefreshInterval proxy.Duration `bson:"min_refresh_interval" json:"min_refresh_interval"`
 }
 
 // NewOAuth creates a new instance of OAuth
@@ -109,6 +125,41 @@ func (t TokenStrategy) GetIntrospectionSettings() (*IntrospectionSettings, error
 	return settings, nil
 }
 
+// GetJWKS returns the key set of the JWKS settings, nil when the strategy has none. The key sets are shared by
+// the APIs using the same JWKS URL and settings, so the keys are fetched once.
+func (t TokenStrategy) GetJWKS() *jwt.JWKS {
+	if t.JWKS == nil || t.JWKS.URL == "" {
+		return nil
+	}
+
+	jwksMu.Lock()
+	defer jwksMu.Unlock()
+
+	settings := *t.JWKS
+	if cached, ok := jwksCache[settings]; ok {
+		return cached
+	}
+
+	var opts []jwt.JWKSOption
+	if settings.RefreshInterval > 0 {
+		opts = append(opts, jwt.WithRefreshInterval(time.Duration(settings.RefreshInterval)))
+	}
+	if settings.MinRefreshInterval > 0 {
+		opts = append(opts, jwt.WithMinRefreshInterval(time.Duration(settings.MinRefreshInterval)))
+	}
+
+	jwks := jwt.NewJWKS(settings.URL, opts...)
+	jwksCache[settings] = jwks
+
+	go func() {
+		if err := jwks.Refresh(); err != nil {
+			log.WithError(err).WithField("url", settings.URL).Warn("Could not fetch the JWKS keys, they are fetched again on the first token")
+		}
+	}()
+
+	return jwks
+}
+
 // GetJWTSigningMethods parses and returns chain of JWT signing methods for token signature validation.
 // Supports fallback to legacy format with {"secret": "key"} as single signing method with HS256 alg.
 func (t TokenStrategy) GetJWTSigningMethods() ([]jwt.SigningMethod, error) {
@@ -124,6 +175,9 @@ func (t TokenStrategy) GetJWTSigningMethods() ([]jwt.SigningMethod, error) {
 			return methods, err
 		}
 		if legacy.Secret == "" {
+			if t.GetJWKS() != nil {
+				return nil, nil
+			}
 			return nil, ErrJWTSecretMissing
 		}
 
@@ -131,47 +185,3 @@ func (t TokenStrategy) GetJWTSigningMethods() ([]jwt.SigningMethod, error) {
 	}
 	return methods, err
 }
-
-// AccessRule represents a rule that will be applied to a JWT that could be revoked
-type AccessRule struct {
-	mu        sync.Mutex
-	Predicate string `bson:"predicate" json:"predicate"`
-	Action    string `bson:"action" json:"action"`
-	parsed    bool
-}
-
-// IsAllowed checks if the rule is allowed to
-func (r *AccessRule) IsAllowed(claims map[string]interface{}) (bool, error) {
-	var err error
-
-	if !r.parsed {
-		matched, err := r.parse(claims)
-		if err != nil {
-			return false, err
-		}
-
-		if !matched {
-			return true, nil
-		}
-	}
-
-	return r.Action == "allow", err
-}
-
-func (r *AccessRule) parse(claims map[string]interface{}) (bool, error) {
-	expression, err := govaluate.NewEvaluableExpression(r.Predicate)
-	if err != nil {
-		return false, errors.New("Could not create an expression with this predicate")
-	}
-
-	result, err := expression.Evaluate(claims)
-	if err != nil {
-		return false, errors.New("Cannot evaluate the expression")
-	}
-
-	r.mu.Lock()
-	r.parsed = true
-	r.mu.Unlock()
-
-	return result.(bool), nil
-}
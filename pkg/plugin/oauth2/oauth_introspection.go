@@ -37,7 +37,7 @@ func NewIntrospectionManager(def *proxy.Definition, settings *IntrospectionSetti
 
 // IsKeyAuthorized checks if the access token is valid
 func (o *IntrospectionManager) IsKeyAuthorized(ctx context.Context, accessToken string) bool {
-	resp, err := o.doStatusRequest(accessToken)
+	resp, err := o.doStatusRequest(ctx, accessToken)
 	defer resp.Body.Close()
 
 	if err != nil {
@@ -60,8 +60,8 @@ func (o *IntrospectionManager) IsKeyAuthorized(ctx context.Context, accessToken
 	return oauthResp.Active
 }
 
-func (o *IntrospectionManager) doStatusRequest(accessToken string) (*http.Response, error) {
-	upstream, err := o.balancer.Elect(o.urls.ToBalancerTargets())
+func (o *IntrospectionManager) doStatusRequest(ctx context.Context, accessToken string) (*http.Response, error) {
+	upstream, err := o.balancer.Elect(ctx, o.urls.ToBalancerTargets())
 	if err != nil {
 		return nil, errors.Wrap(err, "Could not elect one upstream")
 	}
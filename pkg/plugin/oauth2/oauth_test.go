@@ -2,36 +2,41 @@ package oauth2
 
 import (
 	"testing"
+	"time"
 
 	"github.com/globalsign/mgo/bson"
+	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestAccessRulesWithWrongPredicate(t *testing.T) {
-	rules := AccessRule{
-		Action:    "wrong",
-		Predicate: "wrong.predicate",
-	}
-	_, err := rules.IsAllowed(make(map[string]interface{}))
+	rules := []*AccessRule{{
+		Action:    "deny",
+		Predicate: "wrong.predicate ==",
+	}}
+	_, err := NewAccessRules(rules, "")
 	require.Error(t, err)
 }
 
 func TestAccessRulesWithEmptyPredicate(t *testing.T) {
-	rules := AccessRule{
-		Action:    "wrong",
+	rules := []*AccessRule{{
+		Action:    "deny",
 		Predicate: "",
-	}
-	_, err := rules.IsAllowed(map[string]interface{}{"test": true})
+	}}
+	_, err := NewAccessRules(rules, "")
 	require.Error(t, err)
 }
 
-func TestAccessRulesWithPredicateThatDoesntMatch(t *testing.T) {
-	rules := AccessRule{
+func TestAccessRulesWithWrongAction(t *testing.T) {
+	rules := []*AccessRule{{
 		Action:    "wrong",
-		Predicate: "test = false",
-	}
-	_, err := rules.IsAllowed(map[string]interface{}{"test": true})
+		Predicate: "test == false",
+	}}
+	_, err := NewAccessRules(rules, "")
+	require.Error(t, err)
+
+	_, err = NewAccessRules(nil, "wrong")
 	require.Error(t, err)
 }
 
@@ -47,6 +52,28 @@ func TestTokenStrategyWithEmptySecret(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestTokenStrategyWithJWKSAndNoSecret(t *testing.T) {
+	strategy := TokenStrategy{Settings: bson.M{}, JWKS: &JWKSSettings{URL: "http://localhost:1/.well-known/jwks.json"}}
+	methods, err := strategy.GetJWTSigningMethods()
+	require.NoError(t, err)
+	assert.Empty(t, methods)
+}
+
+func TestTokenStrategy_GetJWKS(t *testing.T) {
+	assert.Nil(t, TokenStrategy{}.GetJWKS())
+
+	settings := JWKSSettings{URL: "http://localhost:1/.well-known/jwks.json", MinRefreshInterval: proxy.Duration(time.Second)}
+	jwks := TokenStrategy{JWKS: &settings}.GetJWKS()
+	require.NotNil(t, jwks)
+	assert.Equal(t, settings.URL, jwks.URL())
+
+	same := settings
+	assert.True(t, jwks == TokenStrategy{JWKS: &same}.GetJWKS(), "the key sets are shared by the APIs")
+
+	other := JWKSSettings{URL: settings.URL}
+	assert.False(t, jwks == TokenStrategy{JWKS: &other}.GetJWKS())
+}
+
 func TestTokenStrategy_GetJWTSigningMethods_mongo(t *testing.T) {
 	settingsLegacy := TokenStrategy{Settings: bson.M{"secret": "foo-bar"}}
 	methodsLegacy, err := settingsLegacy.GetJWTSigningMethods()
@@ -0,0 +1,275 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hellofresh/janus/pkg/errors"
+)
+
+const defaultScopeClaim = "scope"
+
+// Policy reasons, reported as the error of the policy violation metrics
+const (
+	policyIssuer   = "issuer"
+	policyAudience = "audience"
+	policyClaim    = "claim"
+	policyScope    = "scope"
+)
+
+var (
+	// ErrTokenIssuerNotAllowed is used when the token is not issued by one of the allowed issuers
+	ErrTokenIssuerNotAllowed = errors.New(http.StatusUnauthorized, "token issuer not allowed")
+	// ErrTokenAudienceNotAllowed is used when the token is not meant for one of the allowed audiences
+	ErrTokenAudienceNotAllowed = errors.New(http.StatusUnauthorized, "token audience not allowed")
+	// ErrTokenClaimNotAllowed is used when a required claim of the token is missing or has a value not allowed
+	ErrTokenClaimNotAllowed = errors.New(http.StatusUnauthorized, "token claims not allowed")
+	// ErrTokenClaimsUnreadable is used when the claims of the token can not be read to enforce the policy
+	ErrTokenClaimsUnreadable = errors.New(http.StatusUnauthorized, "token claims can not be verified")
+	// ErrInsufficientScope is used when the token does not have the scopes the request requires
+	ErrInsufficientScope = errors.New(http.StatusForbidden, "insufficient scope")
+)
+
+// Policy is the validation policy of the JWT claims, checked on top of the signature and time based claims
+type Policy struct {
+	// Issuers are the allowed `iss` claims, any issuer is allowed when empty
+	Issuers []string `json:"issuers"`
+	// Audiences are the allowed audiences, the `aud` claim must contain one of them. Any audience is allowed when empty
+	Audiences []string `json:"audiences"`
+	// Claims are the claims the token must have
+	Claims []*ClaimRequirement `json:"claims"`
+	// ScopeClaim is the claim holding the token scopes, either a space separated string or a list. Both `scope` and
+	// `scp` are looked up when empty
+	ScopeClaim string `json:"scope_claim"`
+	// Scopes are the scopes required by the requests matching a method and path
+	Scopes []*ScopeRequirement `json:"scopes"`
+}
+
+// ClaimRequirement is a claim the token must have. The claim is a dot separated path for nested claims, its value
+// must match every matcher set. A list claim matches when one of its items does.
+type ClaimRequirement struct {
+	Claim   string   `json:"claim"`
+	Equals  string   `json:"equals"`
+	OneOf   []string `json:"one_of"`
+	Pattern string   `json:"pattern"`
+
+	pattern *regexp.Regexp
+}
+
+// ScopeRequirement are the scopes the token must have for the requests matching the methods and path. The path
+// matches the request path exactly, or all the paths it prefixes when it ends with `*`.
+type ScopeRequirement struct {
+	Methods []string `json:"methods"`
+	Path    string   `json:"path"`
+	Scopes  []string `json:"scopes"`
+}
+
+// IsEmpty checks if the policy has nothing to validate
+func (p *Policy) IsEmpty() bool {
+	return p == nil || len(p.Issuers) == 0 && len(p.Audiences) == 0 && len(p.Claims) == 0 && len(p.Scopes) == 0
+}
+
+// Compile checks the policy and compiles the claim patterns
+func (p *Policy) Compile() error {
+	if p == nil {
+		return nil
+	}
+
+	for _, c := range p.Claims {
+		if c.Claim == "" {
+			return fmt.Errorf("policy claim requirement without claim")
+		}
+
+		if c.Pattern != "" {
+			pattern, err := regexp.Compile(c.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern of the policy claim %s: %s", c.Claim, err)
+			}
+			c.pattern = pattern
+		}
+	}
+
+	for _, s := range p.Scopes {
+		if len(s.Scopes) == 0 {
+			return fmt.Errorf("policy scope requirement of %s without scopes", s.Path)
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the claims of a request token. It returns the reason and the error of the first violation.
+func (p *Policy) Validate(r *http.Request, claims map[string]interface{}) (string, error) {
+	if len(p.Issuers) > 0 && !anyIn(claimValues(claims["iss"]), p.Issuers) {
+		return policyIssuer, ErrTokenIssuerNotAllowed
+	}
+
+	if len(p.Audiences) > 0 && !anyIn(claimValues(claims["aud"]), p.Audiences) {
+		return policyAudience, ErrTokenAudienceNotAllowed
+	}
+
+	for _, c := range p.Claims {
+		if !c.matches(lookupClaim(claims, c.Claim)) {
+			return policyClaim, ErrTokenClaimNotAllowed
+		}
+	}
+
+	if required := p.requiredScopes(r); len(required) > 0 {
+		scopes := p.scopes(claims)
+		for _, scope := range required {
+			if !anyIn([]string{scope}, scopes) {
+				return policyScope, ErrInsufficientScope
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// requiredScopes returns the scopes of all the requirements matching the request
+func (p *Policy) requiredScopes(r *http.Request) []string {
+	var scopes []string
+	for _, s := range p.Scopes {
+		if s.matches(r) {
+			scopes = append(scopes, s.Scopes...)
+		}
+	}
+
+	return scopes
+}
+
+func (p *Policy) scopes(claims map[string]interface{}) []string {
+	var value interface{}
+	if p.ScopeClaim != "" {
+		value = lookupClaim(claims, p.ScopeClaim)
+	} else if value = claims[defaultScopeClaim]; value == nil {
+		value = claims["scp"]
+	}
+
+	if s, ok := value.(string); ok {
+		return strings.Fields(s)
+	}
+
+	return claimValues(value)
+}
+
+func (s *ScopeRequirement) matches(r *http.Request) bool {
+	if len(s.Methods) > 0 {
+		found := false
+		for _, method := range s.Methods {
+			if strings.EqualFold(method, r.Method) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if s.Path == "" {
+		return true
+	}
+
+	requestPath := path.Clean("/" + r.URL.Path)
+	if !strings.HasSuffix(s.Path, "*") {
+		return requestPath == path.Clean(s.Path)
+	}
+
+	// the prefix matches on segment boundaries, so /orders* does not match /orders-admin
+	prefix := path.Clean(strings.TrimSuffix(s.Path, "*"))
+	return prefix == "/" || requestPath == prefix || strings.HasPrefix(requestPath, prefix+"/")
+}
+
+func (c *ClaimRequirement) matches(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+
+	values := claimValues(value)
+	if c.Equals != "" && !anyIn(values, []string{c.Equals}) {
+		return false
+	}
+	if len(c.OneOf) > 0 && !anyIn(values, c.OneOf) {
+		return false
+	}
+	if c.pattern != nil {
+		for _, v := range values {
+			if c.pattern.MatchString(v) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// lookupClaim returns the claim at the dot separated path, nil when the claim is missing
+func lookupClaim(claims map[string]interface{}, path string) interface{} {
+	if value, ok := claims[path]; ok {
+		return value
+	}
+
+	var value interface{} = claims
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+
+	return value
+}
+
+// claimValues returns the string representations of a scalar claim or the scalar items of a list claim
+func claimValues(value interface{}) []string {
+	if list, ok := value.([]interface{}); ok {
+		values := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := scalarValue(item); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+
+	if s, ok := scalarValue(value); ok {
+		return []string{s}
+	}
+
+	return nil
+}
+
+func scalarValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+func anyIn(values, allowed []string) bool {
+	for _, v := range values {
+		for _, a := range allowed {
+			if v == a {
+				return true
+			}
+		}
+	}
+
+	return false
+}
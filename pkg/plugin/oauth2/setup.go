@@ -39,6 +39,13 @@ func init() {
 // Config represents the oauth configuration
 type Config struct {
 	ServerName string `json:"server_name"`
+	// ConsumerClaim is the JWT claim holding the id of the consumer, the consumer is not resolved when empty
+	ConsumerClaim string `json:"consumer_claim"`
+	// Policy is the validation policy of the token claims, the claims are not validated when empty
+	Policy *Policy `json:"policy"`
+	// ClaimHeaders maps the JWT claims to the headers sent upstream, no claim is sent when empty. The incoming
+	// headers with the prefix are removed in any case
+	ClaimHeaders *ClaimHeaders `json:"claim_headers"`
 }
 
 func onAdminAPIStartup(event interface{}) error {
@@ -120,6 +127,18 @@ func setupOAuth2(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
 		return err
 	}
 
+	if err := config.Policy.Compile(); err != nil {
+		return err
+	}
+
+	// the incoming claim headers are always stripped, so the upstreams can trust the prefix even when no claim is mapped
+	if config.ClaimHeaders == nil {
+		config.ClaimHeaders = &ClaimHeaders{}
+	}
+	if err := config.ClaimHeaders.Compile(); err != nil {
+		return err
+	}
+
 	oauthServer, err := repo.FindByName(config.ServerName)
 	if nil != err {
 		return err
@@ -131,15 +150,29 @@ func setupOAuth2(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
 		return err
 	}
 
+	accessRules, err := NewAccessRules(oauthServer.AccessRules, oauthServer.AccessRulesDefault)
+	if err != nil {
+		return errors.Wrap(err, "Could not compile the access rules of the oauth server")
+	}
+
 	signingMethods, err := oauthServer.TokenStrategy.GetJWTSigningMethods()
 	if err != nil {
 		return err
 	}
 
-	parser := jwt.NewParser(jwt.NewParserConfigWithLookup(oauthServer.TokenStrategy.TokenLookup, oauthServer.TokenStrategy.Leeway, signingMethods...))
+	parserConfig := jwt.NewParserConfigWithLookup(oauthServer.TokenStrategy.TokenLookup, oauthServer.TokenStrategy.Leeway, signingMethods...)
+	parserConfig.JWKS = oauthServer.TokenStrategy.GetJWKS()
+	parser := jwt.NewParser(parserConfig)
+	// the token is verified once by the key exists middleware, the next ones read its claims from the context
 	def.AddMiddleware(NewKeyExistsMiddleware(manager, parser))
-	//def.AddMiddleware(NewRevokeRulesMiddleware(jwt.NewParser(jwt.NewParserConfig(oauthServer.TokenStrategy.Leeway, signingMethods...)), oauthServer.AccessRules))
-	def.AddMiddleware(NewRevokeRulesMiddleware(parser, oauthServer.AccessRules))
+	if !config.Policy.IsEmpty() {
+		def.AddMiddleware(NewPolicyMiddleware(config.Policy))
+	}
+	def.AddMiddleware(NewRevokeRulesMiddleware(accessRules))
+	if config.ConsumerClaim != "" {
+		def.AddMiddleware(NewConsumerMiddleware(config.ConsumerClaim))
+	}
+	def.AddMiddleware(NewClaimHeadersMiddleware(config.ClaimHeaders))
 
 	return nil
 }
@@ -151,6 +184,14 @@ func validateConfig(rawConfig plugin.Config) (bool, error) {
 		return false, err
 	}
 
+	if err := config.Policy.Compile(); err != nil {
+		return false, err
+	}
+
+	if err := config.ClaimHeaders.Compile(); err != nil {
+		return false, err
+	}
+
 	return govalidator.ValidateStruct(config)
 }
 
@@ -18,3 +18,33 @@ func TestOAuth2Config(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "test", config.ServerName)
 }
+
+func TestOAuth2ConfigPolicy(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"server_name": "test",
+		"policy": map[string]interface{}{
+			"issuers":   []string{"https://idp.example.com"},
+			"audiences": []string{"orders"},
+			"claims": []map[string]interface{}{
+				{"claim": "sub", "pattern": "^user-"},
+			},
+			"scopes": []map[string]interface{}{
+				{"methods": []string{"POST"}, "path": "/orders", "scopes": []string{"orders:write"}},
+			},
+		},
+	}
+
+	var config Config
+	require.NoError(t, plugin.Decode(rawConfig, &config))
+	assert.Equal(t, []string{"https://idp.example.com"}, config.Policy.Issuers)
+	assert.Equal(t, "^user-", config.Policy.Claims[0].Pattern)
+	assert.Equal(t, []string{"orders:write"}, config.Policy.Scopes[0].Scopes)
+
+	valid, err := validateConfig(rawConfig)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	rawConfig["policy"] = map[string]interface{}{"claims": []map[string]interface{}{{"claim": "sub", "pattern": "("}}}
+	_, err = validateConfig(rawConfig)
+	assert.Error(t, err)
+}
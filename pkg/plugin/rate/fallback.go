@@ -0,0 +1,111 @@
+package rate
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/ulule/limiter"
+)
+
+const defaultRecheckInterval = time.Second
+
+// ErrStoreUnavailable is used when the limiter store can not be reached
+var ErrStoreUnavailable = errors.New(http.StatusServiceUnavailable, "rate limiter is unavailable")
+
+// FallbackStore counts the requests in a shared store and, while that store fails, in a local one. The local
+// store enforces the share of the limits of one instance, so the whole cluster keeps to the limits
+// approximately during the outage. Once the shared store fails it is only tried again after a recheck interval,
+// so the requests do not pay its timeouts.
+type FallbackStore struct {
+	sync.Mutex
+	shared    limiter.Store
+	local     limiter.Store
+	replicas  int64
+	recheck   time.Duration
+	downUntil time.Time
+	now       func() time.Time
+}
+
+// NewFallbackStore creates a new instance of FallbackStore. A nil local store makes the requests fail with
+// ErrStoreUnavailable while the shared store is down, replicas is the number of instances sharing the limits.
+func NewFallbackStore(shared limiter.Store, local limiter.Store, replicas int) *FallbackStore {
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	return &FallbackStore{
+		shared:   shared,
+		local:    local,
+		replicas: int64(replicas),
+		recheck:  defaultRecheckInterval,
+		now:      time.Now,
+	}
+}
+
+// Get counts a request for the key and returns the state of its limit
+func (s *FallbackStore) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return s.do(ctx, key, rate, limiter.Store.Get)
+}
+
+// Peek returns the state of the limit of the key without counting a request
+func (s *FallbackStore) Peek(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return s.do(ctx, key, rate, limiter.Store.Peek)
+}
+
+type storeFunc func(limiter.Store, context.Context, string, limiter.Rate) (limiter.Context, error)
+
+func (s *FallbackStore) do(ctx context.Context, key string, rate limiter.Rate, fn storeFunc) (limiter.Context, error) {
+	if !s.down() {
+		c, err := fn(s.shared, ctx, key, rate)
+		if err == nil {
+			return c, nil
+		}
+
+		s.markDown(err)
+	}
+
+	if s.local == nil {
+		return limiter.Context{}, ErrStoreUnavailable
+	}
+
+	return fn(s.local, ctx, key, s.share(rate))
+}
+
+func (s *FallbackStore) down() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.now().Before(s.downUntil)
+}
+
+func (s *FallbackStore) markDown(err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.now()
+	if now.Before(s.downUntil) {
+		return
+	}
+	s.downUntil = now.Add(s.recheck)
+
+	log.WithError(err).WithField("local_fallback", s.local != nil).Warn("Rate limiter store failed")
+}
+
+// share returns the part of the rate a single instance enforces
+func (s *FallbackStore) share(rate limiter.Rate) limiter.Rate {
+	limit := rate.Limit / s.replicas
+	if limit < 1 {
+		limit = 1
+	}
+
+	return limiter.Rate{
+		Formatted: strconv.FormatInt(limit, 10) + "/" + rate.Formatted,
+		Period:    rate.Period,
+		Limit:     limit,
+	}
+}
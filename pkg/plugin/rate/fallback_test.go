@@ -0,0 +1,101 @@
+package rate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulule/limiter"
+)
+
+func TestFallbackStore(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeScripter()
+	now := time.Unix(1500000000, 0)
+	local := NewTokenBucketStore()
+	local.now = func() time.Time { return now }
+	store := NewFallbackStore(NewGCRAStore(client, DefaultPrefix), local, 5)
+	store.now = func() time.Time { return now }
+
+	rate := limiter.Rate{Formatted: "10-M", Period: time.Minute, Limit: 10}
+	ctx := context.Background()
+
+	c, err := store.Get(ctx, "key", rate)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), c.Limit)
+	assert.Equal(t, int64(9), c.Remaining)
+
+	client.fail(errors.New("connection refused"))
+	for i := 0; i < 2; i++ {
+		c, err = store.Get(ctx, "key", rate)
+		require.NoError(t, err)
+		assert.False(t, c.Reached)
+		assert.Equal(t, int64(2), c.Limit, "the local fallback enforces the share of one of the replicas")
+	}
+
+	c, err = store.Get(ctx, "key", rate)
+	require.NoError(t, err)
+	assert.True(t, c.Reached)
+
+	client.fail(nil)
+	c, _ = store.Get(ctx, "key", rate)
+	assert.Equal(t, int64(2), c.Limit, "the shared store is not tried before the recheck interval")
+
+	now = now.Add(defaultRecheckInterval)
+	c, _ = store.Get(ctx, "key", rate)
+	assert.Equal(t, int64(10), c.Limit)
+	assert.Equal(t, int64(8), c.Remaining)
+}
+
+func TestFallbackStoreWithoutLocalFallback(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeScripter()
+	client.fail(errors.New("connection refused"))
+	store := NewFallbackStore(NewGCRAStore(client, DefaultPrefix), nil, 0)
+
+	_, err := store.Get(context.Background(), "key", limiter.Rate{Formatted: "10-M", Period: time.Minute, Limit: 10})
+	assert.Equal(t, ErrStoreUnavailable, err)
+}
+
+func TestRateLimitMiddlewareOnStoreError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scenario string
+		failOpen bool
+		expected int
+	}{
+		{
+			scenario: "when failing open the request is let through",
+			failOpen: true,
+			expected: http.StatusOK,
+		},
+		{
+			scenario: "when failing closed the request is rejected",
+			expected: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			client := newFakeScripter()
+			client.fail(errors.New("connection refused"))
+			store := NewFallbackStore(NewGCRAStore(client, DefaultPrefix), nil, 0)
+
+			rates, err := parseRates([]string{"10-M"})
+			require.NoError(t, err)
+			l := NewRateLimiter("example", store, nil, rates, nil, WithFailOpen(test.failOpen))
+			handler := NewRateLimitMiddleware(l, nil)(http.HandlerFunc(ping))
+
+			w := doRequest(handler, nil)
+			assert.Equal(t, test.expected, w.Code)
+			assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+		})
+	}
+}
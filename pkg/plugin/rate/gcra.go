@@ -0,0 +1,113 @@
+package rate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/ulule/limiter"
+)
+
+// gcraScript runs the generic cell rate algorithm atomically in redis. The theoretical arrival time (TAT) of the
+// next request is stored per key, a request is allowed when the TAT it pushes is at most one period ahead of now.
+// The redis clock is used, so the instances sharing the store do not need synchronised clocks.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local period = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local clock = redis.call("TIME")
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+local interval = period / limit
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+  tat = now
+end
+
+local newTat = tat + interval * cost
+if newTat - now > period then
+  local retry = math.ceil(tat + interval - period - now)
+  local remaining = math.floor((period - (tat - now)) / interval)
+  return {0, remaining, retry, now}
+end
+
+if cost > 0 then
+  redis.call("SET", KEYS[1], tostring(newTat), "PX", math.ceil(newTat - now))
+end
+
+return {1, math.floor((period - (newTat - now)) / interval), math.ceil(newTat - now), now}
+`)
+
+// scripter is the part of the redis client the GCRA store relies on
+type scripter interface {
+	Eval(script string, keys []string, args ...interface{}) *redis.Cmd
+	EvalSha(sha1 string, keys []string, args ...interface{}) *redis.Cmd
+	ScriptExists(hashes ...string) *redis.BoolSliceCmd
+	ScriptLoad(script string) *redis.StringCmd
+}
+
+// GCRAStore is a limiter.Store running the generic cell rate algorithm in redis. Unlike fixed windows it does
+// not let twice the limit through around the window boundaries and it behaves the same no matter how many
+// instances share the store.
+type GCRAStore struct {
+	client scripter
+	prefix string
+}
+
+// NewGCRAStore creates a new instance of GCRAStore
+func NewGCRAStore(client scripter, prefix string) *GCRAStore {
+	return &GCRAStore{client: client, prefix: prefix}
+}
+
+// Get counts a request for the key and returns the state of its limit
+func (s *GCRAStore) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return s.take(key, rate, 1)
+}
+
+// Peek returns the state of the limit of the key without counting a request
+func (s *GCRAStore) Peek(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return s.take(key, rate, 0)
+}
+
+func (s *GCRAStore) take(key string, rate limiter.Rate, cost int) (limiter.Context, error) {
+	if rate.Limit <= 0 {
+		return limiter.Context{}, fmt.Errorf("invalid rate limit %d", rate.Limit)
+	}
+
+	period := int64(rate.Period / time.Millisecond)
+	reply, err := gcraScript.Run(s.client, []string{s.prefix + ":" + key}, period, rate.Limit, cost).Result()
+	if err != nil {
+		return limiter.Context{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 4 {
+		return limiter.Context{}, fmt.Errorf("unexpected gcra reply %v", reply)
+	}
+
+	ints := make([]int64, len(values))
+	for i, v := range values {
+		if ints[i], ok = v.(int64); !ok {
+			return limiter.Context{}, fmt.Errorf("unexpected gcra reply %v", reply)
+		}
+	}
+	allowed, remaining, resetMs, nowMs := ints[0] == 1, ints[1], ints[2], ints[3]
+
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return limiter.Context{
+		Limit:     rate.Limit,
+		Remaining: remaining,
+		Reset:     ceilSeconds(nowMs + resetMs),
+		Reached:   !allowed,
+	}, nil
+}
+
+// ceilSeconds rounds a unix time in milliseconds up to seconds
+func ceilSeconds(ms int64) int64 {
+	return (ms + 999) / 1000
+}
@@ -0,0 +1,127 @@
+package rate
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulule/limiter"
+)
+
+// fakeScripter is an in-process stand-in for redis running a Go port of the GCRA script
+type fakeScripter struct {
+	sync.Mutex
+	tats map[string]float64
+	now  time.Time
+	err  error
+}
+
+func newFakeScripter() *fakeScripter {
+	return &fakeScripter{tats: make(map[string]float64), now: time.Unix(1500000000, 0)}
+}
+
+func (f *fakeScripter) Eval(script string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.EvalSha("", keys, args...)
+}
+
+func (f *fakeScripter) EvalSha(sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.err != nil {
+		return redis.NewCmdResult(nil, f.err)
+	}
+
+	period := float64(args[0].(int64))
+	limit := float64(args[1].(int64))
+	cost := float64(args[2].(int))
+	now := float64(f.now.UnixNano() / int64(time.Millisecond))
+	interval := period / limit
+
+	tat, ok := f.tats[keys[0]]
+	if !ok || tat < now {
+		tat = now
+	}
+
+	newTat := tat + interval*cost
+	if newTat-now > period {
+		retry := int64(math.Ceil(tat + interval - period - now))
+		remaining := int64(math.Floor((period - (tat - now)) / interval))
+		return redis.NewCmdResult([]interface{}{int64(0), remaining, retry, int64(now)}, nil)
+	}
+
+	if cost > 0 {
+		f.tats[keys[0]] = newTat
+	}
+
+	remaining := int64(math.Floor((period - (newTat - now)) / interval))
+	return redis.NewCmdResult([]interface{}{int64(1), remaining, int64(math.Ceil(newTat - now)), int64(now)}, nil)
+}
+
+func (f *fakeScripter) ScriptExists(hashes ...string) *redis.BoolSliceCmd {
+	return redis.NewBoolSliceResult([]bool{true}, nil)
+}
+
+func (f *fakeScripter) ScriptLoad(script string) *redis.StringCmd {
+	return redis.NewStringResult("", nil)
+}
+
+func (f *fakeScripter) advance(d time.Duration) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+func (f *fakeScripter) fail(err error) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.err = err
+}
+
+func TestGCRAStore(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeScripter()
+	store := NewGCRAStore(client, DefaultPrefix)
+	rate := limiter.Rate{Formatted: "3-M", Period: time.Minute, Limit: 3}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		c, err := store.Get(ctx, "key", rate)
+		require.NoError(t, err)
+		assert.False(t, c.Reached)
+		assert.Equal(t, int64(i), c.Remaining)
+	}
+	assert.Contains(t, client.tats, "limiter:key")
+
+	c, err := store.Get(ctx, "key", rate)
+	require.NoError(t, err)
+	assert.True(t, c.Reached)
+	assert.Equal(t, client.now.Add(20*time.Second).Unix(), c.Reset, "a request is let through every 20 seconds")
+
+	client.advance(20 * time.Second)
+	c, err = store.Peek(ctx, "key", rate)
+	require.NoError(t, err)
+	assert.False(t, c.Reached)
+	assert.Equal(t, int64(1), c.Remaining)
+
+	c, err = store.Get(ctx, "key", rate)
+	require.NoError(t, err)
+	assert.False(t, c.Reached)
+
+	c, err = store.Get(ctx, "key", rate)
+	require.NoError(t, err)
+	assert.True(t, c.Reached, "the window slides, the quota is not reset at once")
+
+	client.fail(errors.New("connection refused"))
+	_, err = store.Get(ctx, "key", rate)
+	assert.Error(t, err)
+}
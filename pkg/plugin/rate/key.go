@@ -0,0 +1,84 @@
+package rate
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hellofresh/janus/pkg/consumer"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/ulule/limiter"
+)
+
+const (
+	keyIP        = "ip"
+	keyConsumer  = "consumer"
+	keyHeader    = "header"
+	keyClaim     = "claim"
+	keyBasicUser = "basic_user"
+)
+
+// KeySource represents a request value the requests are counted by
+type KeySource struct {
+	// Type is one of ip, consumer, header, claim or basic_user
+	Type string `json:"type" valid:"in(ip|consumer|header|claim|basic_user)"`
+	// Name is the name of the header or JWT claim
+	Name string `json:"name"`
+}
+
+// isVerified checks if the value of the source can be trusted. The header, claim and basic_user values are sent by
+// the client and not checked by the limiter, the consumer is the one the auth plugins resolved.
+func (s KeySource) isVerified() bool {
+	return s.Type == keyIP || s.Type == keyConsumer
+}
+
+// identity is the consumer a request is counted for
+type identity struct {
+	// value holds the request values joined by "|", it is what the consumer overrides are matched against
+	value string
+	// key is the counter key, it tells the sources apart so a header can not impersonate a client IP
+	key string
+	// fallback is set when a value was missing and the request is counted by client IP
+	fallback bool
+}
+
+// requestIdentity returns the identity the request is counted by. The values of a composite key are joined
+// and requests missing any of them are counted by their client IP.
+func requestIdentity(r *http.Request, sources []KeySource) identity {
+	if len(sources) == 0 {
+		sources = []KeySource{{Type: keyIP}}
+	}
+
+	values := make([]string, 0, len(sources))
+	keys := make([]string, 0, len(sources))
+	for _, source := range sources {
+		value := keyValue(r, source)
+		if value == "" {
+			ip := limiter.GetIP(r).String()
+			return identity{value: ip, key: keyIP + "=" + ip, fallback: true}
+		}
+
+		values = append(values, value)
+		keys = append(keys, source.Type+"="+value)
+	}
+
+	return identity{value: strings.Join(values, "|"), key: strings.Join(keys, "|")}
+}
+
+func keyValue(r *http.Request, source KeySource) string {
+	switch source.Type {
+	case keyConsumer:
+		if c, ok := consumer.FromContext(r.Context()); ok {
+			return c.ID
+		}
+		return ""
+	case keyHeader:
+		return r.Header.Get(source.Name)
+	case keyClaim:
+		return proxy.ClaimFromRequest(r, source.Name)
+	case keyBasicUser:
+		username, _, _ := r.BasicAuth()
+		return username
+	default:
+		return limiter.GetIP(r).String()
+	}
+}
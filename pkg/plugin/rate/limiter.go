@@ -0,0 +1,87 @@
+package rate
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ulule/limiter"
+)
+
+// RateLimiter counts the requests of every consumer against a set of limits
+type RateLimiter struct {
+	name      string
+	store     limiter.Store
+	key       []KeySource
+	rates     []limiter.Rate
+	overrides map[string][]limiter.Rate
+	failOpen  bool
+}
+
+// Option configures a RateLimiter
+type Option func(*RateLimiter)
+
+// WithFailOpen lets the requests through when the store fails, instead of rejecting them
+func WithFailOpen(failOpen bool) Option {
+	return func(l *RateLimiter) {
+		l.failOpen = failOpen
+	}
+}
+
+// Result is the state of the limits of a consumer after counting a request
+type Result struct {
+	// Identity is the value the request was counted by
+	Identity string
+	// Context is the state of the tightest limit, the reached one when the request is over a limit
+	Context limiter.Context
+	// Rates are the limits the request was counted against
+	Rates []limiter.Rate
+}
+
+// NewRateLimiter creates a new instance of RateLimiter, the overrides replace the limits of the consumers
+// matching their key
+func NewRateLimiter(name string, store limiter.Store, key []KeySource, rates []limiter.Rate, overrides map[string][]limiter.Rate, opts ...Option) *RateLimiter {
+	l := RateLimiter{
+		name:      name,
+		store:     store,
+		key:       key,
+		rates:     rates,
+		overrides: overrides,
+	}
+
+	for _, opt := range opts {
+		opt(&l)
+	}
+
+	return &l
+}
+
+// Limit counts the request against the limits of its consumer, in order. Once a limit is reached the request
+// is not counted against the next ones.
+func (l *RateLimiter) Limit(ctx context.Context, r *http.Request) (Result, error) {
+	id := requestIdentity(r, l.key)
+
+	rates := l.rates
+	if override, ok := l.overrides[id.value]; ok && !id.fallback {
+		rates = override
+	}
+
+	result := Result{Identity: id.value, Rates: rates}
+	for i, rate := range rates {
+		c, err := l.store.Get(ctx, l.name+":"+rate.Formatted+":"+id.key, rate)
+		if err != nil {
+			return result, err
+		}
+
+		// the rejected requests are not counted against the remaining limits
+		if c.Reached {
+			result.Context = c
+			break
+		}
+
+		if i == 0 || c.Remaining < result.Context.Remaining {
+			result.Context = c
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package rate
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/stats-go/bucket"
+	"github.com/hellofresh/stats-go/client"
+	log "github.com/sirupsen/logrus"
+	"github.com/ulule/limiter"
+)
+
+const (
+	limiterSection = "limiter"
+	limiterMetric  = "state"
+)
+
+// NewRateLimitMiddleware creates a new rate limit middleware. Requests over any of the limits of their
+// consumer are rejected with 429, all the responses carry the state of the tightest limit in the
+// RateLimit-* and X-RateLimit-* headers. When the limiter store fails the requests are let through or
+// rejected with 503, depending on the fail open setting of the limiter.
+func NewRateLimitMiddleware(l *RateLimiter, statsClient client.Client) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := l.Limit(r.Context(), r)
+			if err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"request_uri": r.RequestURI,
+					"fail_open":   l.failOpen,
+				}).Error("Failed to get limiter context from request")
+
+				if l.failOpen {
+					handler.ServeHTTP(w, r)
+					return
+				}
+
+				errors.Handler(w, ErrStoreUnavailable)
+				return
+			}
+
+			setHeaders(w.Header(), result, time.Now())
+			trackLimitState(statsClient, result.Context, r)
+
+			if result.Context.Reached {
+				log.WithFields(log.Fields{
+					"ip_address":  limiter.GetIP(r).String(),
+					"limiter_key": result.Identity,
+					"request_uri": r.RequestURI,
+				}).Warning("Rate Limit exceded for this consumer")
+
+				http.Error(w, "Limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+func setHeaders(h http.Header, result Result, now time.Time) {
+	c := result.Context
+	reset := c.Reset - now.Unix()
+	if reset < 0 {
+		reset = 0
+	}
+
+	policies := make([]string, 0, len(result.Rates)+1)
+	policies = append(policies, strconv.FormatInt(c.Limit, 10))
+	for _, rate := range result.Rates {
+		policies = append(policies, strconv.FormatInt(rate.Limit, 10)+";w="+strconv.FormatInt(int64(rate.Period/time.Second), 10))
+	}
+
+	h.Set("RateLimit-Limit", strings.Join(policies, ", "))
+	h.Set("RateLimit-Remaining", strconv.FormatInt(c.Remaining, 10))
+	h.Set("RateLimit-Reset", strconv.FormatInt(reset, 10))
+
+	h.Set("X-RateLimit-Limit", strconv.FormatInt(c.Limit, 10))
+	h.Set("X-RateLimit-Remaining", strconv.FormatInt(c.Remaining, 10))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(c.Reset, 10))
+
+	if c.Reached {
+		h.Set("Retry-After", strconv.FormatInt(reset, 10))
+	}
+}
+
+func trackLimitState(statsClient client.Client, c limiter.Context, r *http.Request) {
+	if statsClient == nil || c.Limit == 0 {
+		return
+	}
+
+	requestsPerformed := c.Limit - c.Remaining
+	limitState := requestsPerformed * 100 / c.Limit
+
+	operation := bucket.BuildHTTPRequestMetricOperation(r, statsClient.GetHTTPMetricCallback())
+	// replace request method with fixed section name
+	operation[0] = limiterMetric
+
+	statsClient.TrackState(limiterSection, operation, int(limitState))
+}
@@ -0,0 +1,211 @@
+package rate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/hellofresh/janus/pkg/consumer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulule/limiter"
+	smemory "github.com/ulule/limiter/drivers/store/memory"
+)
+
+func newTestRateLimiter(t *testing.T, key []KeySource, limits []string, overrides map[string][]string) *RateLimiter {
+	rates, err := parseRates(limits)
+	require.NoError(t, err)
+
+	parsedOverrides := make(map[string][]limiter.Rate, len(overrides))
+	for consumer, limits := range overrides {
+		parsedOverrides[consumer], err = parseRates(limits)
+		require.NoError(t, err)
+	}
+
+	return NewRateLimiter("example", smemory.NewStore(), key, rates, parsedOverrides)
+}
+
+func doRequest(handler http.Handler, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Parallel()
+
+	l := newTestRateLimiter(t, nil, []string{"2-M", "10-H"}, nil)
+	handler := NewRateLimitMiddleware(l, nil)(http.HandlerFunc(ping))
+
+	w := doRequest(handler, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2, 2;w=60, 10;w=3600", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = doRequest(handler, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = doRequest(handler, nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "Limit exceeded")
+}
+
+func TestRateLimitRejectedRequestsAreNotCounted(t *testing.T) {
+	t.Parallel()
+
+	store := smemory.NewStore()
+	rates, err := parseRates([]string{"1-M", "3-H"})
+	require.NoError(t, err)
+
+	handler := NewRateLimitMiddleware(NewRateLimiter("example", store, nil, rates, nil), nil)(http.HandlerFunc(ping))
+
+	assert.Equal(t, http.StatusOK, doRequest(handler, nil).Code)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusTooManyRequests, doRequest(handler, nil).Code)
+	}
+
+	c, err := store.Peek(context.Background(), "example:3-H:ip=10.0.0.1", rates[1])
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), c.Remaining)
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scenario string
+		key      []KeySource
+		first    http.Header
+		second   http.Header
+		shared   bool
+	}{
+		{
+			scenario: "when keyed by client ip",
+			first:    http.Header{"X-Api-Key": {"a"}},
+			second:   http.Header{"X-Api-Key": {"b"}},
+			shared:   true,
+		},
+		{
+			scenario: "when keyed by header",
+			key:      []KeySource{{Type: keyHeader, Name: "X-Api-Key"}},
+			first:    http.Header{"X-Api-Key": {"a"}},
+			second:   http.Header{"X-Api-Key": {"b"}},
+		},
+		{
+			scenario: "when keyed by claim",
+			key:      []KeySource{{Type: keyClaim, Name: "sub"}},
+			first:    http.Header{"Authorization": {"Bearer " + token(t, "alice")}},
+			second:   http.Header{"Authorization": {"Bearer " + token(t, "bob")}},
+		},
+		{
+			scenario: "when keyed by basic auth user",
+			key:      []KeySource{{Type: keyBasicUser}},
+			first:    http.Header{"Authorization": {basicAuth("alice")}},
+			second:   http.Header{"Authorization": {basicAuth("bob")}},
+		},
+		{
+			scenario: "when keyed by a composite key",
+			key:      []KeySource{{Type: keyHeader, Name: "X-Tenant"}, {Type: keyBasicUser}},
+			first:    http.Header{"X-Tenant": {"hf"}, "Authorization": {basicAuth("alice")}},
+			second:   http.Header{"X-Tenant": {"gc"}, "Authorization": {basicAuth("alice")}},
+		},
+		{
+			scenario: "when the key value is missing the client ip is used",
+			key:      []KeySource{{Type: keyHeader, Name: "X-Api-Key"}},
+			first:    http.Header{},
+			second:   http.Header{"X-Forwarded-For": {"10.0.0.2"}},
+			shared:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			l := newTestRateLimiter(t, test.key, []string{"1-M"}, nil)
+			handler := NewRateLimitMiddleware(l, nil)(http.HandlerFunc(ping))
+
+			assert.Equal(t, http.StatusOK, doRequest(handler, test.first).Code)
+
+			expected := http.StatusOK
+			if test.shared {
+				expected = http.StatusTooManyRequests
+			}
+			assert.Equal(t, expected, doRequest(handler, test.second).Code)
+		})
+	}
+}
+
+func TestRateLimitConsumerKey(t *testing.T) {
+	t.Parallel()
+
+	l := newTestRateLimiter(t, []KeySource{{Type: keyConsumer}}, []string{"1-M"}, nil)
+	handler := NewRateLimitMiddleware(l, nil)(http.HandlerFunc(ping))
+
+	doConsumerRequest := func(id string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req = req.WithContext(consumer.WithConsumer(req.Context(), &consumer.Consumer{ID: id}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, doConsumerRequest("alice"))
+	assert.Equal(t, http.StatusOK, doConsumerRequest("bob"))
+	assert.Equal(t, http.StatusTooManyRequests, doConsumerRequest("alice"))
+
+	// the requests without a consumer are counted by client IP
+	assert.Equal(t, http.StatusOK, doRequest(handler, http.Header{"X-Consumer-Id": {"alice"}}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(handler, nil).Code)
+}
+
+func TestRateLimitOverrides(t *testing.T) {
+	t.Parallel()
+
+	l := newTestRateLimiter(
+		t,
+		[]KeySource{{Type: keyHeader, Name: "X-Api-Key"}},
+		[]string{"1-M"},
+		map[string][]string{"premium": {"3-M"}},
+	)
+	handler := NewRateLimitMiddleware(l, nil)(http.HandlerFunc(ping))
+
+	premium := http.Header{"X-Api-Key": {"premium"}}
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, doRequest(handler, premium).Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(handler, premium).Code)
+
+	regular := http.Header{"X-Api-Key": {"regular"}}
+	assert.Equal(t, http.StatusOK, doRequest(handler, regular).Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(handler, regular).Code)
+}
+
+func ping(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func token(t *testing.T, sub string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": sub}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	return token
+}
+
+func basicAuth(username string) string {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth(username, "secret")
+	return req.Header.Get("Authorization")
+}
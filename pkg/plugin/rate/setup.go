@@ -6,12 +6,12 @@ import (
 
 	"github.com/asaskevich/govalidator"
 	"github.com/go-redis/redis"
+	"github.com/hellofresh/janus/pkg/consumer"
 	"github.com/hellofresh/janus/pkg/errors"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/hellofresh/stats-go/client"
 	"github.com/ulule/limiter"
-	"github.com/ulule/limiter/drivers/middleware/stdlib"
 	storeMemory "github.com/ulule/limiter/drivers/store/memory"
 	storeRedis "github.com/ulule/limiter/drivers/store/redis"
 )
@@ -20,23 +20,63 @@ var (
 	statsClient client.Client
 	// ErrInvalidPolicy is used when an invalid policy was provided
 	ErrInvalidPolicy = errors.New(http.StatusBadRequest, "policy is not supported")
+	// ErrNoLimits is used when no limit was provided
+	ErrNoLimits = errors.New(http.StatusBadRequest, "at least one limit is required")
+	// ErrUnverifiedKey is used when the key has a value sent by the client and the unverified keys are not allowed
+	ErrUnverifiedKey = errors.New(http.StatusBadRequest, "the header, claim and basic_user keys require allow_unverified_key")
 )
 
 const (
 	// DefaultPrefix is the default prefix to use for the key in the store.
 	DefaultPrefix = "limiter"
+
+	algorithmGCRA        = "gcra"
+	algorithmFixedWindow = "fixed_window"
+
+	onErrorOpen   = "open"
+	onErrorClosed = "closed"
+
+	defaultRedisPoolSize    = 10
+	defaultRedisIdleTimeout = 240 * time.Second
 )
 
 // Config represents a rate limit config
 type Config struct {
+	// Limit is a single limit, kept for the configurations written before Limits
 	Limit       string      `json:"limit"`
+	Limits      []string    `json:"limits"`
 	Policy      string      `json:"policy"`
 	RedisConfig redisConfig `json:"redis"`
+	// Algorithm is either fixed_window, the default, or gcra, that smooths the requests over the period
+	Algorithm string `json:"algorithm" valid:"in(gcra|fixed_window)"`
+	// OnError tells if the requests are let through (open) or rejected (closed) when the store fails
+	OnError       string              `json:"on_error" valid:"in(open|closed)"`
+	LocalFallback localFallbackConfig `json:"local_fallback"`
+	// Key is the request value the requests are counted by, several sources make a composite key
+	Key []KeySource `json:"key"`
+	// AllowUnverifiedKey allows the key sources whose values are not verified, they can be spoofed by the clients
+	AllowUnverifiedKey bool `json:"allow_unverified_key"`
+	// Overrides replaces the limits of the consumers matching the key value
+	Overrides map[string][]string `json:"overrides"`
 }
 
 type redisConfig struct {
-	DSN    string `json:"dsn"`
-	Prefix string `json:"prefix"`
+	DSN          string         `json:"dsn"`
+	Prefix       string         `json:"prefix"`
+	PoolSize     int            `json:"pool_size"`
+	MinIdleConns int            `json:"min_idle_conns"`
+	DialTimeout  proxy.Duration `json:"dial_timeout"`
+	ReadTimeout  proxy.Duration `json:"read_timeout"`
+	WriteTimeout proxy.Duration `json:"write_timeout"`
+	PoolTimeout  proxy.Duration `json:"pool_timeout"`
+	IdleTimeout  proxy.Duration `json:"idle_timeout"`
+}
+
+// localFallbackConfig represents the local limiter used while the redis store is down
+type localFallbackConfig struct {
+	Enabled bool `json:"enabled"`
+	// Replicas is the number of Janus instances sharing the limits, each of them enforces its share locally
+	Replicas int `json:"replicas"`
 }
 
 func init() {
@@ -58,8 +98,7 @@ func onStartup(event interface{}) error {
 }
 
 func validateConfig(rawConfig plugin.Config) (bool, error) {
-	var config Config
-	err := plugin.Decode(rawConfig, &config)
+	config, _, _, err := decodeConfig(rawConfig)
 	if err != nil {
 		return false, err
 	}
@@ -67,54 +106,157 @@ func validateConfig(rawConfig plugin.Config) (bool, error) {
 	return govalidator.ValidateStruct(config)
 }
 
+// setupRateLimit builds the limiter store once for the API, the consumer overrides share it and only change the
+// limits, key and error handling the requests are counted with
 func setupRateLimit(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
-	var config Config
-	err := plugin.Decode(rawConfig, &config)
+	config, _, _, err := decodeConfig(rawConfig)
 	if err != nil {
 		return err
 	}
 
-	rate, err := limiter.NewRateFromFormatted(config.Limit)
+	limiterStore, err := getLimiterStore(config)
 	if err != nil {
 		return err
 	}
 
-	limiterStore, err := getLimiterStore(config.Policy, config.RedisConfig)
+	mw, err := consumer.NewOverrideMiddleware("rate_limit", rawConfig, func(rawConfig map[string]interface{}) (func(http.Handler) http.Handler, error) {
+		config, rates, overrides, err := decodeConfig(rawConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		rateLimiter := NewRateLimiter(def.Name, limiterStore, config.Key, rates, overrides, WithFailOpen(config.OnError == onErrorOpen))
+		return NewRateLimitMiddleware(rateLimiter, statsClient), nil
+	})
 	if err != nil {
 		return err
 	}
 
-	limiterInstance := limiter.New(limiterStore, rate)
-	def.AddMiddleware(NewRateLimitLogger(limiterInstance, statsClient))
-	def.AddMiddleware(stdlib.NewMiddleware(limiterInstance).Handler)
-
+	def.AddMiddleware(mw)
 	return nil
 }
 
-func getLimiterStore(policy string, config redisConfig) (limiter.Store, error) {
-	switch policy {
+// decodeConfig decodes the config and parses its default and per consumer limits
+func decodeConfig(rawConfig plugin.Config) (Config, []limiter.Rate, map[string][]limiter.Rate, error) {
+	config := Config{
+		Algorithm: algorithmFixedWindow,
+		OnError:   onErrorOpen,
+	}
+	if err := plugin.Decode(rawConfig, &config); err != nil {
+		return config, nil, nil, err
+	}
+
+	if !config.AllowUnverifiedKey {
+		for _, source := range config.Key {
+			if !source.isVerified() {
+				return config, nil, nil, ErrUnverifiedKey
+			}
+		}
+	}
+
+	limits := config.Limits
+	if config.Limit != "" {
+		limits = append([]string{config.Limit}, limits...)
+	}
+	if len(limits) == 0 {
+		return config, nil, nil, ErrNoLimits
+	}
+
+	rates, err := parseRates(limits)
+	if err != nil {
+		return config, nil, nil, err
+	}
+
+	overrides := make(map[string][]limiter.Rate, len(config.Overrides))
+	for consumer, limits := range config.Overrides {
+		if len(limits) == 0 {
+			return config, nil, nil, ErrNoLimits
+		}
+
+		if overrides[consumer], err = parseRates(limits); err != nil {
+			return config, nil, nil, err
+		}
+	}
+
+	return config, rates, overrides, nil
+}
+
+func parseRates(limits []string) ([]limiter.Rate, error) {
+	rates := make([]limiter.Rate, 0, len(limits))
+	for _, limit := range limits {
+		rate, err := limiter.NewRateFromFormatted(limit)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func getLimiterStore(config Config) (limiter.Store, error) {
+	switch config.Policy {
 	case "redis":
-		option, err := redis.ParseURL(config.DSN)
+		redisClient, err := newRedisClient(config.RedisConfig)
 		if err != nil {
 			return nil, err
 		}
-		option.PoolSize = 3
-		option.IdleTimeout = 240 * time.Second
-		redisClient := redis.NewClient(option)
 
-		if config.Prefix == "" {
-			config.Prefix = DefaultPrefix
+		prefix := config.RedisConfig.Prefix
+		if prefix == "" {
+			prefix = DefaultPrefix
 		}
 
-		return storeRedis.NewStoreWithOptions(redisClient, limiter.StoreOptions{
-			Prefix:   config.Prefix,
-			MaxRetry: limiter.DefaultMaxRetry,
-		})
+		var shared limiter.Store
+		if config.Algorithm == algorithmGCRA {
+			shared = NewGCRAStore(redisClient, prefix)
+		} else {
+			shared, err = storeRedis.NewStoreWithOptions(redisClient, limiter.StoreOptions{
+				Prefix:   prefix,
+				MaxRetry: limiter.DefaultMaxRetry,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var local limiter.Store
+		if config.LocalFallback.Enabled {
+			local = NewTokenBucketStore()
+		}
+
+		return NewFallbackStore(shared, local, config.LocalFallback.Replicas), nil
 
 	case "local":
+		if config.Algorithm == algorithmGCRA {
+			return NewTokenBucketStore(), nil
+		}
 		return storeMemory.NewStore(), nil
 
 	default:
 		return nil, ErrInvalidPolicy
 	}
 }
+
+func newRedisClient(config redisConfig) (*redis.Client, error) {
+	option, err := redis.ParseURL(config.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	option.PoolSize = defaultRedisPoolSize
+	if config.PoolSize > 0 {
+		option.PoolSize = config.PoolSize
+	}
+	option.MinIdleConns = config.MinIdleConns
+	option.IdleTimeout = defaultRedisIdleTimeout
+	if config.IdleTimeout > 0 {
+		option.IdleTimeout = time.Duration(config.IdleTimeout)
+	}
+	option.DialTimeout = time.Duration(config.DialTimeout)
+	option.ReadTimeout = time.Duration(config.ReadTimeout)
+	option.WriteTimeout = time.Duration(config.WriteTimeout)
+	option.PoolTimeout = time.Duration(config.PoolTimeout)
+
+	return redis.NewClient(option), nil
+}
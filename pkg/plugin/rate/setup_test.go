@@ -1,11 +1,15 @@
 package rate
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/hellofresh/janus/pkg/consumer"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRateLimitConfig(t *testing.T) {
@@ -20,6 +24,10 @@ func TestRateLimitConfig(t *testing.T) {
 
 	assert.Equal(t, "10-S", config.Limit)
 	assert.Equal(t, "local", config.Policy)
+
+	config, _, _, err = decodeConfig(rawConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, algorithmFixedWindow, config.Algorithm)
 }
 
 func TestInvalidRateLimitConfig(t *testing.T) {
@@ -42,7 +50,35 @@ func TestRateLimitPluginLocalPolicy(t *testing.T) {
 	err := setupRateLimit(def, rawConfig)
 
 	assert.NoError(t, err)
-	assert.Len(t, def.Middleware(), 2)
+	assert.Len(t, def.Middleware(), 1)
+}
+
+func TestRateLimitPluginConsumerOverridesShareTheStore(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"limits": []string{"10-M"},
+		"policy": "local",
+		"key":    []map[string]interface{}{{"type": "consumer"}},
+	}
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	def.Name = "example"
+	require.NoError(t, setupRateLimit(def, rawConfig))
+	handler := def.Middleware()[0](http.HandlerFunc(ping))
+
+	alice := &consumer.Consumer{ID: "alice"}
+	do := func(override map[string]interface{}) int {
+		alice.Plugins = map[string]map[string]interface{}{"rate_limit": override}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(consumer.WithConsumer(req.Context(), alice))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do(map[string]interface{}{"limits": []string{"1-M"}}))
+	// a changed override is rebuilt with the store of the API, so the counters are kept
+	assert.Equal(t, http.StatusTooManyRequests, do(map[string]interface{}{"limits": []string{"1-M"}, "on_error": "closed"}))
 }
 
 func TestRateLimitPluginRedisPolicyWithInvalidStorage(t *testing.T) {
@@ -68,3 +104,98 @@ func TestRateLimitPluginInvalidPolicy(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestRateLimitConfigWithSeveralLimits(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"limit":  "10-S",
+		"limits": []string{"1000-H"},
+		"policy": "local",
+		"key": []map[string]interface{}{
+			{"type": "header", "name": "X-Tenant"},
+			{"type": "claim", "name": "sub"},
+		},
+		"allow_unverified_key": true,
+		"overrides": map[string]interface{}{
+			"hf|admin": []string{"100-S"},
+		},
+	}
+
+	result, err := validateConfig(rawConfig)
+	assert.NoError(t, err)
+	assert.True(t, result)
+
+	config, rates, overrides, err := decodeConfig(rawConfig)
+	assert.NoError(t, err)
+	assert.Len(t, config.Key, 2)
+	assert.Len(t, rates, 2)
+	assert.Len(t, overrides["hf|admin"], 1)
+}
+
+func TestRateLimitClusterConfig(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"limits":    []string{"100-S"},
+		"policy":    "redis",
+		"algorithm": "gcra",
+		"on_error":  "closed",
+		"redis": map[string]interface{}{
+			"dsn":          "redis://localhost:6379",
+			"pool_size":    50,
+			"read_timeout": "50ms",
+		},
+		"local_fallback": map[string]interface{}{
+			"enabled":  true,
+			"replicas": 30,
+		},
+	}
+
+	result, err := validateConfig(rawConfig)
+	assert.NoError(t, err)
+	assert.True(t, result)
+
+	config, _, _, err := decodeConfig(rawConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, config.RedisConfig.PoolSize)
+	assert.Equal(t, 30, config.LocalFallback.Replicas)
+
+	store, err := getLimiterStore(config)
+	assert.NoError(t, err)
+	assert.IsType(t, &FallbackStore{}, store)
+}
+
+func TestRateLimitLocalAlgorithms(t *testing.T) {
+	store, err := getLimiterStore(Config{Policy: "local", Algorithm: algorithmGCRA})
+	assert.NoError(t, err)
+	assert.IsType(t, &TokenBucketStore{}, store)
+
+	store, err = getLimiterStore(Config{Policy: "local", Algorithm: algorithmFixedWindow})
+	assert.NoError(t, err)
+	assert.NotNil(t, store)
+}
+
+func TestRateLimitConsumerKeyConfig(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"limits": []string{"10-S"},
+		"policy": "local",
+		"key":    []map[string]interface{}{{"type": "consumer"}},
+	}
+
+	result, err := validateConfig(rawConfig)
+	assert.NoError(t, err)
+	assert.True(t, result)
+}
+
+func TestInvalidRateLimitConfigs(t *testing.T) {
+	for _, rawConfig := range []map[string]interface{}{
+		{"limits": []string{"10-S"}, "algorithm": "leaky"},
+		{"limits": []string{"10-S"}, "on_error": "maybe"},
+		{"policy": "local"},
+		{"limits": []string{"10-X"}, "policy": "local"},
+		{"limits": []string{"10-S"}, "overrides": map[string]interface{}{"a": []string{"wrong"}}},
+		{"limits": []string{"10-S"}, "key": []map[string]interface{}{{"type": "cookie"}}},
+		{"limits": []string{"10-S"}, "key": []map[string]interface{}{{"type": "header", "name": "X-Api-Key"}}},
+	} {
+		result, err := validateConfig(rawConfig)
+		assert.Error(t, err)
+		assert.False(t, result)
+	}
+}
@@ -0,0 +1,112 @@
+package rate
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ulule/limiter"
+)
+
+const sweepInterval = time.Minute
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	rate   limiter.Rate
+}
+
+// TokenBucketStore is an in-process limiter.Store, every key has a bucket holding up to the limit of tokens
+// that refills continuously over the period
+type TokenBucketStore struct {
+	sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewTokenBucketStore creates a new instance of TokenBucketStore
+func NewTokenBucketStore() *TokenBucketStore {
+	return &TokenBucketStore{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Get takes a token from the bucket of the key and returns the state of its limit
+func (s *TokenBucketStore) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return s.take(key, rate, 1), nil
+}
+
+// Peek returns the state of the limit of the key without taking a token
+func (s *TokenBucketStore) Peek(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return s.take(key, rate, 0), nil
+}
+
+func (s *TokenBucketStore) take(key string, rate limiter.Rate, cost float64) limiter.Context {
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	limit := float64(rate.Limit)
+	b, ok := s.buckets[key]
+	if !ok || b.rate != rate {
+		b = &tokenBucket{tokens: limit, last: now, rate: rate}
+		s.buckets[key] = b
+	}
+	b.refill(now)
+
+	perToken := float64(rate.Period) / limit
+	if b.tokens < cost {
+		return limiter.Context{
+			Limit:     rate.Limit,
+			Remaining: 0,
+			Reset:     resetAt(now, time.Duration((cost-b.tokens)*perToken)),
+			Reached:   true,
+		}
+	}
+
+	b.tokens -= cost
+	return limiter.Context{
+		Limit:     rate.Limit,
+		Remaining: int64(math.Floor(b.tokens)),
+		Reset:     resetAt(now, time.Duration((limit-b.tokens)*perToken)),
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return
+	}
+
+	limit := float64(b.rate.Limit)
+	b.tokens = math.Min(limit, b.tokens+float64(elapsed)*limit/float64(b.rate.Period))
+	b.last = now
+}
+
+// sweep drops the full buckets, they are the same as no bucket at all
+func (s *TokenBucketStore) sweep(now time.Time) {
+	if s.lastSweep.IsZero() {
+		s.lastSweep = now
+	}
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.rate.Limit) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// resetAt returns the unix time in seconds, rounded up, the given time from now
+func resetAt(now time.Time, d time.Duration) int64 {
+	return ceilSeconds(int64(now.Add(d).UnixNano() / int64(time.Millisecond)))
+}
@@ -0,0 +1,52 @@
+package rate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulule/limiter"
+)
+
+func TestTokenBucketStore(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1500000000, 0)
+	store := NewTokenBucketStore()
+	store.now = func() time.Time { return now }
+	rate := limiter.Rate{Formatted: "2-S", Period: time.Second, Limit: 2}
+	ctx := context.Background()
+
+	c, err := store.Get(ctx, "key", rate)
+	require.NoError(t, err)
+	assert.False(t, c.Reached)
+	assert.Equal(t, int64(1), c.Remaining)
+
+	c, _ = store.Get(ctx, "key", rate)
+	assert.False(t, c.Reached)
+	assert.Equal(t, int64(0), c.Remaining)
+
+	c, _ = store.Get(ctx, "key", rate)
+	assert.True(t, c.Reached)
+	assert.Equal(t, now.Add(500*time.Millisecond).Unix()+1, c.Reset)
+
+	c, _ = store.Get(ctx, "other", rate)
+	assert.False(t, c.Reached, "every key has its own bucket")
+
+	now = now.Add(500 * time.Millisecond)
+	c, _ = store.Peek(ctx, "key", rate)
+	assert.Equal(t, int64(1), c.Remaining, "the bucket refills over the period")
+
+	c, _ = store.Get(ctx, "key", rate)
+	assert.False(t, c.Reached)
+	c, _ = store.Get(ctx, "key", rate)
+	assert.True(t, c.Reached)
+
+	now = now.Add(2 * time.Minute)
+	store.Peek(ctx, "key", rate)
+	store.Lock()
+	assert.Len(t, store.buckets, 1, "the full buckets are dropped")
+	store.Unlock()
+}
@@ -1,6 +1,9 @@
 package requesttransformer
 
 import (
+	"net/http"
+
+	"github.com/hellofresh/janus/pkg/consumer"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 )
@@ -12,12 +15,21 @@ func init() {
 }
 
 func setupRequestTransformer(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
-	var config Config
-	err := plugin.Decode(rawConfig, &config)
+	mw, err := consumer.NewOverrideMiddleware("request_transformer", rawConfig, newRequestTransformerMiddleware)
 	if err != nil {
 		return err
 	}
 
-	def.AddMiddleware(NewRequestTransformer(config))
+	def.AddMiddleware(mw)
 	return nil
 }
+
+func newRequestTransformerMiddleware(rawConfig map[string]interface{}) (func(http.Handler) http.Handler, error) {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRequestTransformer(config), nil
+}
@@ -1,6 +1,9 @@
 package responsetransformer
 
 import (
+	"net/http"
+
+	"github.com/hellofresh/janus/pkg/consumer"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 )
@@ -12,12 +15,21 @@ func init() {
 }
 
 func setupResponseTransformer(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
-	var config Config
-	err := plugin.Decode(rawConfig, &config)
+	mw, err := consumer.NewOverrideMiddleware("response_transformer", rawConfig, newResponseTransformerMiddleware)
 	if err != nil {
 		return err
 	}
 
-	def.AddMiddleware(NewResponseTransformer(config))
+	def.AddMiddleware(mw)
 	return nil
 }
+
+func newResponseTransformerMiddleware(rawConfig map[string]interface{}) (func(http.Handler) http.Handler, error) {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewResponseTransformer(config), nil
+}
@@ -0,0 +1,68 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+const budgetWindow = 10 * time.Second
+
+// budget allows the retries while they are fewer than the configured percentage of the requests, plus the
+// minimum number of retries, in the current window. A nil budget allows all the retries.
+type budget struct {
+	sync.Mutex
+	percent     int
+	minRetries  int
+	requests    int
+	retries     int
+	windowStart time.Time
+	now         func() time.Time
+}
+
+func newBudget(cfg Budget) *budget {
+	if cfg.Percent <= 0 && cfg.MinRetries <= 0 {
+		return nil
+	}
+
+	return &budget{percent: cfg.Percent, minRetries: cfg.MinRetries, now: time.Now}
+}
+
+// request records a new request in the budget
+func (b *budget) request() {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.roll()
+	b.requests++
+}
+
+// withdraw checks if there is budget left for one more retry and records it
+func (b *budget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.roll()
+	if b.retries >= b.minRetries+b.requests*b.percent/100 {
+		return false
+	}
+
+	b.retries++
+	return true
+}
+
+func (b *budget) roll() {
+	now := b.now()
+	if now.Sub(b.windowStart) >= budgetWindow {
+		b.windowStart = now
+		b.requests = 0
+		b.retries = 0
+	}
+}
@@ -0,0 +1,36 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudget(t *testing.T) {
+	now := time.Now()
+	b := newBudget(Budget{Percent: 20, MinRetries: 1})
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		b.request()
+	}
+
+	// 20% of 10 requests plus the minimum retry
+	assert.True(t, b.withdraw())
+	assert.True(t, b.withdraw())
+	assert.True(t, b.withdraw())
+	assert.False(t, b.withdraw())
+
+	now = now.Add(budgetWindow)
+	assert.True(t, b.withdraw())
+	assert.False(t, b.withdraw())
+}
+
+func TestNilBudget(t *testing.T) {
+	b := newBudget(Budget{})
+	assert.Nil(t, b)
+
+	b.request()
+	assert.True(t, b.withdraw())
+}
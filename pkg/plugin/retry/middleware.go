@@ -1,65 +1,175 @@
 package retry
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"time"
 
+	"code.cloudfoundry.org/bytefmt"
 	"github.com/Knetic/govaluate"
-	"github.com/felixge/httpsnoop"
-	janusErr "github.com/hellofresh/janus/pkg/errors"
 	"github.com/hellofresh/janus/pkg/metrics"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/request"
 	"github.com/pkg/errors"
-	"github.com/rafaeljesus/retry-go"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
-	defaultPredicate = "statusCode == 0 || statusCode >= 500"
+	defaultPredicate = "statusCode >= 500"
 	proxySection     = "proxy"
 )
 
-// NewRetryMiddleware creates a new retry middleware
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// NewRetryMiddleware creates a new retry middleware. The response of every attempt is held back until the
+// predicate passes or there are no attempts left, so the client only gets the response of the last attempt.
+// Responses bigger than the response limit are streamed to the client and not retried.
 func NewRetryMiddleware(cfg Config) func(http.Handler) http.Handler {
+	if cfg.Predicate == "" {
+		cfg.Predicate = defaultPredicate
+	}
+	if cfg.BodyLimit == "" {
+		cfg.BodyLimit = defaultBodyLimit
+	}
+
+	if cfg.ResponseLimit == "" {
+		cfg.ResponseLimit = defaultResponseLimit
+	}
+
+	limit, err := bytefmt.ToBytes(cfg.BodyLimit)
+	if err != nil {
+		log.WithError(err).WithField("body_limit", cfg.BodyLimit).Error("invalid retry body_limit")
+	}
+	responseLimit, err := bytefmt.ToBytes(cfg.ResponseLimit)
+	if err != nil {
+		log.WithError(err).WithField("response_limit", cfg.ResponseLimit).Error("invalid retry response_limit")
+	}
+
+	expression, expressionErr := govaluate.NewEvaluableExpression(cfg.Predicate)
+	budget := newBudget(cfg.Budget)
+
 	return func(handler http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			log.WithFields(log.Fields{
-				"attempts": cfg.Attempts,
-				"backoff":  cfg.Backoff,
-			}).Debug("Starting retry middleware")
+		if expressionErr != nil {
+			log.WithError(expressionErr).Error("could not create an expression with this predicate")
+			return handler
+		}
 
-			if cfg.Predicate == "" {
-				cfg.Predicate = defaultPredicate
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Attempts <= 1 || (cfg.IdempotentOnly && !idempotentMethods[r.Method]) {
+				handler.ServeHTTP(w, r)
+				return
 			}
 
-			expression, err := govaluate.NewEvaluableExpression(cfg.Predicate)
-			if err != nil {
-				log.WithError(err).Error("could not create an expression with this predicate")
+			body, ok := request.BufferBody(r, int64(limit))
+			if !ok {
+				log.Debug("Request body is too big to be retried")
 				handler.ServeHTTP(w, r)
 				return
 			}
 
-			if err := retry.Do(func() error {
-				m := httpsnoop.CaptureMetrics(handler, w, r)
+			log.WithFields(log.Fields{
+				"attempts": cfg.Attempts,
+				"backoff":  cfg.Backoff,
+			}).Debug("Starting retry middleware")
+
+			budget.request()
+			ctx := proxy.WithAttempts(r.Context(), &proxy.Attempts{})
 
-				params := make(map[string]interface{}, 8)
-				params["statusCode"] = m.Code
-				params["request"] = r
+			var resp *responseBuffer
+			for attempt := 1; ; attempt++ {
+				resp = serveAttempt(w, handler, r.WithContext(ctx), body, time.Duration(cfg.PerTryTimeout), int64(responseLimit))
+				if resp.streaming {
+					log.WithField("attempts", attempt).Debug("Response is too big to be retried, it was streamed")
+					return
+				}
 
-				result, err := expression.Evaluate(params)
+				failed, err := evaluate(expression, resp.code, r)
 				if err != nil {
-					return errors.New("cannot evaluate the expression")
+					log.WithError(err).Error("cannot evaluate the expression")
+					break
+				}
+				if !failed {
+					break
 				}
 
-				if result.(bool) {
-					return errors.Errorf("%s %s request failed", r.Method, r.URL)
+				if attempt >= cfg.Attempts || r.Context().Err() != nil || !budget.withdraw() {
+					log.WithError(errors.Errorf("%s %s request failed", r.Method, r.URL)).
+						WithField("attempts", attempt).Debug("request failed too many times")
+					statsClient := metrics.WithContext(r.Context())
+					statsClient.SetHTTPRequestSection(proxySection).TrackRequest(r, nil, false).ResetHTTPRequestSection()
+					break
 				}
 
-				return nil
-			}, cfg.Attempts, time.Duration(cfg.Backoff)); err != nil {
-				statsClient := metrics.WithContext(r.Context())
-				statsClient.SetHTTPRequestSection(proxySection).TrackRequest(r, nil, false).ResetHTTPRequestSection()
-				janusErr.Handler(w, errors.Wrap(err, "request failed too many times"))
+				select {
+				case <-time.After(backoff(attempt, time.Duration(cfg.Backoff), time.Duration(cfg.MaxBackoff))):
+				case <-r.Context().Done():
+				}
 			}
+
+			resp.writeTo(w)
 		})
 	}
 }
+
+func serveAttempt(w http.ResponseWriter, handler http.Handler, r *http.Request, body []byte, timeout time.Duration, limit int64) *responseBuffer {
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+
+	if body != nil {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	resp := newResponseBuffer(w, limit)
+	handler.ServeHTTP(resp, r)
+
+	return resp
+}
+
+func evaluate(expression *govaluate.EvaluableExpression, code int, r *http.Request) (bool, error) {
+	params := make(map[string]interface{}, 8)
+	params["statusCode"] = code
+	params["request"] = r
+
+	result, err := expression.Evaluate(params)
+	if err != nil {
+		return false, err
+	}
+
+	failed, ok := result.(bool)
+	if !ok {
+		return false, errors.New("the predicate result is not a boolean")
+	}
+
+	return failed, nil
+}
+
+// backoff returns the time to wait after the given attempt, it grows exponentially up to max and half of it
+// is random so the retries of many clients are spread over time
+func backoff(attempt int, base time.Duration, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -1,13 +1,21 @@
 package retry
 
 import (
+	"context"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/hellofresh/janus/pkg/proxy/discovery"
 	"github.com/hellofresh/janus/pkg/test"
+	"github.com/hellofresh/stats-go/client"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMiddleware(t *testing.T) {
@@ -29,6 +37,14 @@ func TestMiddleware(t *testing.T) {
 			scenario: "when the upstream fails to respond",
 			function: testFailedUpstreamRetry,
 		},
+		{
+			scenario: "when the upstream recovers",
+			function: testRecoveredUpstreamRetry,
+		},
+		{
+			scenario: "when an attempt times out",
+			function: testPerTryTimeout,
+		},
 	}
 
 	for _, test := range tests {
@@ -66,3 +82,184 @@ func testFailedUpstreamRetry(t *testing.T, r *http.Request, w *httptest.Response
 
 	assert.Equal(t, http.StatusBadGateway, w.Code)
 }
+
+func testRecoveredUpstreamRetry(t *testing.T, r *http.Request, w *httptest.ResponseRecorder) {
+	mw := NewRetryMiddleware(Config{Attempts: 3, Backoff: Duration(time.Millisecond)})
+
+	calls := 0
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.Header().Set("X-Failed", "true")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("failed"))
+			return
+		}
+		w.Write([]byte("ok"))
+	})).ServeHTTP(w, r)
+
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+	assert.Empty(t, w.Header().Get("X-Failed"))
+}
+
+func testPerTryTimeout(t *testing.T, r *http.Request, w *httptest.ResponseRecorder) {
+	mw := NewRetryMiddleware(Config{Attempts: 2, PerTryTimeout: Duration(10 * time.Millisecond)})
+
+	calls := 0
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			<-r.Context().Done()
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		w.Write([]byte("ok"))
+	})).ServeHTTP(w, r)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRetryReplaysRequestBody(t *testing.T) {
+	mw := NewRetryMiddleware(Config{Attempts: 3, Backoff: Duration(time.Millisecond), IdempotentOnly: true})
+
+	var bodies []string
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	r := httptest.NewRequest(http.MethodPut, "/", strings.NewReader("payload"))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, []string{"payload", "payload", "payload"}, bodies)
+}
+
+func TestRetryStreamsBigResponses(t *testing.T) {
+	mw := NewRetryMiddleware(Config{Attempts: 3, Backoff: Duration(time.Millisecond), ResponseLimit: "1K"})
+
+	calls := 0
+	body := strings.Repeat("a", 1500)
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(body[:1000]))
+		w.Write([]byte(body[1000:]))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, 1, calls, "a streamed response is not retried")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, body, w.Body.String())
+}
+
+func TestRetrySkipsRequests(t *testing.T) {
+	tests := []struct {
+		scenario string
+		config   Config
+		request  func() *http.Request
+	}{
+		{
+			scenario: "non idempotent method",
+			config:   Config{Attempts: 3, IdempotentOnly: true},
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload"))
+			},
+		},
+		{
+			scenario: "body over the limit",
+			config:   Config{Attempts: 3, BodyLimit: "2B"},
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodPut, "/", strings.NewReader("payload"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			calls := 0
+			handler := NewRetryMiddleware(tt.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "payload", string(body))
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tt.request())
+
+			assert.Equal(t, 1, calls)
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+		})
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	mw := NewRetryMiddleware(Config{Attempts: 3, Budget: Budget{MinRetries: 2}})
+
+	calls := 0
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	// the first request retries twice and spends the budget, the following ones are not retried
+	assert.Equal(t, 5, calls)
+}
+
+func TestRetryStopsWhenClientGoesAway(t *testing.T) {
+	mw := NewRetryMiddleware(Config{Attempts: 3, Backoff: Duration(time.Hour)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		cancel()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryUsesDifferentTarget(t *testing.T) {
+	failing := httptest.NewServer(test.FailWith(http.StatusServiceUnavailable))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(test.Ping))
+	defer healthy.Close()
+
+	def := proxy.NewDefinition()
+	def.Upstreams.Balancing = "roundrobin"
+	source := discovery.Static{{Target: failing.URL}, {Target: healthy.URL}}
+	handler := proxy.NewBalancedReverseProxy(def, source, balancer.NewRoundrobinBalancer(), client.NewNoop(), nil)
+
+	mw := NewRetryMiddleware(Config{Attempts: 2, Backoff: Duration(time.Millisecond)})
+	for i := 0; i < 4; i++ {
+		w := httptest.NewRecorder()
+		mw(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, max := range map[int]time.Duration{1: 100, 2: 200, 3: 400, 4: 500, 10: 500} {
+		d := backoff(attempt, 100, 500)
+		assert.True(t, d >= max/2 && d <= max, "attempt %d: %s", attempt, d)
+	}
+
+	assert.Equal(t, time.Duration(0), backoff(1, 0, 0))
+}
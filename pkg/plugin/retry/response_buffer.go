@@ -0,0 +1,76 @@
+package retry
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// responseBuffer holds the response of an attempt until it is known whether it is sent to the client. Once the
+// body is bigger than the limit the buffered response is sent and the rest of the body is streamed, the attempt
+// can not be retried anymore.
+type responseBuffer struct {
+	w           http.ResponseWriter
+	limit       int64
+	header      http.Header
+	code        int
+	wroteHeader bool
+	body        bytes.Buffer
+	streaming   bool
+}
+
+func newResponseBuffer(w http.ResponseWriter, limit int64) *responseBuffer {
+	return &responseBuffer{w: w, limit: limit, header: make(http.Header), code: http.StatusOK}
+}
+
+func (b *responseBuffer) Header() http.Header {
+	if b.streaming {
+		return b.w.Header()
+	}
+
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(code int) {
+	if b.wroteHeader {
+		return
+	}
+
+	b.code = code
+	b.wroteHeader = true
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	if b.streaming {
+		return b.w.Write(p)
+	}
+
+	if int64(b.body.Len()+len(p)) > b.limit {
+		b.writeTo(b.w)
+		b.streaming = true
+		return b.w.Write(p)
+	}
+
+	return b.body.Write(p)
+}
+
+// Flush only sends the response once it is streamed, until then it is held back
+func (b *responseBuffer) Flush() {
+	if !b.streaming {
+		return
+	}
+
+	if f, ok := b.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (b *responseBuffer) writeTo(w http.ResponseWriter) {
+	header := w.Header()
+	for k, v := range b.header {
+		header[k] = v
+	}
+
+	w.WriteHeader(b.code)
+	w.Write(b.body.Bytes())
+}
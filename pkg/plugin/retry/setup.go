@@ -4,6 +4,8 @@ import (
 	"strconv"
 	"time"
 
+	"code.cloudfoundry.org/bytefmt"
+	"github.com/Knetic/govaluate"
 	"github.com/asaskevich/govalidator"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
@@ -12,14 +14,39 @@ import (
 
 const (
 	strNull = "null"
+
+	defaultBackoff    = time.Second
+	defaultMaxBackoff = 10 * time.Second
+	defaultBodyLimit  = "1M"
+
+	defaultResponseLimit = "1M"
 )
 
 type (
-	// Config represents the Body Limit configuration
+	// Config represents the Retry configuration
 	Config struct {
-		Attempts  int      `json:"attempts"`
-		Backoff   Duration `json:"backoff"`
-		Predicate string   `json:"predicate"`
+		// Attempts is the maximum number of times a request is sent, including the first one
+		Attempts   int      `json:"attempts"`
+		Backoff    Duration `json:"backoff"`
+		MaxBackoff Duration `json:"max_backoff"`
+		Predicate  string   `json:"predicate"`
+		// PerTryTimeout is the timeout of every attempt, zero means no timeout
+		PerTryTimeout Duration `json:"per_try_timeout"`
+		// BodyLimit is the biggest request body that is buffered to be sent again, requests with bigger
+		// bodies are not retried
+		BodyLimit string `json:"body_limit"`
+		// ResponseLimit is the biggest response body that is held back, bigger responses are streamed to the
+		// client and not retried
+		ResponseLimit  string `json:"response_limit"`
+		IdempotentOnly bool   `json:"idempotent_only"`
+		Budget         Budget `json:"budget"`
+	}
+
+	// Budget limits the number of retries to a percentage of the requests, so retries do not overload
+	// upstreams that are already failing
+	Budget struct {
+		Percent    int `json:"percent" valid:"range(0|100)"`
+		MinRetries int `json:"min_retries"`
 	}
 
 	// Duration is a wrapper for time.Duration so we can use human readable configs
@@ -63,8 +90,7 @@ func init() {
 }
 
 func setupRetry(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
-	var config Config
-	err := plugin.Decode(rawConfig, &config)
+	config, err := decodeConfig(rawConfig)
 	if err != nil {
 		return err
 	}
@@ -74,11 +100,38 @@ func setupRetry(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
 }
 
 func validateConfig(rawConfig plugin.Config) (bool, error) {
-	var config Config
-	err := plugin.Decode(rawConfig, &config)
+	config, err := decodeConfig(rawConfig)
 	if err != nil {
 		return false, err
 	}
 
+	if config.Predicate != "" {
+		if _, err := govaluate.NewEvaluableExpression(config.Predicate); err != nil {
+			return false, errors.Wrap(err, "invalid retry predicate")
+		}
+	}
+
 	return govalidator.ValidateStruct(config)
 }
+
+func decodeConfig(rawConfig plugin.Config) (Config, error) {
+	config := Config{
+		Backoff:        Duration(defaultBackoff),
+		MaxBackoff:     Duration(defaultMaxBackoff),
+		BodyLimit:      defaultBodyLimit,
+		ResponseLimit:  defaultResponseLimit,
+		IdempotentOnly: true,
+	}
+	if err := plugin.Decode(rawConfig, &config); err != nil {
+		return config, err
+	}
+
+	if _, err := bytefmt.ToBytes(config.BodyLimit); err != nil {
+		return config, errors.Wrap(err, "invalid retry body_limit")
+	}
+	if _, err := bytefmt.ToBytes(config.ResponseLimit); err != nil {
+		return config, errors.Wrap(err, "invalid retry response_limit")
+	}
+
+	return config, nil
+}
@@ -15,3 +15,45 @@ func TestSetup(t *testing.T) {
 
 	assert.Len(t, def.Middleware(), 1)
 }
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		scenario string
+		config   plugin.Config
+		valid    bool
+	}{
+		{
+			scenario: "valid config",
+			config:   plugin.Config{"attempts": 3, "backoff": "100ms", "per_try_timeout": "1s", "budget": map[string]interface{}{"percent": 20}},
+			valid:    true,
+		},
+		{
+			scenario: "invalid predicate",
+			config:   plugin.Config{"predicate": "statusCode >="},
+		},
+		{
+			scenario: "invalid body limit",
+			config:   plugin.Config{"body_limit": "lots"},
+		},
+		{
+			scenario: "invalid response limit",
+			config:   plugin.Config{"response_limit": "lots"},
+		},
+		{
+			scenario: "budget percent out of range",
+			config:   plugin.Config{"budget": map[string]interface{}{"percent": 200}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			valid, err := validateConfig(tt.config)
+			assert.Equal(t, tt.valid, valid)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+)
+
+// Attempts records the upstream targets a request was sent to. Middleware that sends a request more than once,
+// like retries, adds it to the request context so every attempt goes to a target that was not tried yet.
+type Attempts struct {
+	sync.Mutex
+	targets []string
+}
+
+// WithAttempts returns a copy of ctx that records the targets the request is sent to in the given attempts
+func WithAttempts(ctx context.Context, attempts *Attempts) context.Context {
+	return context.WithValue(ctx, attemptsKey, attempts)
+}
+
+// Targets returns the targets the request was sent to, in order
+func (a *Attempts) Targets() []string {
+	a.Lock()
+	defer a.Unlock()
+
+	return append([]string(nil), a.targets...)
+}
+
+func (a *Attempts) add(target string) {
+	a.Lock()
+	defer a.Unlock()
+
+	a.targets = append(a.targets, target)
+}
+
+func (a *Attempts) tried(target string) bool {
+	a.Lock()
+	defer a.Unlock()
+
+	for _, t := range a.targets {
+		if t == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// untriedTargets returns the targets the request was not sent to yet, or all of them if all were tried
+func (a *Attempts) untriedTargets(targets []*balancer.Target) []*balancer.Target {
+	var untried []*balancer.Target
+	for _, t := range targets {
+		if !a.tried(t.Target) {
+			untried = append(untried, t)
+		}
+	}
+
+	if len(untried) == 0 {
+		return targets
+	}
+
+	return untried
+}
+
+func attemptsFromContext(ctx context.Context) *Attempts {
+	attempts, _ := ctx.Value(attemptsKey).(*Attempts)
+	return attempts
+}
@@ -3,9 +3,12 @@
 package balancer
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -22,7 +25,16 @@ var (
 type (
 	// Balancer holds the load balancer methods for many different algorithms
 	Balancer interface {
-		Elect(hosts []*Target) (*Target, error)
+		// Elect elects one of the given hosts, ctx is the context of the request being balanced
+		Elect(ctx context.Context, hosts []*Target) (*Target, error)
+	}
+
+	// FeedbackBalancer is a Balancer that learns from the outcome of the requests sent to the elected targets
+	FeedbackBalancer interface {
+		Balancer
+		// Done is called once the request sent to the elected target is finished, rtt is the time it took
+		// for the target to respond and err is set when the request failed on the transport level
+		Done(target *Target, rtt time.Duration, err error)
 	}
 
 	// Target is an ip address/hostname with a port that identifies an instance of a backend service
@@ -37,6 +49,9 @@ func init() {
 	typeRegistry["roundrobin"] = reflect.TypeOf(RoundrobinBalancer{})
 	typeRegistry["rr"] = reflect.TypeOf(RoundrobinBalancer{})
 	typeRegistry["weight"] = reflect.TypeOf(WeightBalancer{})
+	typeRegistry["least_conn"] = reflect.TypeOf(LeastConnBalancer{})
+	typeRegistry["ewma"] = reflect.TypeOf(EWMABalancer{})
+	typeRegistry["hash"] = reflect.TypeOf(HashBalancer{})
 }
 
 // New creates a new Balancer based on balancing strategy
@@ -48,3 +63,13 @@ func New(balance string) (Balancer, error) {
 
 	return reflect.New(alg).Elem().Addr().Interface().(Balancer), nil
 }
+
+// hostsID identifies a list of hosts and their weights
+func hostsID(hosts []*Target) string {
+	parts := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		parts = append(parts, host.Target+"|"+strconv.Itoa(host.Weight))
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,119 @@
+package balancer
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConcurrentElect is meant to be run with -race
+func TestConcurrentElect(t *testing.T) {
+	t.Parallel()
+
+	hosts := []*Target{
+		{Target: "http://a.com", Weight: 1},
+		{Target: "http://b.com", Weight: 2},
+		{Target: "http://c.com", Weight: 3},
+	}
+
+	for alg := range typeRegistry {
+		alg := alg
+		t.Run(alg, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := New(alg)
+			require.NoError(t, err)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+
+					ctx := WithHashKey(context.Background(), strconv.Itoa(i))
+					for j := 0; j < 200; j++ {
+						host, err := b.Elect(ctx, hosts)
+						if !assert.NoError(t, err) {
+							return
+						}
+
+						if fb, ok := b.(FeedbackBalancer); ok {
+							fb.Done(host, 0, nil)
+						}
+					}
+				}(i)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func TestRoundRobinConcurrentElectIsEven(t *testing.T) {
+	t.Parallel()
+
+	hosts := []*Target{{Target: "http://a.com"}, {Target: "http://b.com"}, {Target: "http://c.com"}}
+	b := NewRoundrobinBalancer()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		elected = map[string]int{}
+	)
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				host, err := b.Elect(context.Background(), hosts)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				mu.Lock()
+				elected[host.Target]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[string]int{"http://a.com": 1000, "http://b.com": 1000, "http://c.com": 1000}, elected)
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	hosts := []*Target{{Target: "http://a.com"}, {Target: "http://b.com"}}
+	r := NewRegistry()
+
+	b, err := r.Get("test", "", "roundrobin", hosts)
+	require.NoError(t, err)
+
+	// unchanged configuration keeps the balancer and its rotation
+	first, _ := b.Elect(context.Background(), hosts)
+	same, err := r.Get("test", "", "roundrobin", []*Target{{Target: "http://a.com"}, {Target: "http://b.com"}})
+	require.NoError(t, err)
+	assert.True(t, b == same)
+	second, _ := same.Elect(context.Background(), hosts)
+	assert.NotEqual(t, first, second)
+
+	changedTargets, err := r.Get("test", "", "roundrobin", hosts[:1])
+	require.NoError(t, err)
+	assert.False(t, b == changedTargets)
+
+	changedAlg, err := r.Get("test", "", "least_conn", hosts[:1])
+	require.NoError(t, err)
+	assert.IsType(t, &LeastConnBalancer{}, changedAlg)
+
+	_, err = r.Get("another", "", "unknown", hosts)
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+
+	r.Retain([]string{"another"})
+	afterRetain, err := r.Get("test", "", "least_conn", hosts[:1])
+	require.NoError(t, err)
+	assert.False(t, changedAlg == afterRetain)
+}
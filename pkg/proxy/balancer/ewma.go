@@ -0,0 +1,131 @@
+package balancer
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEWMADecay is the default time window the latency average decays over
+	DefaultEWMADecay = 10 * time.Second
+)
+
+type (
+	// EWMABalancer balancer elects targets by their peak exponentially weighted moving average latency
+	// multiplied by their in-flight requests. It compares two random targets and elects the cheapest one
+	// (power of two choices), so a single fast target does not get the whole traffic at once.
+	EWMABalancer struct {
+		// Decay is the time window the latency average decays over
+		Decay time.Duration
+
+		mu    sync.Mutex
+		stats map[string]*ewmaStat
+		now   func() time.Time
+	}
+
+	ewmaStat struct {
+		latency float64 // nanoseconds
+		stamp   time.Time
+		pending int
+	}
+)
+
+// NewEWMABalancer creates a new instance of EWMABalancer
+func NewEWMABalancer() *EWMABalancer {
+	return &EWMABalancer{Decay: DefaultEWMADecay}
+}
+
+// Elect backend using peak EWMA strategy
+func (b *EWMABalancer) Elect(ctx context.Context, hosts []*Target) (*Target, error) {
+	if len(hosts) == 0 {
+		return nil, ErrEmptyBackendList
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	host := hosts[0]
+	if len(hosts) > 1 {
+		i := rand.Intn(len(hosts))
+		j := rand.Intn(len(hosts) - 1)
+		if j >= i {
+			j++
+		}
+
+		host = hosts[i]
+		if b.cost(hosts[j]) < b.cost(host) {
+			host = hosts[j]
+		}
+	}
+
+	b.stat(host.Target).pending++
+
+	return host, nil
+}
+
+// Done records the latency of the finished request. Failed requests are penalised, so a target that
+// fails fast does not look faster than the healthy ones.
+func (b *EWMABalancer) Done(target *Target, rtt time.Duration, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.stat(target.Target)
+	if s.pending > 0 {
+		s.pending--
+	}
+
+	latency := float64(rtt)
+	if err != nil {
+		latency = math.Max(latency, 2*s.latency)
+	}
+
+	now := b.clock()
+	if latency > s.latency {
+		// peak sensitive: latency spikes are taken into account immediately
+		s.latency = latency
+	} else {
+		w := math.Exp(-float64(now.Sub(s.stamp)) / float64(b.decay()))
+		s.latency = s.latency*w + latency*(1-w)
+	}
+	s.stamp = now
+}
+
+// cost must be called holding the lock
+func (b *EWMABalancer) cost(host *Target) float64 {
+	s := b.stat(host.Target)
+	return s.latency * float64(s.pending+1) / float64(weightOf(host))
+}
+
+// stat must be called holding the lock
+func (b *EWMABalancer) stat(target string) *ewmaStat {
+	if b.stats == nil {
+		b.stats = make(map[string]*ewmaStat)
+	}
+
+	s, ok := b.stats[target]
+	if !ok {
+		s = &ewmaStat{stamp: b.clock()}
+		b.stats[target] = s
+	}
+
+	return s
+}
+
+func (b *EWMABalancer) decay() time.Duration {
+	if b.Decay <= 0 {
+		return DefaultEWMADecay
+	}
+
+	return b.Decay
+}
+
+func (b *EWMABalancer) clock() time.Time {
+	if b.now == nil {
+		return time.Now()
+	}
+
+	return b.now()
+}
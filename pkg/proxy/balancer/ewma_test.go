@@ -0,0 +1,86 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type EWMATestSuite struct {
+	suite.Suite
+	hosts []*Target
+}
+
+func (suite *EWMATestSuite) SetupTest() {
+	suite.hosts = []*Target{
+		{Target: "http://fast.com"},
+		{Target: "http://slow.com"},
+	}
+}
+
+func (suite *EWMATestSuite) TestEWMABalancerPrefersFasterTarget() {
+	balancer := NewEWMABalancer()
+	balancer.Done(suite.hosts[0], 10*time.Millisecond, nil)
+	balancer.Done(suite.hosts[1], 500*time.Millisecond, nil)
+
+	for i := 0; i < 10; i++ {
+		electedHost, err := balancer.Elect(context.Background(), suite.hosts)
+		suite.NoError(err)
+		suite.Equal(suite.hosts[0], electedHost)
+		balancer.Done(electedHost, 10*time.Millisecond, nil)
+	}
+}
+
+func (suite *EWMATestSuite) TestEWMABalancerTakesPendingIntoAccount() {
+	balancer := NewEWMABalancer()
+	balancer.Done(suite.hosts[0], 10*time.Millisecond, nil)
+	balancer.Done(suite.hosts[1], 25*time.Millisecond, nil)
+
+	// requests pile up on the fast target until it gets more expensive than the slow one
+	elected := map[string]int{}
+	for i := 0; i < 4; i++ {
+		electedHost, err := balancer.Elect(context.Background(), suite.hosts)
+		suite.NoError(err)
+		elected[electedHost.Target]++
+	}
+
+	suite.Equal(map[string]int{"http://fast.com": 3, "http://slow.com": 1}, elected)
+}
+
+func (suite *EWMATestSuite) TestEWMABalancerDecay() {
+	now := time.Now()
+	balancer := NewEWMABalancer()
+	balancer.now = func() time.Time { return now }
+
+	balancer.Done(suite.hosts[0], time.Second, nil)
+
+	// lower latencies are averaged in over time, peaks are taken immediately
+	now = now.Add(balancer.Decay)
+	balancer.Done(suite.hosts[0], 0, nil)
+	suite.InDelta(float64(time.Second)/2.718281828, balancer.stats["http://fast.com"].latency, float64(time.Millisecond))
+
+	balancer.Done(suite.hosts[0], 2*time.Second, nil)
+	suite.Equal(float64(2*time.Second), balancer.stats["http://fast.com"].latency)
+}
+
+func (suite *EWMATestSuite) TestEWMABalancerPenalisesFailures() {
+	balancer := NewEWMABalancer()
+	balancer.Done(suite.hosts[0], 100*time.Millisecond, nil)
+	balancer.Done(suite.hosts[0], time.Millisecond, errors.New("connection refused"))
+
+	suite.Equal(float64(200*time.Millisecond), balancer.stats["http://fast.com"].latency)
+}
+
+func (suite *EWMATestSuite) TestEWMABalancerEmptyList() {
+	balancer := NewEWMABalancer()
+
+	_, err := balancer.Elect(context.Background(), []*Target{})
+	suite.Error(err)
+}
+
+func TestEWMATestSuite(t *testing.T) {
+	suite.Run(t, new(EWMATestSuite))
+}
@@ -0,0 +1,148 @@
+package balancer
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const (
+	// DefaultHashReplicas is the default number of points every target gets on the hash ring, it is multiplied
+	// by the target weight
+	DefaultHashReplicas = 100
+)
+
+type hashKeyType int
+
+const hashKey hashKeyType = iota
+
+type (
+	// HashBalancer balancer elects targets by consistent hashing of the request hash key, so the requests with
+	// the same key keep going to the same target and adding or removing a target moves as few keys as possible.
+	// Requests without hash key are balanced randomly.
+	HashBalancer struct {
+		// Replicas is the number of points every target gets on the hash ring
+		Replicas int
+
+		mu   sync.RWMutex
+		ring *hashRing
+	}
+
+	hashRing struct {
+		id     string
+		points []uint64
+		hosts  map[uint64]*Target
+	}
+)
+
+// NewHashBalancer creates a new instance of HashBalancer
+func NewHashBalancer() *HashBalancer {
+	return &HashBalancer{Replicas: DefaultHashReplicas}
+}
+
+// WithHashKey returns a copy of the context that carries the hash key of the request
+func WithHashKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKey, key)
+}
+
+// HashKeyFromContext returns the hash key of the request, or an empty string if there is none
+func HashKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(hashKey).(string)
+	return key
+}
+
+// Elect backend using consistent hashing strategy
+func (b *HashBalancer) Elect(ctx context.Context, hosts []*Target) (*Target, error) {
+	if len(hosts) == 0 {
+		return nil, ErrEmptyBackendList
+	}
+
+	key := HashKeyFromContext(ctx)
+	if key == "" {
+		return hosts[rand.Intn(len(hosts))], nil
+	}
+
+	return b.ringFor(hosts).get(hashOf(key)), nil
+}
+
+// ringFor returns the ring of the given hosts, the last ring is cached since the hosts rarely change
+func (b *HashBalancer) ringFor(hosts []*Target) *hashRing {
+	id := hostsID(hosts)
+
+	b.mu.RLock()
+	ring := b.ring
+	b.mu.RUnlock()
+
+	if ring != nil && ring.id == id {
+		return ring
+	}
+
+	ring = newHashRing(id, hosts, b.replicas())
+
+	b.mu.Lock()
+	b.ring = ring
+	b.mu.Unlock()
+
+	return ring
+}
+
+func (b *HashBalancer) replicas() int {
+	if b.Replicas <= 0 {
+		return DefaultHashReplicas
+	}
+
+	return b.Replicas
+}
+
+func newHashRing(id string, hosts []*Target, replicas int) *hashRing {
+	ring := &hashRing{id: id, hosts: make(map[uint64]*Target)}
+
+	for _, host := range hosts {
+		for i := 0; i < replicas*weightOf(host); i++ {
+			point := hashOf(host.Target + "#" + strconv.Itoa(i))
+			if _, ok := ring.hosts[point]; ok {
+				continue
+			}
+
+			ring.hosts[point] = host
+			ring.points = append(ring.points, point)
+		}
+	}
+
+	sort.Slice(ring.points, func(i, j int) bool {
+		return ring.points[i] < ring.points[j]
+	})
+
+	return ring
+}
+
+// get returns the host owning the first point clockwise from the given hash
+func (r *hashRing) get(hash uint64) *Target {
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i] >= hash
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.hosts[r.points[i]]
+}
+
+// hashOf hashes the given string with FNV-1a, mixed with the murmur3 finalizer since FNV alone spreads
+// similar strings (like the points of the same target) poorly over the ring
+func hashOf(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+
+	k := h.Sum64()
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+
+	return k
+}
@@ -0,0 +1,90 @@
+package balancer
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HashTestSuite struct {
+	suite.Suite
+	hosts []*Target
+}
+
+func (suite *HashTestSuite) SetupTest() {
+	suite.hosts = []*Target{
+		{Target: "http://a.com"},
+		{Target: "http://b.com"},
+		{Target: "http://c.com"},
+		{Target: "http://d.com"},
+	}
+}
+
+func (suite *HashTestSuite) TestHashBalancerSameKeySameTarget() {
+	balancer := NewHashBalancer()
+	ctx := WithHashKey(context.Background(), "user-1")
+
+	first, err := balancer.Elect(ctx, suite.hosts)
+	suite.NoError(err)
+
+	for i := 0; i < 10; i++ {
+		electedHost, err := balancer.Elect(ctx, suite.hosts)
+		suite.NoError(err)
+		suite.Equal(first, electedHost)
+	}
+}
+
+func (suite *HashTestSuite) TestHashBalancerSpreadsKeys() {
+	balancer := NewHashBalancer()
+
+	elected := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		electedHost, err := balancer.Elect(WithHashKey(context.Background(), "user-"+strconv.Itoa(i)), suite.hosts)
+		suite.NoError(err)
+		elected[electedHost.Target]++
+	}
+
+	suite.Len(elected, len(suite.hosts))
+	for _, count := range elected {
+		suite.InDelta(250, count, 100)
+	}
+}
+
+func (suite *HashTestSuite) TestHashBalancerRemovingTargetMovesOnlyItsKeys() {
+	balancer := NewHashBalancer()
+	remaining := suite.hosts[:3]
+
+	for i := 0; i < 1000; i++ {
+		ctx := WithHashKey(context.Background(), "user-"+strconv.Itoa(i))
+
+		before, err := balancer.Elect(ctx, suite.hosts)
+		suite.NoError(err)
+		after, err := balancer.Elect(ctx, remaining)
+		suite.NoError(err)
+
+		if before != suite.hosts[3] {
+			suite.Equal(before, after)
+		}
+	}
+}
+
+func (suite *HashTestSuite) TestHashBalancerWithoutKey() {
+	balancer := NewHashBalancer()
+
+	electedHost, err := balancer.Elect(context.Background(), suite.hosts)
+	suite.NoError(err)
+	suite.Contains(suite.hosts, electedHost)
+}
+
+func (suite *HashTestSuite) TestHashBalancerEmptyList() {
+	balancer := NewHashBalancer()
+
+	_, err := balancer.Elect(WithHashKey(context.Background(), "user-1"), []*Target{})
+	suite.Error(err)
+}
+
+func TestHashTestSuite(t *testing.T) {
+	suite.Run(t, new(HashTestSuite))
+}
@@ -0,0 +1,78 @@
+package balancer
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+type (
+	// LeastConnBalancer balancer elects the target with the least in-flight requests relatively to its weight
+	LeastConnBalancer struct {
+		mu     sync.Mutex
+		active map[string]int
+	}
+)
+
+// NewLeastConnBalancer creates a new instance of LeastConnBalancer
+func NewLeastConnBalancer() *LeastConnBalancer {
+	return &LeastConnBalancer{}
+}
+
+// Elect backend using least connections strategy
+func (b *LeastConnBalancer) Elect(ctx context.Context, hosts []*Target) (*Target, error) {
+	if len(hosts) == 0 {
+		return nil, ErrEmptyBackendList
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.active == nil {
+		b.active = make(map[string]int)
+	}
+
+	var (
+		candidates []*Target
+		best       float64
+	)
+	for _, host := range hosts {
+		load := float64(b.active[host.Target]+1) / float64(weightOf(host))
+
+		switch {
+		case len(candidates) == 0 || load < best:
+			best = load
+			candidates = append(candidates[:0], host)
+		case load == best:
+			candidates = append(candidates, host)
+		}
+	}
+
+	// break ties randomly, so idle targets do not always get the first request in the list
+	host := candidates[rand.Intn(len(candidates))]
+	b.active[host.Target]++
+
+	return host, nil
+}
+
+// Done decrements the in-flight requests of the target
+func (b *LeastConnBalancer) Done(target *Target, rtt time.Duration, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.active[target.Target] <= 1 {
+		delete(b.active, target.Target)
+		return
+	}
+
+	b.active[target.Target]--
+}
+
+func weightOf(host *Target) int {
+	if host.Weight <= 0 {
+		return 1
+	}
+
+	return host.Weight
+}
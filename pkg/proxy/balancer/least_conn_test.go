@@ -0,0 +1,63 @@
+package balancer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LeastConnTestSuite struct {
+	suite.Suite
+	hosts []*Target
+}
+
+func (suite *LeastConnTestSuite) SetupTest() {
+	suite.hosts = []*Target{
+		{Target: "127.0.0.1", Weight: 1},
+		{Target: "http://test.com", Weight: 1},
+		{Target: "http://example.com", Weight: 2},
+	}
+}
+
+func (suite *LeastConnTestSuite) TestLeastConnBalancerSuccessfulBalance() {
+	balancer := NewLeastConnBalancer()
+
+	// the heaviest target takes two requests before the others are busier than it is
+	elected := map[string]int{}
+	for i := 0; i < 4; i++ {
+		electedHost, err := balancer.Elect(context.Background(), suite.hosts)
+		suite.NoError(err)
+		elected[electedHost.Target]++
+	}
+
+	suite.Equal(map[string]int{"127.0.0.1": 1, "http://test.com": 1, "http://example.com": 2}, elected)
+}
+
+func (suite *LeastConnTestSuite) TestLeastConnBalancerDone() {
+	balancer := NewLeastConnBalancer()
+	hosts := suite.hosts[:2]
+
+	first, err := balancer.Elect(context.Background(), hosts)
+	suite.NoError(err)
+	second, err := balancer.Elect(context.Background(), hosts)
+	suite.NoError(err)
+	suite.NotEqual(first, second)
+
+	// the first target finished its request, so it is the least loaded one
+	balancer.Done(first, 0, nil)
+	electedHost, err := balancer.Elect(context.Background(), hosts)
+	suite.NoError(err)
+	suite.Equal(first, electedHost)
+}
+
+func (suite *LeastConnTestSuite) TestLeastConnBalancerEmptyList() {
+	balancer := NewLeastConnBalancer()
+
+	_, err := balancer.Elect(context.Background(), []*Target{})
+	suite.Error(err)
+}
+
+func TestLeastConnTestSuite(t *testing.T) {
+	suite.Run(t, new(LeastConnTestSuite))
+}
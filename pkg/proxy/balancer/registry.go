@@ -0,0 +1,67 @@
+package balancer
+
+import "sync"
+
+type (
+	// Registry holds the balancers by API name and upstream group, so their state survives configuration reloads
+	Registry struct {
+		sync.Mutex
+		balancers map[string]map[string]registryEntry
+	}
+
+	registryEntry struct {
+		balance  string
+		hostsID  string
+		balancer Balancer
+	}
+)
+
+// NewRegistry creates a new instance of Registry
+func NewRegistry() *Registry {
+	return &Registry{balancers: make(map[string]map[string]registryEntry)}
+}
+
+// Get returns the balancer of the given API and upstream group, the group is empty for the APIs without groups.
+// The existing balancer is reused as long as the balancing algorithm and the hosts did not change, otherwise
+// a new one is created.
+func (r *Registry) Get(name string, group string, balance string, hosts []*Target) (Balancer, error) {
+	id := hostsID(hosts)
+
+	r.Lock()
+	defer r.Unlock()
+
+	groups, ok := r.balancers[name]
+	if !ok {
+		groups = make(map[string]registryEntry)
+		r.balancers[name] = groups
+	}
+
+	if e, ok := groups[group]; ok && e.balance == balance && e.hostsID == id {
+		return e.balancer, nil
+	}
+
+	b, err := New(balance)
+	if err != nil {
+		return nil, err
+	}
+
+	groups[group] = registryEntry{balance: balance, hostsID: id, balancer: b}
+	return b, nil
+}
+
+// Retain removes the balancers of all the APIs that are not in the given list
+func (r *Registry) Retain(names []string) {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for name := range r.balancers {
+		if !keep[name] {
+			delete(r.balancers, name)
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package balancer
 
-import "sync"
+import (
+	"context"
+	"sync/atomic"
+)
 
 type (
-	// RoundrobinBalancer balancer
+	// RoundrobinBalancer balancer, it is safe for concurrent use
 	RoundrobinBalancer struct {
-		current int // current backend position
-		mu      sync.RWMutex
+		current uint64 // number of elections so far, accessed atomically
 	}
 )
 
@@ -16,20 +18,11 @@ func NewRoundrobinBalancer() *RoundrobinBalancer {
 }
 
 // Elect backend using roundrobin strategy
-func (b *RoundrobinBalancer) Elect(hosts []*Target) (*Target, error) {
+func (b *RoundrobinBalancer) Elect(ctx context.Context, hosts []*Target) (*Target, error) {
 	if len(hosts) == 0 {
 		return nil, ErrEmptyBackendList
 	}
 
-	if b.current >= len(hosts) {
-		b.current = 0
-	}
-
-	host := hosts[b.current]
-
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	b.current++
-
-	return host, nil
+	n := atomic.AddUint64(&b.current, 1)
+	return hosts[(n-1)%uint64(len(hosts))], nil
 }
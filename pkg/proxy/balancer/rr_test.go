@@ -1,6 +1,7 @@
 package balancer
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -22,19 +23,19 @@ func (suite *RoundRobinTestSuite) SetupTest() {
 func (suite *RoundRobinTestSuite) TestRoundRobinBalancerSuccessfulBalance() {
 	balancer := NewRoundrobinBalancer()
 
-	electedHost, err := balancer.Elect(suite.hosts)
+	electedHost, err := balancer.Elect(context.Background(), suite.hosts)
 	suite.NoError(err)
 	suite.Equal(suite.hosts[0], electedHost)
 
-	electedHost, err = balancer.Elect(suite.hosts)
+	electedHost, err = balancer.Elect(context.Background(), suite.hosts)
 	suite.NoError(err)
 	suite.Equal(suite.hosts[1], electedHost)
 
-	electedHost, err = balancer.Elect(suite.hosts)
+	electedHost, err = balancer.Elect(context.Background(), suite.hosts)
 	suite.NoError(err)
 	suite.Equal(suite.hosts[2], electedHost)
 
-	electedHost, err = balancer.Elect(suite.hosts)
+	electedHost, err = balancer.Elect(context.Background(), suite.hosts)
 	suite.NoError(err)
 	suite.Equal(suite.hosts[0], electedHost)
 }
@@ -42,7 +43,7 @@ func (suite *RoundRobinTestSuite) TestRoundRobinBalancerSuccessfulBalance() {
 func (suite *RoundRobinTestSuite) TestRoundRobinBalancerEmptyList() {
 	balancer := NewRoundrobinBalancer()
 
-	_, err := balancer.Elect([]*Target{})
+	_, err := balancer.Elect(context.Background(), []*Target{})
 	suite.Error(err)
 }
 
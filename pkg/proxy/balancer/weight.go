@@ -1,6 +1,7 @@
 package balancer
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 )
@@ -21,7 +22,7 @@ func NewWeightBalancer() *WeightBalancer {
 }
 
 // Elect backend using weight strategy
-func (b *WeightBalancer) Elect(hosts []*Target) (*Target, error) {
+func (b *WeightBalancer) Elect(ctx context.Context, hosts []*Target) (*Target, error) {
 	if len(hosts) == 0 {
 		return nil, ErrEmptyBackendList
 	}
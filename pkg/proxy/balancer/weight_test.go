@@ -1,6 +1,7 @@
 package balancer
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"testing"
@@ -24,7 +25,7 @@ func (suite *WeightBalancerTestSuite) SetupTest() {
 func (suite *WeightBalancerTestSuite) TestWeightBalancer() {
 	balancer := NewWeightBalancer()
 
-	electedHost, err := balancer.Elect(suite.hosts)
+	electedHost, err := balancer.Elect(context.Background(), suite.hosts)
 	suite.NoError(err)
 	suite.NotNil(electedHost)
 }
@@ -32,14 +33,14 @@ func (suite *WeightBalancerTestSuite) TestWeightBalancer() {
 func (suite *WeightBalancerTestSuite) TestWeightBalancerEmptyList() {
 	balancer := NewWeightBalancer()
 
-	_, err := balancer.Elect([]*Target{})
+	_, err := balancer.Elect(context.Background(), []*Target{})
 	suite.Error(err)
 }
 
 func (suite *WeightBalancerTestSuite) TestWeightBalancerZeroWeight() {
 	balancer := NewWeightBalancer()
 
-	_, err := balancer.Elect([]*Target{{Target: "", Weight: 0}})
+	_, err := balancer.Elect(context.Background(), []*Target{{Target: "", Weight: 0}})
 	suite.Error(err)
 }
 
@@ -51,7 +52,7 @@ func (suite *WeightBalancerTestSuite) TestWeightBalancerZeroWeightForOneTarget()
 		{Target: "http://test.com", Weight: 100},
 	}
 
-	electedHost, err := balancer.Elect(hosts)
+	electedHost, err := balancer.Elect(context.Background(), hosts)
 	suite.NoError(err)
 	suite.Equal(hosts[1], electedHost)
 }
@@ -79,7 +80,7 @@ func (suite *WeightBalancerTestSuite) TestWeightBalancerWeight() {
 		elected0 := 0
 		elected1 := 0
 		for i := 0; i < totalSteps; i++ {
-			electedHost, err := balancer.Elect(hosts)
+			electedHost, err := balancer.Elect(context.Background(), hosts)
 			suite.NoError(err)
 
 			if electedHost == hosts[0] {
@@ -10,6 +10,7 @@ import (
 	"github.com/globalsign/mgo/bson"
 	"github.com/hellofresh/janus/pkg/proxy/balancer"
 	"github.com/hellofresh/janus/pkg/router"
+	"github.com/pkg/errors"
 )
 
 // Definition defines proxy rules for a route
@@ -23,18 +24,90 @@ type Definition struct {
 	Methods            []string           `bson:"methods" json:"methods"`
 	Hosts              []string           `bson:"hosts" json:"hosts"`
 	ForwardingTimeouts ForwardingTimeouts `bson:"forwarding_timeouts" json:"forwarding_timeouts" mapstructure:"forwarding_timeouts"`
+	StickySession      StickySession      `bson:"sticky_session" json:"sticky_session" mapstructure:"sticky_session"`
+	Transport          Transport          `bson:"transport" json:"transport" mapstructure:"transport"`
 }
 
 // RouterDefinition represents an API that you want to proxy with internal router routines
 type RouterDefinition struct {
 	*Definition
+	// Name is the name of the API the definition belongs to, it is used to keep per API upstream state
+	Name string
+	// Plugins are the names of the enabled plugins of the API, in the order their middleware run
+	Plugins    []string
 	middleware []router.Constructor
 }
 
 // Upstreams represents a collection of targets where the requests will go to
 type Upstreams struct {
-	Balancing string  `bson:"balancing" json:"balancing"`
-	Targets   Targets `bson:"targets" json:"targets"`
+	Balancing        string           `bson:"balancing" json:"balancing"`
+	Targets          Targets          `bson:"targets" json:"targets"`
+	HealthCheck      HealthCheck      `bson:"health_check" json:"health_check"`
+	OutlierDetection OutlierDetection `bson:"outlier_detection" json:"outlier_detection"`
+	HashOn           HashOn           `bson:"hash_on" json:"hash_on"`
+	Discovery        Discovery        `bson:"discovery" json:"discovery"`
+	Groups           []*UpstreamGroup `bson:"groups" json:"groups"`
+}
+
+// UpstreamGroup is a named set of targets, like stable or canary, that receives a percentage of the traffic.
+// When groups are defined they replace the upstreams targets.
+type UpstreamGroup struct {
+	Name    string   `bson:"name" json:"name" valid:"required~proxy.upstreams.groups.name is required"`
+	Weight  int      `bson:"weight" json:"weight" valid:"range(0|100)"`
+	Targets Targets  `bson:"targets" json:"targets"`
+	Pin     GroupPin `bson:"pin" json:"pin"`
+}
+
+// GroupPin sends the requests with the given header or cookie value to the group, regardless of the weights
+type GroupPin struct {
+	Header string `bson:"header" json:"header"`
+	Cookie string `bson:"cookie" json:"cookie"`
+	// Value is the expected header or cookie value, defaults to "true"
+	Value string `bson:"value" json:"value"`
+}
+
+// Discovery represents the source the upstream targets are discovered from, it replaces the static targets list
+type Discovery struct {
+	// Type is one of dns_srv, dns_a or file
+	Type string `bson:"type" json:"type" valid:"in(dns_srv|dns_a|file)"`
+	// Name is the DNS name to resolve or the path of the targets file
+	Name            string   `bson:"name" json:"name"`
+	Scheme          string   `bson:"scheme" json:"scheme" valid:"in(http|https)"`
+	Port            int      `bson:"port" json:"port" valid:"range(0|65535)"`
+	RefreshInterval Duration `bson:"refresh_interval" json:"refresh_interval"`
+}
+
+// HashOn represents the request value the hash balancer elects the targets by
+type HashOn struct {
+	// Type is one of header, cookie, query, claim or ip
+	Type string `bson:"type" json:"type" valid:"in(header|cookie|query|claim|ip)"`
+	// Name is the name of the header, cookie, query parameter or JWT claim
+	Name string `bson:"name" json:"name"`
+}
+
+// HealthCheck represents the active health check configuration of the upstream targets
+type HealthCheck struct {
+	Path               string      `bson:"path" json:"path" valid:"urlpath"`
+	Interval           Duration    `bson:"interval" json:"interval"`
+	Timeout            Duration    `bson:"timeout" json:"timeout"`
+	HealthyThreshold   int         `bson:"healthy_threshold" json:"healthy_threshold"`
+	UnhealthyThreshold int         `bson:"unhealthy_threshold" json:"unhealthy_threshold"`
+	ExpectedStatuses   StatusRange `bson:"expected_statuses" json:"expected_statuses"`
+}
+
+// OutlierDetection represents the passive health check configuration of the upstream targets, a target that
+// keeps failing proxied requests is ejected for a cooling-off period
+type OutlierDetection struct {
+	Enabled            bool     `bson:"enabled" json:"enabled"`
+	ConsecutiveErrors  int      `bson:"consecutive_errors" json:"consecutive_errors"`
+	EjectionTime       Duration `bson:"ejection_time" json:"ejection_time"`
+	MaxEjectionPercent int      `bson:"max_ejection_percent" json:"max_ejection_percent" valid:"range(0|100)"`
+}
+
+// StatusRange is an inclusive range of HTTP status codes
+type StatusRange struct {
+	Min int `bson:"min" json:"min"`
+	Max int `bson:"max" json:"max"`
 }
 
 // Target is an ip address/hostname with a port that identifies an instance of a backend service
@@ -109,6 +182,41 @@ type ForwardingTimeouts struct {
 	ResponseHeaderTimeout Duration `bson:"response_header_timeout" json:"response_header_timeout"`
 }
 
+// Transport represents the connection settings used to reach the upstream targets
+type Transport struct {
+	// MaxConnsPerHost limits the number of open connections per upstream target, zero means no limit
+	MaxConnsPerHost int `bson:"max_conns_per_host" json:"max_conns_per_host" mapstructure:"max_conns_per_host" valid:"range(0|1000000)"`
+	// MaxIdleConns limits the number of idle connections kept open across all the upstream targets, defaults to 100
+	MaxIdleConns int `bson:"max_idle_conns" json:"max_idle_conns" mapstructure:"max_idle_conns" valid:"range(0|1000000)"`
+	// HTTP2 is one of on, off or h2c, defaults to on
+	HTTP2 string       `bson:"http2" json:"http2" mapstructure:"http2" valid:"in(on|off|h2c)"`
+	TLS   TransportTLS `bson:"tls" json:"tls" mapstructure:"tls"`
+}
+
+// TransportTLS represents the TLS settings used to connect to the upstream targets
+type TransportTLS struct {
+	// CertFile and KeyFile are the client certificate presented to the upstream targets for mutual TLS
+	CertFile string `bson:"cert_file" json:"cert_file" mapstructure:"cert_file"`
+	KeyFile  string `bson:"key_file" json:"key_file" mapstructure:"key_file"`
+	// CAFile is the bundle of CA certificates the upstream certificates are verified with, instead of the system ones
+	CAFile     string `bson:"ca_file" json:"ca_file" mapstructure:"ca_file"`
+	ServerName string `bson:"server_name" json:"server_name" mapstructure:"server_name"`
+	// MinVersion is one of 1.0, 1.1 or 1.2
+	MinVersion string `bson:"min_version" json:"min_version" mapstructure:"min_version" valid:"in(1.0|1.1|1.2)"`
+}
+
+// StickySession represents the cookie based session affinity configuration. The cookie identifies the target
+// elected for the first request, so the following requests go to the same target while it is available.
+type StickySession struct {
+	Enabled    bool     `bson:"enabled" json:"enabled"`
+	CookieName string   `bson:"cookie_name" json:"cookie_name"`
+	TTL        Duration `bson:"ttl" json:"ttl"`
+	Path       string   `bson:"path" json:"path"`
+	Domain     string   `bson:"domain" json:"domain"`
+	Secure     bool     `bson:"secure" json:"secure"`
+	HTTPOnly   bool     `bson:"http_only" json:"http_only"`
+}
+
 // NewDefinition creates a new Proxy Definition with default values
 func NewDefinition() *Definition {
 	return &Definition{
@@ -137,12 +245,119 @@ func (d *RouterDefinition) AddMiddleware(m router.Constructor) {
 
 // Validate validates proxy data
 func (d *Definition) Validate() (bool, error) {
-	return govalidator.ValidateStruct(d)
+	isValid, err := govalidator.ValidateStruct(d)
+	if !isValid || err != nil {
+		return isValid, err
+	}
+
+	if tls := d.Transport.TLS; (tls.CertFile == "") != (tls.KeyFile == "") {
+		return false, errors.New("proxy.transport.tls: cert_file and key_file must be set together")
+	}
+
+	if d.Upstreams != nil {
+		if err := d.Upstreams.validateGroups(); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// KeepGroupTargets copies the targets of the previous groups to the groups of the same name that have no
+// targets, so the group weights can be updated without repeating the targets. A copy of the previous groups is
+// kept when the definition has none, the previous groups are not changed.
+func (d *Definition) KeepGroupTargets(previous []*UpstreamGroup) {
+	if d == nil || d.Upstreams == nil {
+		return
+	}
+
+	if d.Upstreams.Groups == nil {
+		for _, prev := range previous {
+			group := *prev
+			group.Targets = append(Targets(nil), prev.Targets...)
+			d.Upstreams.Groups = append(d.Upstreams.Groups, &group)
+		}
+		return
+	}
+
+	for _, group := range d.Upstreams.Groups {
+		if len(group.Targets) > 0 {
+			continue
+		}
+
+		for _, prev := range previous {
+			if prev.Name == group.Name {
+				group.Targets = append(Targets(nil), prev.Targets...)
+				break
+			}
+		}
+	}
 }
 
 // IsBalancerDefined checks if load balancer is defined
 func (d *Definition) IsBalancerDefined() bool {
-	return d.Upstreams != nil && d.Upstreams.Targets != nil && len(d.Upstreams.Targets) > 0
+	return d.Upstreams != nil && (len(d.Upstreams.Targets) > 0 || len(d.Upstreams.Groups) > 0)
+}
+
+func (u *Upstreams) validateGroups() error {
+	if len(u.Groups) == 0 {
+		return nil
+	}
+
+	if u.Discovery.IsEnabled() {
+		return errors.New("proxy.upstreams.groups: groups can not be used with a discovery")
+	}
+
+	names := make(map[string]bool, len(u.Groups))
+	total := 0
+	for _, group := range u.Groups {
+		if names[group.Name] {
+			return errors.Errorf("proxy.upstreams.groups: duplicated group %q", group.Name)
+		}
+		names[group.Name] = true
+
+		if len(group.Targets) == 0 {
+			return errors.Errorf("proxy.upstreams.groups: group %q has no targets", group.Name)
+		}
+
+		total += group.Weight
+	}
+
+	if total != 100 {
+		return errors.Errorf("proxy.upstreams.groups: weights must add up to 100, got %d", total)
+	}
+
+	return nil
+}
+
+// allTargets returns the targets of all the groups, or the upstreams targets if there are no groups
+func (u *Upstreams) allTargets() Targets {
+	if len(u.Groups) == 0 {
+		return u.Targets
+	}
+
+	var targets Targets
+	seen := make(map[string]bool)
+	for _, group := range u.Groups {
+		for _, target := range group.Targets {
+			if !seen[target.Target] {
+				seen[target.Target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	return targets
+}
+
+// IsEnabled checks if the targets are discovered
+func (d Discovery) IsEnabled() bool {
+	return d.Type != ""
+}
+
+// IsEnabled checks if active health checking is configured
+func (h HealthCheck) IsEnabled() bool {
+	return h.Path != ""
 }
 
 // ToBalancerTargets returns the balancer expected type
@@ -157,6 +372,16 @@ func (t Targets) ToBalancerTargets() []*balancer.Target {
 
 	return balancerTargets
 }
+
+func targetURLs(t []*balancer.Target) []string {
+	targets := make([]string, 0, len(t))
+	for _, target := range t {
+		targets = append(targets, target.Target)
+	}
+
+	return targets
+}
+
 func init() {
 	// initializes custom validators
 	govalidator.CustomTypeTagMap.Set("urlpath", func(i interface{}, o interface{}) bool {
@@ -37,6 +37,14 @@ func TestDefinition(t *testing.T) {
 			scenario: "is balancer defined",
 			function: testIsBalancerDefined,
 		},
+		{
+			scenario: "upstream groups validation",
+			function: testUpstreamGroupsValidation,
+		},
+		{
+			scenario: "transport validation",
+			function: testTransportValidation,
+		},
 		{
 			scenario: "add middleware",
 			function: testAddMiddlewares,
@@ -115,6 +123,75 @@ func testIsBalancerDefined(t *testing.T) {
 	assert.Len(t, definition.Upstreams.Targets.ToBalancerTargets(), 1)
 }
 
+func testUpstreamGroupsValidation(t *testing.T) {
+	newGroupsDefinition := func(groups ...*UpstreamGroup) *Definition {
+		return &Definition{ListenPath: "/*", Upstreams: &Upstreams{Balancing: "roundrobin", Groups: groups}}
+	}
+	targets := Targets{{Target: "http://test.com"}}
+
+	isValid, err := newGroupsDefinition(
+		&UpstreamGroup{Name: "stable", Weight: 90, Targets: targets},
+		&UpstreamGroup{Name: "canary", Weight: 10, Targets: targets},
+	).Validate()
+	assert.NoError(t, err)
+	assert.True(t, isValid)
+
+	invalid := map[string]*Definition{
+		"weights do not add up": newGroupsDefinition(
+			&UpstreamGroup{Name: "stable", Weight: 90, Targets: targets},
+			&UpstreamGroup{Name: "canary", Weight: 20, Targets: targets},
+		),
+		"duplicated group": newGroupsDefinition(
+			&UpstreamGroup{Name: "stable", Weight: 50, Targets: targets},
+			&UpstreamGroup{Name: "stable", Weight: 50, Targets: targets},
+		),
+		"group without targets": newGroupsDefinition(
+			&UpstreamGroup{Name: "stable", Weight: 100},
+		),
+	}
+
+	withDiscovery := newGroupsDefinition(&UpstreamGroup{Name: "stable", Weight: 100, Targets: targets})
+	withDiscovery.Upstreams.Discovery = Discovery{Type: "file", Name: "targets.json"}
+	invalid["groups with a discovery"] = withDiscovery
+
+	for scenario, definition := range invalid {
+		isValid, err := definition.Validate()
+		assert.Error(t, err, scenario)
+		assert.False(t, isValid, scenario)
+	}
+}
+
+func testTransportValidation(t *testing.T) {
+	newTransportDefinition := func(transport Transport) *Definition {
+		return &Definition{
+			ListenPath: "/*",
+			Upstreams:  &Upstreams{Balancing: "roundrobin", Targets: Targets{{Target: "http://test.com"}}},
+			Transport:  transport,
+		}
+	}
+
+	isValid, err := newTransportDefinition(Transport{
+		MaxConnsPerHost: 10,
+		HTTP2:           "h2c",
+		TLS:             TransportTLS{CertFile: "cert.pem", KeyFile: "key.pem", MinVersion: "1.2"},
+	}).Validate()
+	assert.NoError(t, err)
+	assert.True(t, isValid)
+
+	invalid := map[string]*Definition{
+		"unknown HTTP/2 mode":       newTransportDefinition(Transport{HTTP2: "h3"}),
+		"unknown TLS version":       newTransportDefinition(Transport{TLS: TransportTLS{MinVersion: "1.4"}}),
+		"certificate without key":   newTransportDefinition(Transport{TLS: TransportTLS{CertFile: "cert.pem"}}),
+		"negative max connections":  newTransportDefinition(Transport{MaxConnsPerHost: -1}),
+		"negative idle connections": newTransportDefinition(Transport{MaxIdleConns: -1}),
+	}
+	for scenario, definition := range invalid {
+		isValid, err := definition.Validate()
+		assert.Error(t, err, scenario)
+		assert.False(t, isValid, scenario)
+	}
+}
+
 func testAddMiddlewares(t *testing.T) {
 	routerDefinition := NewRouterDefinition(NewDefinition())
 	routerDefinition.AddMiddleware(middleware.NewLogger().Handler)
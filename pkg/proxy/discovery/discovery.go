@@ -0,0 +1,98 @@
+// Package discovery resolves the upstream targets of an API from a discovery source, like DNS records or a
+// watched targets file, and keeps them up to date
+package discovery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+)
+
+const (
+	// TypeDNSSRV resolves the targets from DNS SRV records
+	TypeDNSSRV = "dns_srv"
+	// TypeDNSA resolves the targets from DNS A/AAAA records
+	TypeDNSA = "dns_a"
+	// TypeFile reads the targets from a JSON or YAML file
+	TypeFile = "file"
+
+	// DefaultRefreshInterval is the default time between two resolutions of the targets
+	DefaultRefreshInterval = 30 * time.Second
+	// DefaultScheme is the default scheme of the targets resolved from DNS records
+	DefaultScheme = "http"
+)
+
+var (
+	// ErrUnsupportedType is used when an unsupported discovery type is given
+	ErrUnsupportedType = errors.New("unsupported discovery type")
+	// ErrNoTargets is used when a discovery source resolves no targets
+	ErrNoTargets = errors.New("no targets discovered")
+)
+
+type (
+	// Config represents the discovery source configuration
+	Config struct {
+		// Type is one of dns_srv, dns_a or file
+		Type string
+		// Name is the DNS name to resolve or the path of the targets file
+		Name string
+		// Scheme is the scheme of the targets resolved from DNS records
+		Scheme string
+		// Port is the port of the targets resolved from DNS A records
+		Port            int
+		RefreshInterval time.Duration
+	}
+
+	// Source provides the current upstream targets of an API
+	Source interface {
+		Targets() []*balancer.Target
+	}
+
+	// Static is a Source of a fixed list of targets
+	Static []*balancer.Target
+
+	// Resolver resolves the targets of a discovery source
+	Resolver interface {
+		Resolve(ctx context.Context) ([]*balancer.Target, error)
+	}
+
+	// Notifier is implemented by the resolvers that know when their targets changed, so they do not have to wait
+	// for the next refresh
+	Notifier interface {
+		Changes() <-chan struct{}
+		Close() error
+	}
+)
+
+// Targets returns the static targets
+func (s Static) Targets() []*balancer.Target {
+	return s
+}
+
+// NewResolver creates the resolver of the configured discovery type
+func NewResolver(config Config) (Resolver, error) {
+	config = config.withDefaults()
+
+	switch config.Type {
+	case TypeDNSSRV, TypeDNSA:
+		return NewDNSResolver(config), nil
+	case TypeFile:
+		return NewFileResolver(config.Name)
+	default:
+		return nil, ErrUnsupportedType
+	}
+}
+
+func (c Config) withDefaults() Config {
+	if c.RefreshInterval <= 0 {
+		c.RefreshInterval = DefaultRefreshInterval
+	}
+
+	if c.Scheme == "" {
+		c.Scheme = DefaultScheme
+	}
+
+	return c
+}
@@ -0,0 +1,82 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+)
+
+type dnsLookup interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// DNSResolver resolves the targets from DNS SRV or A/AAAA records
+type DNSResolver struct {
+	config Config
+	lookup dnsLookup
+}
+
+// NewDNSResolver creates a new instance of DNSResolver that uses the system resolver
+func NewDNSResolver(config Config) *DNSResolver {
+	return &DNSResolver{config: config.withDefaults(), lookup: net.DefaultResolver}
+}
+
+// Resolve resolves the targets, SRV records keep their port and weight while A records use the configured port
+func (r *DNSResolver) Resolve(ctx context.Context) ([]*balancer.Target, error) {
+	if r.config.Type == TypeDNSSRV {
+		return r.resolveSRV(ctx)
+	}
+
+	return r.resolveHost(ctx)
+}
+
+func (r *DNSResolver) resolveSRV(ctx context.Context) ([]*balancer.Target, error) {
+	_, records, err := r.lookup.LookupSRV(ctx, "", "", r.config.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]*balancer.Target, 0, len(records))
+	for _, record := range records {
+		weight := int(record.Weight)
+		if weight == 0 {
+			weight = 1
+		}
+
+		host := strings.TrimSuffix(record.Target, ".")
+		targets = append(targets, &balancer.Target{
+			Target: r.url(host, int(record.Port)),
+			Weight: weight,
+		})
+	}
+
+	return targets, nil
+}
+
+func (r *DNSResolver) resolveHost(ctx context.Context) ([]*balancer.Target, error) {
+	addrs, err := r.lookup.LookupHost(ctx, r.config.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]*balancer.Target, 0, len(addrs))
+	for _, addr := range addrs {
+		targets = append(targets, &balancer.Target{Target: r.url(addr, r.config.Port), Weight: 1})
+	}
+
+	return targets, nil
+}
+
+func (r *DNSResolver) url(host string, port int) string {
+	if port > 0 {
+		host = net.JoinHostPort(host, strconv.Itoa(port))
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	return r.config.Scheme + "://" + host
+}
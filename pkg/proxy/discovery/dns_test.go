@@ -0,0 +1,99 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubDNS struct {
+	srv   map[string][]*net.SRV
+	hosts map[string][]string
+}
+
+func (s stubDNS) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	records, ok := s.srv[name]
+	if !ok {
+		return "", nil, errors.New("no such host")
+	}
+	return name, records, nil
+}
+
+func (s stubDNS) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, ok := s.hosts[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addrs, nil
+}
+
+func TestDNSResolver(t *testing.T) {
+	t.Parallel()
+
+	dns := stubDNS{
+		srv: map[string][]*net.SRV{
+			"_http._tcp.my-api.service.consul": {
+				{Target: "node1.my-api.service.consul.", Port: 8080, Weight: 10},
+				{Target: "node2.my-api.service.consul.", Port: 8081},
+			},
+		},
+		hosts: map[string][]string{
+			"my-api.internal": {"10.0.0.1", "fd00::1"},
+		},
+	}
+
+	tests := []struct {
+		scenario string
+		config   Config
+		expected []*balancer.Target
+	}{
+		{
+			scenario: "srv records",
+			config:   Config{Type: TypeDNSSRV, Name: "_http._tcp.my-api.service.consul"},
+			expected: []*balancer.Target{
+				{Target: "http://node1.my-api.service.consul:8080", Weight: 10},
+				{Target: "http://node2.my-api.service.consul:8081", Weight: 1},
+			},
+		},
+		{
+			scenario: "a records with port",
+			config:   Config{Type: TypeDNSA, Name: "my-api.internal", Scheme: "https", Port: 8443},
+			expected: []*balancer.Target{
+				{Target: "https://10.0.0.1:8443", Weight: 1},
+				{Target: "https://[fd00::1]:8443", Weight: 1},
+			},
+		},
+		{
+			scenario: "a records without port",
+			config:   Config{Type: TypeDNSA, Name: "my-api.internal"},
+			expected: []*balancer.Target{
+				{Target: "http://10.0.0.1", Weight: 1},
+				{Target: "http://[fd00::1]", Weight: 1},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			r := NewDNSResolver(test.config)
+			r.lookup = dns
+
+			targets, err := r.Resolve(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, targets)
+		})
+	}
+
+	t.Run("unknown name", func(t *testing.T) {
+		r := NewDNSResolver(Config{Type: TypeDNSSRV, Name: "unknown"})
+		r.lookup = dns
+
+		_, err := r.Resolve(context.Background())
+		assert.Error(t, err)
+	})
+}
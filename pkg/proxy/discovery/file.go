@@ -0,0 +1,115 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// fileTarget is a target of the targets file
+type fileTarget struct {
+	Target string `json:"target" yaml:"target"`
+	Weight int    `json:"weight" yaml:"weight"`
+}
+
+// FileResolver reads the targets from a JSON or YAML file, that holds a list of objects with target and weight.
+// The file is watched, so the changes are picked up right away.
+type FileResolver struct {
+	path    string
+	watcher *fsnotify.Watcher
+	changes chan struct{}
+}
+
+// NewFileResolver creates a new instance of FileResolver
+func NewFileResolver(path string) (*FileResolver, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a file system watcher")
+	}
+
+	// the directory is watched instead of the file, so files replaced by a rename are still followed
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, errors.Wrap(err, "failed to watch the targets file")
+	}
+
+	r := &FileResolver{path: path, watcher: watcher, changes: make(chan struct{}, 1)}
+	go r.watch()
+
+	return r, nil
+}
+
+// Resolve reads the targets from the file
+func (r *FileResolver) Resolve(ctx context.Context) ([]*balancer.Target, error) {
+	body, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileTargets []fileTarget
+	switch strings.ToLower(filepath.Ext(r.path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(body, &fileTargets)
+	default:
+		err = json.Unmarshal(body, &fileTargets)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the targets file")
+	}
+
+	targets := make([]*balancer.Target, 0, len(fileTargets))
+	for _, t := range fileTargets {
+		if t.Target == "" {
+			continue
+		}
+
+		targets = append(targets, &balancer.Target{Target: t.Target, Weight: t.Weight})
+	}
+
+	return targets, nil
+}
+
+// Changes returns the channel that receives a value when the targets file changed
+func (r *FileResolver) Changes() <-chan struct{} {
+	return r.changes
+}
+
+// Close stops watching the targets file
+func (r *FileResolver) Close() error {
+	return r.watcher.Close()
+}
+
+func (r *FileResolver) watch() {
+	name := filepath.Clean(r.path)
+
+	for {
+		select {
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if filepath.Clean(event.Name) != name || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+
+			select {
+			case r.changes <- struct{}{}:
+			default:
+			}
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.WithError(err).WithField("path", r.path).Error("error received from file system notify")
+		}
+	}
+}
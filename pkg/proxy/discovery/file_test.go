@@ -0,0 +1,115 @@
+package discovery
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileResolver(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "janus-discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		scenario string
+		file     string
+		content  string
+	}{
+		{
+			scenario: "json file",
+			file:     "targets.json",
+			content:  `[{"target": "http://10.0.0.1:8080", "weight": 2}, {"target": "http://10.0.0.2:8080"}]`,
+		},
+		{
+			scenario: "yaml file",
+			file:     "targets.yaml",
+			content:  "- target: http://10.0.0.1:8080\n  weight: 2\n- target: http://10.0.0.2:8080\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			path := filepath.Join(dir, test.file)
+			require.NoError(t, ioutil.WriteFile(path, []byte(test.content), 0644))
+
+			r, err := NewFileResolver(path)
+			require.NoError(t, err)
+			defer r.Close()
+
+			targets, err := r.Resolve(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, []*balancer.Target{
+				{Target: "http://10.0.0.1:8080", Weight: 2},
+				{Target: "http://10.0.0.2:8080"},
+			}, targets)
+		})
+	}
+
+	t.Run("invalid file", func(t *testing.T) {
+		path := filepath.Join(dir, "invalid.json")
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{"target": `), 0644))
+
+		r, err := NewFileResolver(path)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Resolve(context.Background())
+		assert.Error(t, err)
+	})
+}
+
+func TestWatcherFollowsFileChanges(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "janus-discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "targets.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`[{"target": "http://10.0.0.1"}]`), 0644))
+
+	r := NewRegistry()
+	defer r.Retain(nil)
+
+	w, err := r.Set("test", Config{Type: TypeFile, Name: path, RefreshInterval: time.Hour}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []*balancer.Target{{Target: "http://10.0.0.1"}}, w.Targets())
+
+	updates := make(chan []*balancer.Target, 1)
+	w.OnUpdate(func(targets []*balancer.Target) {
+		updates <- targets
+	})
+
+	// an empty targets file keeps the last known targets
+	require.NoError(t, ioutil.WriteFile(path, []byte(`[]`), 0644))
+	w.Refresh()
+	assert.Equal(t, []*balancer.Target{{Target: "http://10.0.0.1"}}, w.Targets())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`[{"target": "http://10.0.0.1"}, {"target": "http://10.0.0.2"}]`), 0644))
+
+	select {
+	case targets := <-updates:
+		assert.Len(t, targets, 2)
+	case <-time.After(5 * time.Second):
+		t.Fatal("targets file change was not picked up")
+	}
+	assert.Len(t, w.Targets(), 2)
+
+	// same configuration reuses the running watcher
+	same, err := r.Set("test", Config{Type: TypeFile, Name: path, RefreshInterval: time.Hour}, nil)
+	require.NoError(t, err)
+	assert.True(t, w == same)
+
+	_, err = r.Set("another", Config{Type: "consul"}, nil)
+	assert.Equal(t, ErrUnsupportedType, err)
+}
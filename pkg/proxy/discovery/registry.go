@@ -0,0 +1,85 @@
+package discovery
+
+import (
+	"sync"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+)
+
+// Registry holds the running discovery watchers by API name
+type Registry struct {
+	sync.RWMutex
+	watchers map[string]*Watcher
+}
+
+// NewRegistry creates a new instance of Registry
+func NewRegistry() *Registry {
+	return &Registry{watchers: make(map[string]*Watcher)}
+}
+
+// Set returns a running watcher for the given API. A watcher that is already running with the same
+// configuration is reused, so the discovered targets survive configuration reloads. The static targets are
+// balanced until the first successful resolution.
+func (r *Registry) Set(name string, config Config, static []*balancer.Target) (*Watcher, error) {
+	if w, ok := r.Get(name); ok && w.Config() == config.withDefaults() {
+		return w, nil
+	}
+
+	resolver, err := NewResolver(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// the first resolution can take up to the resolve timeout, the registry is not locked meanwhile
+	w := NewWatcher(name, config, resolver, static)
+	w.Start()
+
+	r.Lock()
+	previous, ok := r.watchers[name]
+	r.watchers[name] = w
+	r.Unlock()
+
+	if ok {
+		previous.Stop()
+	}
+
+	return w, nil
+}
+
+// Get returns the watcher of the given API
+func (r *Registry) Get(name string) (*Watcher, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	w, ok := r.watchers[name]
+	return w, ok
+}
+
+// Remove stops and removes the watcher of the given API
+func (r *Registry) Remove(name string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if w, ok := r.watchers[name]; ok {
+		w.Stop()
+		delete(r.watchers, name)
+	}
+}
+
+// Retain stops and removes the watchers of all the APIs that are not in the given list
+func (r *Registry) Retain(names []string) {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for name, w := range r.watchers {
+		if !keep[name] {
+			w.Stop()
+			delete(r.watchers, name)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package discovery
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	log "github.com/sirupsen/logrus"
+)
+
+// resolveTimeout is the timeout of a single resolution of the targets
+const resolveTimeout = 5 * time.Second
+
+// Watcher periodically resolves the targets of an API and keeps the last successfully resolved ones, so the
+// balanced targets are updated without rebuilding the router. Failed or empty resolutions keep the last targets,
+// the initial ones until the first successful resolution.
+type Watcher struct {
+	name     string
+	config   Config
+	resolver Resolver
+
+	mu       sync.RWMutex
+	targets  []*balancer.Target
+	onUpdate func([]*balancer.Target)
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewWatcher creates a new instance of Watcher, the initial targets are used until the targets are resolved
+func NewWatcher(name string, config Config, resolver Resolver, initial []*balancer.Target) *Watcher {
+	return &Watcher{
+		name:     name,
+		config:   config.withDefaults(),
+		resolver: resolver,
+		targets:  initial,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start resolves the targets once and keeps refreshing them in background
+func (w *Watcher) Start() {
+	w.Refresh()
+
+	go func() {
+		ticker := time.NewTicker(w.config.RefreshInterval)
+		defer ticker.Stop()
+
+		var changes <-chan struct{}
+		if n, ok := w.resolver.(Notifier); ok {
+			changes = n.Changes()
+		}
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-changes:
+			case <-w.stop:
+				return
+			}
+
+			w.Refresh()
+		}
+	}()
+}
+
+// Stop stops refreshing the targets
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+
+		if n, ok := w.resolver.(Notifier); ok {
+			if err := n.Close(); err != nil {
+				log.WithError(err).WithField("api_name", w.name).Debug("Failed to close the discovery notifier")
+			}
+		}
+	})
+}
+
+// Config returns the configuration the watcher was created with
+func (w *Watcher) Config() Config {
+	return w.config
+}
+
+// Targets returns the last resolved targets
+func (w *Watcher) Targets() []*balancer.Target {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.targets
+}
+
+// OnUpdate sets the function called with the new targets every time they change
+func (w *Watcher) OnUpdate(fn func([]*balancer.Target)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.onUpdate = fn
+}
+
+// Refresh resolves the targets
+func (w *Watcher) Refresh() {
+	logger := log.WithFields(log.Fields{"api_name": w.name, "discovery": w.config.Type, "name": w.config.Name})
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	targets, err := w.resolver.Resolve(ctx)
+	if err == nil && len(targets) == 0 {
+		err = ErrNoTargets
+	}
+	if err != nil {
+		logger.WithError(err).Error("Could not discover the upstream targets, keeping the last known ones")
+		return
+	}
+
+	w.mu.Lock()
+	if sameTargets(w.targets, targets) {
+		w.mu.Unlock()
+		return
+	}
+	w.targets = targets
+	onUpdate := w.onUpdate
+	w.mu.Unlock()
+
+	logger.WithField("targets", len(targets)).Info("Upstream targets discovered")
+	if onUpdate != nil {
+		onUpdate(targets)
+	}
+}
+
+// sameTargets compares the targets whatever their order, DNS answers are not returned in a stable order
+func sameTargets(a, b []*balancer.Target) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA, sortedB := sortedTargets(a), sortedTargets(b)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortedTargets(targets []*balancer.Target) []balancer.Target {
+	sorted := make([]balancer.Target, len(targets))
+	for i, t := range targets {
+		sorted[i] = *t
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Target != sorted[j].Target {
+			return sorted[i].Target < sorted[j].Target
+		}
+		return sorted[i].Weight < sorted[j].Weight
+	})
+
+	return sorted
+}
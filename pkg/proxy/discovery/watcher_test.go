@@ -0,0 +1,75 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/stretchr/testify/assert"
+)
+
+type resolverFunc func(ctx context.Context) ([]*balancer.Target, error)
+
+func (f resolverFunc) Resolve(ctx context.Context) ([]*balancer.Target, error) {
+	return f(ctx)
+}
+
+func TestWatcherKeepsStaticTargetsUntilResolved(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var resolved []*balancer.Target
+	resolver := resolverFunc(func(ctx context.Context) ([]*balancer.Target, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if resolved == nil {
+			return nil, errors.New("no such host")
+		}
+		return resolved, nil
+	})
+
+	static := []*balancer.Target{{Target: "http://static"}}
+	w := NewWatcher("test", Config{Type: TypeDNSA, Name: "example.com", RefreshInterval: time.Hour}, resolver, static)
+	w.Start()
+	defer w.Stop()
+	assert.Equal(t, static, w.Targets(), "the static targets are balanced until the first successful resolution")
+
+	mu.Lock()
+	resolved = []*balancer.Target{{Target: "http://10.0.0.1"}}
+	mu.Unlock()
+	w.Refresh()
+	assert.Equal(t, resolved, w.Targets())
+}
+
+func TestWatcherIgnoresTargetsOrder(t *testing.T) {
+	t.Parallel()
+
+	answers := [][]*balancer.Target{
+		{{Target: "http://10.0.0.1", Weight: 1}, {Target: "http://10.0.0.2", Weight: 2}},
+		{{Target: "http://10.0.0.2", Weight: 2}, {Target: "http://10.0.0.1", Weight: 1}},
+		{{Target: "http://10.0.0.2", Weight: 1}, {Target: "http://10.0.0.1", Weight: 2}},
+	}
+	i := 0
+	resolver := resolverFunc(func(ctx context.Context) ([]*balancer.Target, error) {
+		answer := answers[i]
+		i++
+		return answer, nil
+	})
+
+	w := NewWatcher("test", Config{Type: TypeDNSSRV, Name: "example.com", RefreshInterval: time.Hour}, resolver, nil)
+	updates := 0
+	w.OnUpdate(func([]*balancer.Target) {
+		updates++
+	})
+
+	w.Refresh()
+	w.Refresh()
+	assert.Equal(t, 1, updates, "the same targets in another order are not an update")
+
+	w.Refresh()
+	assert.Equal(t, 2, updates, "the weights are part of the targets")
+}
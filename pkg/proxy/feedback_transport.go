@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/hellofresh/janus/pkg/proxy/health"
+)
+
+// feedbackTransport reports the outcome of every proxied request to the outlier detector and to the balancers
+// that learn from it.
+// Transport errors and 5xx responses count as failures for the outlier detector, requests cancelled by the client
+// or rejected by a target guard are ignored. The balancer is told that the request it elected is done once the response body is closed.
+type feedbackTransport struct {
+	base     http.RoundTripper
+	detector *health.OutlierDetector
+	balancer balancer.FeedbackBalancer
+}
+
+func newFeedbackTransport(base http.RoundTripper, detector *health.OutlierDetector, b balancer.Balancer) http.RoundTripper {
+	fb, _ := b.(balancer.FeedbackBalancer)
+	if detector == nil && fb == nil {
+		return base
+	}
+
+	return &feedbackTransport{base: base, detector: detector, balancer: fb}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *feedbackTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := targetFromContext(req.Context())
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	rtt := time.Since(start)
+
+	if target == nil {
+		return resp, err
+	}
+
+	// requests rejected by a target guard never reached the target
+	_, rejected := err.(rejectedError)
+	if t.detector != nil && req.Context().Err() == nil && !rejected {
+		success := err == nil && resp.StatusCode < http.StatusInternalServerError
+		t.detector.Observe(target.Target, success)
+	}
+
+	// requests sent to the sticky session target were not elected by the balancer
+	if t.balancer != nil && !isStickyFromContext(req.Context()) {
+		done := func() { t.balancer.Done(target, rtt, err) }
+		if err != nil || resp.Body == nil || resp.StatusCode == http.StatusSwitchingProtocols {
+			done()
+		} else {
+			resp.Body = &doneBody{ReadCloser: resp.Body, done: done}
+		}
+	}
+
+	return resp, err
+}
+
+// doneBody calls done once, when the response body is closed
+type doneBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+// Close implements io.Closer
+func (b *doneBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/hellofresh/janus/pkg/proxy/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type feedbackRecorder struct {
+	balancer.Balancer
+	done []string
+}
+
+func (r *feedbackRecorder) Done(target *balancer.Target, rtt time.Duration, err error) {
+	r.done = append(r.done, target.Target)
+}
+
+func TestFeedbackTransportOutlierDetection(t *testing.T) {
+	t.Parallel()
+
+	detector := health.NewOutlierDetector("test", health.OutlierConfig{ConsecutiveErrors: 2}, []string{"http://a", "http://b"})
+
+	failing := newFeedbackTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "a" {
+			return &http.Response{StatusCode: http.StatusBadGateway}, nil
+		}
+		return nil, errors.New("connection refused")
+	}), detector, balancer.NewRoundrobinBalancer())
+
+	for _, target := range []string{"http://a", "http://a", "http://b"} {
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		failing.RoundTrip(withTarget(req, target))
+	}
+
+	assert.False(t, detector.IsHealthy("http://a"))
+	assert.True(t, detector.IsHealthy("http://b"))
+
+	// requests cancelled by the client are not counted
+	req, _ := http.NewRequest(http.MethodGet, "http://b", nil)
+	ctx, cancel := context.WithCancel(withTarget(req, "http://b").Context())
+	cancel()
+	failing.RoundTrip(req.WithContext(ctx))
+	assert.True(t, detector.IsHealthy("http://b"))
+
+	assert.Equal(t, http.DefaultTransport, newFeedbackTransport(http.DefaultTransport, nil, balancer.NewRoundrobinBalancer()))
+}
+
+func TestFeedbackTransportBalancerFeedback(t *testing.T) {
+	t.Parallel()
+
+	recorder := &feedbackRecorder{Balancer: balancer.NewRoundrobinBalancer()}
+	rt := newFeedbackTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "b" {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("ok"))}, nil
+	}), nil, recorder)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://a", nil)
+	resp, err := rt.RoundTrip(withTarget(req, "http://a"))
+	require.NoError(t, err)
+
+	// the request is done only once the response body is closed
+	assert.Empty(t, recorder.done)
+	resp.Body.Close()
+	resp.Body.Close()
+	assert.Equal(t, []string{"http://a"}, recorder.done)
+
+	req, _ = http.NewRequest(http.MethodGet, "http://b", nil)
+	_, err = rt.RoundTrip(withTarget(req, "http://b"))
+	require.Error(t, err)
+	assert.Equal(t, []string{"http://a", "http://b"}, recorder.done)
+}
+
+func withTarget(req *http.Request, target string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), targetKey, &balancer.Target{Target: target}))
+}
@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+)
+
+const defaultGroupPinValue = "true"
+
+type (
+	// groupsBalancer splits the traffic between the upstream groups by their weights. The requests pinned to a
+	// group always go to it. The targets of the elected group are balanced by the group own balancer.
+	groupsBalancer struct {
+		groups []*balancedGroup
+	}
+
+	balancedGroup struct {
+		*UpstreamGroup
+		balancer balancer.Balancer
+		targets  map[string]bool
+	}
+)
+
+func newGroupsBalancer(groups []*UpstreamGroup, balancers []balancer.Balancer) *groupsBalancer {
+	b := &groupsBalancer{}
+	for i, group := range groups {
+		targets := make(map[string]bool, len(group.Targets))
+		for _, target := range group.Targets {
+			targets[target.Target] = true
+		}
+
+		b.groups = append(b.groups, &balancedGroup{UpstreamGroup: group, balancer: balancers[i], targets: targets})
+	}
+
+	return b
+}
+
+// Elect elects the group of the request and one of its hosts. If the group has none of the given (healthy) hosts,
+// the other groups are tried in order.
+func (b *groupsBalancer) Elect(ctx context.Context, hosts []*balancer.Target) (*balancer.Target, error) {
+	if len(hosts) == 0 {
+		return nil, balancer.ErrEmptyBackendList
+	}
+
+	elected := b.pick(ctx)
+	for _, group := range append([]*balancedGroup{elected}, b.groups...) {
+		if groupHosts := group.filter(hosts); len(groupHosts) > 0 {
+			return group.balancer.Elect(ctx, groupHosts)
+		}
+	}
+
+	return nil, balancer.ErrCannotElectBackend
+}
+
+// Done passes the request feedback to the balancer of the group the target belongs to
+func (b *groupsBalancer) Done(target *balancer.Target, rtt time.Duration, err error) {
+	for _, group := range b.groups {
+		if !group.targets[target.Target] {
+			continue
+		}
+
+		if fb, ok := group.balancer.(balancer.FeedbackBalancer); ok {
+			fb.Done(target, rtt, err)
+		}
+		return
+	}
+}
+
+func (b *groupsBalancer) pick(ctx context.Context) *balancedGroup {
+	if pinned, ok := ctx.Value(groupKey).(string); ok {
+		for _, group := range b.groups {
+			if group.Name == pinned {
+				return group
+			}
+		}
+	}
+
+	r := rand.Intn(100)
+	for _, group := range b.groups {
+		r -= group.Weight
+		if r < 0 {
+			return group
+		}
+	}
+
+	return b.groups[0]
+}
+
+func (g *balancedGroup) filter(hosts []*balancer.Target) []*balancer.Target {
+	var filtered []*balancer.Target
+	for _, host := range hosts {
+		if g.targets[host.Target] {
+			filtered = append(filtered, host)
+		}
+	}
+
+	return filtered
+}
+
+// pinnedGroup returns the name of the first group the request is pinned to by header or cookie
+func pinnedGroup(req *http.Request, groups []*UpstreamGroup) string {
+	for _, group := range groups {
+		if group.Pin.matches(req) {
+			return group.Name
+		}
+	}
+
+	return ""
+}
+
+func (p GroupPin) matches(req *http.Request) bool {
+	expected := p.Value
+	if expected == "" {
+		expected = defaultGroupPinValue
+	}
+
+	if p.Header != "" && req.Header.Get(p.Header) == expected {
+		return true
+	}
+
+	if p.Cookie != "" {
+		if cookie, err := req.Cookie(p.Cookie); err == nil && cookie.Value == expected {
+			return true
+		}
+	}
+
+	return false
+}
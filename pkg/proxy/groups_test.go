@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupsBalancer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scenario string
+		function func(*testing.T)
+	}{
+		{
+			scenario: "traffic is split by weight",
+			function: testGroupsSplitByWeight,
+		},
+		{
+			scenario: "pinned requests go to the group",
+			function: testGroupsPinned,
+		},
+		{
+			scenario: "group without healthy hosts falls back to the others",
+			function: testGroupsFallBack,
+		},
+		{
+			scenario: "feedback goes to the group balancer",
+			function: testGroupsFeedback,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			test.function(t)
+		})
+	}
+}
+
+func newTestGroups(stableWeight int) []*UpstreamGroup {
+	return []*UpstreamGroup{
+		{Name: "stable", Weight: stableWeight, Targets: Targets{{Target: "http://stable1"}, {Target: "http://stable2"}}},
+		{Name: "canary", Weight: 100 - stableWeight, Targets: Targets{{Target: "http://canary"}}, Pin: GroupPin{Header: "X-Canary", Cookie: "canary"}},
+	}
+}
+
+func newTestGroupsBalancer(groups []*UpstreamGroup) *groupsBalancer {
+	return newGroupsBalancer(groups, []balancer.Balancer{balancer.NewRoundrobinBalancer(), balancer.NewLeastConnBalancer()})
+}
+
+func allTestTargets(groups []*UpstreamGroup) []*balancer.Target {
+	return (&Upstreams{Groups: groups}).allTargets().ToBalancerTargets()
+}
+
+func testGroupsSplitByWeight(t *testing.T) {
+	groups := newTestGroups(80)
+	b := newTestGroupsBalancer(groups)
+
+	canary := 0
+	for i := 0; i < 10000; i++ {
+		target, err := b.Elect(context.Background(), allTestTargets(groups))
+		require.NoError(t, err)
+		if target.Target == "http://canary" {
+			canary++
+			b.Done(target, 0, nil)
+		}
+	}
+
+	assert.InDelta(t, 2000, canary, 300)
+}
+
+func testGroupsPinned(t *testing.T) {
+	groups := newTestGroups(100)
+	b := newTestGroupsBalancer(groups)
+
+	header, _ := http.NewRequest(http.MethodGet, "/", nil)
+	header.Header.Set("X-Canary", "true")
+
+	cookie, _ := http.NewRequest(http.MethodGet, "/", nil)
+	cookie.AddCookie(&http.Cookie{Name: "canary", Value: "true"})
+
+	other, _ := http.NewRequest(http.MethodGet, "/", nil)
+	other.Header.Set("X-Canary", "false")
+
+	assert.Equal(t, "canary", pinnedGroup(header, groups))
+	assert.Equal(t, "canary", pinnedGroup(cookie, groups))
+	assert.Equal(t, "", pinnedGroup(other, groups))
+
+	ctx := context.WithValue(context.Background(), groupKey, "canary")
+	target, err := b.Elect(ctx, allTestTargets(groups))
+	require.NoError(t, err)
+	assert.Equal(t, "http://canary", target.Target)
+}
+
+func testGroupsFallBack(t *testing.T) {
+	groups := newTestGroups(0)
+	b := newTestGroupsBalancer(groups)
+
+	healthy := []*balancer.Target{{Target: "http://stable2"}}
+	target, err := b.Elect(context.Background(), healthy)
+	require.NoError(t, err)
+	assert.Equal(t, "http://stable2", target.Target)
+
+	_, err = b.Elect(context.Background(), []*balancer.Target{{Target: "http://unknown"}})
+	assert.Equal(t, balancer.ErrCannotElectBackend, err)
+}
+
+func testGroupsFeedback(t *testing.T) {
+	stable := &feedbackRecorder{Balancer: balancer.NewRoundrobinBalancer()}
+	canary := &feedbackRecorder{Balancer: balancer.NewRoundrobinBalancer()}
+	b := newGroupsBalancer(newTestGroups(50), []balancer.Balancer{stable, canary})
+
+	b.Done(&balancer.Target{Target: "http://canary"}, 0, nil)
+	b.Done(&balancer.Target{Target: "http://stable1"}, 0, nil)
+	b.Done(&balancer.Target{Target: "http://unknown"}, 0, nil)
+
+	assert.Equal(t, []string{"http://stable1"}, stable.done)
+	assert.Equal(t, []string{"http://canary"}, canary.done)
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hellofresh/janus/pkg/proxy/health"
+)
+
+// TargetGuard decides which upstream targets a request can be sent to. Middleware like circuit breakers adds
+// it to the request context with WithTargetGuard.
+type TargetGuard interface {
+	// IsHealthy reports whether the target can be elected for the request
+	health.Status
+	// Allow is called right before the request is sent to the elected target, when it returns an error the
+	// request fails with it instead of being sent
+	Allow(target string) error
+}
+
+// WithTargetGuard returns a copy of ctx that guards the upstream targets the request is sent to
+func WithTargetGuard(ctx context.Context, guard TargetGuard) context.Context {
+	return context.WithValue(ctx, guardKey, guard)
+}
+
+func targetGuardFromContext(ctx context.Context) TargetGuard {
+	guard, _ := ctx.Value(guardKey).(TargetGuard)
+	return guard
+}
+
+// rejectedError is returned by the guard transport when the guard does not allow the request
+type rejectedError struct {
+	error
+}
+
+// guardTransport only sends the requests that the guard in their context allows
+type guardTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *guardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	guard := targetGuardFromContext(req.Context())
+	target := targetFromContext(req.Context())
+	if guard != nil && target != nil {
+		if err := guard.Allow(target.Target); err != nil {
+			return nil, rejectedError{err}
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/hellofresh/janus/pkg/proxy/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rejectingGuard struct {
+	rejected string
+	allowed  []string
+}
+
+func (g *rejectingGuard) IsHealthy(target string) bool {
+	return target != g.rejected
+}
+
+func (g *rejectingGuard) Allow(target string) error {
+	if target == g.rejected {
+		return errors.New("rejected")
+	}
+	g.allowed = append(g.allowed, target)
+	return nil
+}
+
+func TestGuardTransport(t *testing.T) {
+	t.Parallel()
+
+	guard := &rejectingGuard{rejected: "http://a"}
+	detector := health.NewOutlierDetector("test", health.OutlierConfig{ConsecutiveErrors: 1}, []string{"http://a", "http://b"})
+	sent := 0
+	rt := newFeedbackTransport(&guardTransport{base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})}, detector, balancer.NewRoundrobinBalancer())
+
+	req, _ := http.NewRequest(http.MethodGet, "http://a", nil)
+	req = withTarget(req, "http://a")
+	_, err := rt.RoundTrip(req.WithContext(WithTargetGuard(req.Context(), guard)))
+	require.Error(t, err)
+	assert.Equal(t, 0, sent)
+	assert.True(t, detector.IsHealthy("http://a"), "rejected requests do not count as target failures")
+
+	req, _ = http.NewRequest(http.MethodGet, "http://b", nil)
+	req = withTarget(req, "http://b")
+	_, err = rt.RoundTrip(req.WithContext(WithTargetGuard(req.Context(), guard)))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"http://b"}, guard.allowed)
+}
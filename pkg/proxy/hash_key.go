@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	hashOnHeader = "header"
+	hashOnCookie = "cookie"
+	hashOnQuery  = "query"
+	hashOnClaim  = "claim"
+	hashOnIP     = "ip"
+)
+
+// hashKey returns the request value the hash balancer elects the target by, the client IP is used
+// when nothing else is configured
+func hashKey(req *http.Request, on HashOn) string {
+	switch on.Type {
+	case hashOnHeader:
+		return req.Header.Get(on.Name)
+	case hashOnCookie:
+		cookie, err := req.Cookie(on.Name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	case hashOnQuery:
+		return req.URL.Query().Get(on.Name)
+	case hashOnClaim:
+		return ClaimFromRequest(req, on.Name)
+	default:
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			return req.RemoteAddr
+		}
+		return host
+	}
+}
+
+// ClaimFromRequest returns the given claim of the bearer token of the request. The token signature is not
+// verified here, the value is only used for routing or keying and the token is validated by the auth plugins.
+func ClaimFromRequest(req *http.Request, name string) string {
+	parts := strings.SplitN(req.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return ""
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(parts[1], claims); err != nil {
+		return ""
+	}
+
+	value, ok := claims[name]
+	if !ok || value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"net/http"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashKey(t *testing.T) {
+	t.Parallel()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user-1", "tenant": 42}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/foo?session=q-1", nil)
+	require.NoError(t, err)
+	req.RemoteAddr = "10.0.0.1:52345"
+	req.Header.Set("X-User", "h-1")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "c-1"})
+
+	tests := []struct {
+		scenario string
+		on       HashOn
+		expected string
+	}{
+		{scenario: "header", on: HashOn{Type: "header", Name: "X-User"}, expected: "h-1"},
+		{scenario: "missing header", on: HashOn{Type: "header", Name: "X-Missing"}, expected: ""},
+		{scenario: "cookie", on: HashOn{Type: "cookie", Name: "session"}, expected: "c-1"},
+		{scenario: "missing cookie", on: HashOn{Type: "cookie", Name: "missing"}, expected: ""},
+		{scenario: "query", on: HashOn{Type: "query", Name: "session"}, expected: "q-1"},
+		{scenario: "string claim", on: HashOn{Type: "claim", Name: "sub"}, expected: "user-1"},
+		{scenario: "number claim", on: HashOn{Type: "claim", Name: "tenant"}, expected: "42"},
+		{scenario: "missing claim", on: HashOn{Type: "claim", Name: "missing"}, expected: ""},
+		{scenario: "ip", on: HashOn{Type: "ip"}, expected: "10.0.0.1"},
+		{scenario: "ip by default", on: HashOn{}, expected: "10.0.0.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			assert.Equal(t, test.expected, hashKey(req, test.on))
+		})
+	}
+}
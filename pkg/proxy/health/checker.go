@@ -0,0 +1,310 @@
+// Package health provides active health checking for the upstream targets of an API
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const (
+	// DefaultInterval is the default time between two checks of the same target
+	DefaultInterval = 10 * time.Second
+	// DefaultTimeout is the default timeout for a single check request
+	DefaultTimeout = 5 * time.Second
+	// DefaultHealthyThreshold is the default number of consecutive successful checks to mark a target up
+	DefaultHealthyThreshold = 2
+	// DefaultUnhealthyThreshold is the default number of consecutive failed checks to mark a target down
+	DefaultUnhealthyThreshold = 3
+	// DefaultStatusMin is the default lowest response status code considered healthy
+	DefaultStatusMin = http.StatusOK
+	// DefaultStatusMax is the default highest response status code considered healthy
+	DefaultStatusMax = 399
+)
+
+// Config represents the active health check configuration
+type Config struct {
+	Path               string
+	Interval           time.Duration
+	Timeout            time.Duration
+	StatusMin          int
+	StatusMax          int
+	HealthyThreshold   int
+	UnhealthyThreshold int
+}
+
+// TargetStatus represents the health state of a single target
+type TargetStatus struct {
+	Target               string    `json:"target"`
+	Healthy              bool      `json:"healthy"`
+	ConsecutiveSuccesses int       `json:"consecutive_successes"`
+	ConsecutiveFailures  int       `json:"consecutive_failures"`
+	LastCheck            time.Time `json:"last_check"`
+	LastError            string    `json:"last_error,omitempty"`
+}
+
+// Checker periodically checks the targets of an API and keeps track of their health state
+type Checker struct {
+	name   string
+	config Config
+	client *http.Client
+
+	mu      sync.RWMutex
+	targets map[string]*TargetStatus
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewChecker creates a new instance of Checker. Targets start healthy, so traffic flows
+// before the first round of checks is done.
+func NewChecker(name string, config Config, targets []string, rt http.RoundTripper) *Checker {
+	c := &Checker{
+		name:    name,
+		config:  config.withDefaults(),
+		targets: make(map[string]*TargetStatus),
+		stop:    make(chan struct{}),
+	}
+	c.client = &http.Client{Transport: rt, Timeout: c.config.Timeout}
+	c.SetTargets(targets)
+
+	return c
+}
+
+// Start starts checking the targets in background
+func (c *Checker) Start() {
+	go func() {
+		ticker := time.NewTicker(c.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			c.CheckAll()
+
+			select {
+			case <-ticker.C:
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops checking the targets
+func (c *Checker) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
+// Config returns the configuration the checker was created with
+func (c *Checker) Config() Config {
+	return c.config
+}
+
+// SetTargets updates the set of checked targets, keeping the state of the targets that are still present
+func (c *Checker) SetTargets(targets []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := make(map[string]*TargetStatus, len(targets))
+	for _, target := range targets {
+		if status, ok := c.targets[target]; ok {
+			current[target] = status
+			continue
+		}
+
+		current[target] = &TargetStatus{Target: target, Healthy: true}
+	}
+
+	c.targets = current
+}
+
+// IsHealthy checks if the given target may receive traffic. Unknown targets and a nil checker
+// are always considered healthy.
+func (c *Checker) IsHealthy(target string) bool {
+	if c == nil {
+		return true
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	status, ok := c.targets[target]
+	if !ok {
+		return true
+	}
+
+	return status.Healthy
+}
+
+// Statuses returns a snapshot of the targets health state, sorted by target
+func (c *Checker) Statuses() []TargetStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	statuses := make([]TargetStatus, 0, len(c.targets))
+	for _, status := range c.targets {
+		statuses = append(statuses, *status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Target < statuses[j].Target
+	})
+
+	return statuses
+}
+
+// CheckAll runs one round of checks against all targets
+func (c *Checker) CheckAll() {
+	c.mu.RLock()
+	targets := make([]string, 0, len(c.targets))
+	for target := range c.targets {
+		targets = append(targets, target)
+	}
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target string) {
+			defer wg.Done()
+			c.report(target, c.check(target))
+		}(target)
+	}
+	wg.Wait()
+}
+
+func (c *Checker) check(target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+	u.Path = c.config.Path
+	u.RawQuery = ""
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	// Inform to close the connection after the transaction is complete
+	req.Header.Set("Connection", "close")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < c.config.StatusMin || resp.StatusCode > c.config.StatusMax {
+		return errors.Errorf("unexpected health check response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (c *Checker) report(target string, err error) {
+	c.mu.Lock()
+	status, ok := c.targets[target]
+	if !ok {
+		// target was removed while it was being checked
+		c.mu.Unlock()
+		return
+	}
+
+	wasHealthy := status.Healthy
+	status.LastCheck = time.Now()
+	if err == nil {
+		status.LastError = ""
+		status.ConsecutiveFailures = 0
+		status.ConsecutiveSuccesses++
+		if status.ConsecutiveSuccesses >= c.config.HealthyThreshold {
+			status.Healthy = true
+		}
+	} else {
+		status.LastError = err.Error()
+		status.ConsecutiveSuccesses = 0
+		status.ConsecutiveFailures++
+		if status.ConsecutiveFailures >= c.config.UnhealthyThreshold {
+			status.Healthy = false
+		}
+	}
+	isHealthy := status.Healthy
+	c.mu.Unlock()
+
+	logger := log.WithFields(log.Fields{
+		"api_name": c.name,
+		"target":   target,
+	})
+	if wasHealthy != isHealthy {
+		if isHealthy {
+			logger.Info("Upstream target is healthy again, bringing it back")
+		} else {
+			logger.WithError(err).Warn("Upstream target is unhealthy, ejecting it")
+		}
+	}
+
+	c.record(target, err == nil, isHealthy)
+}
+
+func (c *Checker) record(target string, success bool, healthy bool) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(obs.KeyAPIName, c.name),
+		tag.Insert(obs.KeyUpstreamTarget, target),
+		tag.Insert(obs.KeyResult, result),
+	)
+	if err != nil {
+		log.WithError(err).Debug("Failed to tag health check metrics")
+		return
+	}
+
+	var up int64
+	if healthy {
+		up = 1
+	}
+
+	stats.Record(ctx, obs.MUpstreamHealthChecks.M(1), obs.MUpstreamTargetHealthy.M(up))
+}
+
+func (c Config) withDefaults() Config {
+	if c.Interval <= 0 {
+		c.Interval = DefaultInterval
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+
+	if c.StatusMin <= 0 {
+		c.StatusMin = DefaultStatusMin
+	}
+
+	if c.StatusMax <= 0 {
+		c.StatusMax = DefaultStatusMax
+	}
+
+	if c.HealthyThreshold <= 0 {
+		c.HealthyThreshold = DefaultHealthyThreshold
+	}
+
+	if c.UnhealthyThreshold <= 0 {
+		c.UnhealthyThreshold = DefaultUnhealthyThreshold
+	}
+
+	return c
+}
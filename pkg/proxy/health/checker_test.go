@@ -0,0 +1,180 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scenario string
+		function func(*testing.T)
+	}{
+		{
+			scenario: "targets start healthy",
+			function: testTargetsStartHealthy,
+		},
+		{
+			scenario: "target is ejected after unhealthy threshold",
+			function: testTargetEjectedAfterUnhealthyThreshold,
+		},
+		{
+			scenario: "target is brought back after healthy threshold",
+			function: testTargetBroughtBackAfterHealthyThreshold,
+		},
+		{
+			scenario: "unreachable target is ejected",
+			function: testUnreachableTargetEjected,
+		},
+		{
+			scenario: "expected status range",
+			function: testExpectedStatusRange,
+		},
+		{
+			scenario: "set targets keeps known state",
+			function: testSetTargetsKeepsKnownState,
+		},
+		{
+			scenario: "nil checker considers everything healthy",
+			function: testNilChecker,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			test.function(t)
+		})
+	}
+}
+
+func testTargetsStartHealthy(t *testing.T) {
+	c := NewChecker("test", Config{Path: "/status"}, []string{"http://localhost:1"}, nil)
+
+	assert.True(t, c.IsHealthy("http://localhost:1"))
+	require.Len(t, c.Statuses(), 1)
+	assert.True(t, c.Statuses()[0].Healthy)
+}
+
+func testTargetEjectedAfterUnhealthyThreshold(t *testing.T) {
+	ts := newStatusServer(http.StatusInternalServerError)
+	defer ts.Close()
+
+	c := NewChecker("test", Config{Path: "/status", UnhealthyThreshold: 2}, []string{ts.URL}, nil)
+
+	c.CheckAll()
+	assert.True(t, c.IsHealthy(ts.URL))
+
+	c.CheckAll()
+	assert.False(t, c.IsHealthy(ts.URL))
+
+	status := c.Statuses()[0]
+	assert.Equal(t, 2, status.ConsecutiveFailures)
+	assert.NotEmpty(t, status.LastError)
+}
+
+func testTargetBroughtBackAfterHealthyThreshold(t *testing.T) {
+	code := int32(http.StatusServiceUnavailable)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(&code)))
+	}))
+	defer ts.Close()
+
+	c := NewChecker("test", Config{Path: "/status", UnhealthyThreshold: 1, HealthyThreshold: 2}, []string{ts.URL}, nil)
+
+	c.CheckAll()
+	assert.False(t, c.IsHealthy(ts.URL))
+
+	atomic.StoreInt32(&code, http.StatusOK)
+	c.CheckAll()
+	assert.False(t, c.IsHealthy(ts.URL))
+
+	c.CheckAll()
+	assert.True(t, c.IsHealthy(ts.URL))
+	assert.Empty(t, c.Statuses()[0].LastError)
+}
+
+func testUnreachableTargetEjected(t *testing.T) {
+	ts := newStatusServer(http.StatusOK)
+	target := ts.URL
+	ts.Close()
+
+	c := NewChecker("test", Config{Path: "/status", UnhealthyThreshold: 1, Timeout: time.Second}, []string{target}, nil)
+
+	c.CheckAll()
+	assert.False(t, c.IsHealthy(target))
+}
+
+func testExpectedStatusRange(t *testing.T) {
+	ts := newStatusServer(http.StatusUnauthorized)
+	defer ts.Close()
+
+	c := NewChecker("test", Config{Path: "/status", UnhealthyThreshold: 1, StatusMin: 200, StatusMax: 499}, []string{ts.URL}, nil)
+
+	c.CheckAll()
+	assert.True(t, c.IsHealthy(ts.URL))
+}
+
+func testSetTargetsKeepsKnownState(t *testing.T) {
+	ts := newStatusServer(http.StatusInternalServerError)
+	defer ts.Close()
+
+	c := NewChecker("test", Config{Path: "/status", UnhealthyThreshold: 1}, []string{ts.URL}, nil)
+	c.CheckAll()
+	require.False(t, c.IsHealthy(ts.URL))
+
+	c.SetTargets([]string{ts.URL, "http://localhost:1"})
+	assert.False(t, c.IsHealthy(ts.URL))
+	assert.True(t, c.IsHealthy("http://localhost:1"))
+	assert.Len(t, c.Statuses(), 2)
+}
+
+func testNilChecker(t *testing.T) {
+	var c *Checker
+	assert.True(t, c.IsHealthy("http://localhost:1"))
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	defer r.Retain(nil)
+
+	c := r.Set("test", Config{Path: "/status", Interval: time.Hour}, []string{"http://localhost:1"}, nil)
+
+	existing, ok := r.Get("test")
+	require.True(t, ok)
+	assert.Equal(t, c, existing)
+
+	// same configuration reuses the running checker
+	same := r.Set("test", Config{Path: "/status", Interval: time.Hour}, []string{"http://localhost:2"}, nil)
+	assert.Equal(t, c, same)
+	assert.Equal(t, "http://localhost:2", same.Statuses()[0].Target)
+
+	// changed configuration replaces it
+	changed := r.Set("test", Config{Path: "/health", Interval: time.Hour}, []string{"http://localhost:2"}, nil)
+	assert.NotEqual(t, c, changed)
+
+	r.Retain([]string{"another"})
+	_, ok = r.Get("test")
+	assert.False(t, ok)
+	assert.Empty(t, r.Statuses())
+}
+
+func newStatusServer(code int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(code)
+	}))
+}
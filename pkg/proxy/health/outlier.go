@@ -0,0 +1,214 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	obs "github.com/hellofresh/janus/pkg/observability"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const (
+	// DefaultConsecutiveErrors is the default number of consecutive failed requests to eject a target
+	DefaultConsecutiveErrors = 5
+	// DefaultEjectionTime is the default time an ejected target does not receive traffic
+	DefaultEjectionTime = 30 * time.Second
+	// DefaultMaxEjectionPercent is the default maximum percentage of targets that can be ejected at once
+	DefaultMaxEjectionPercent = 50
+)
+
+// OutlierConfig represents the passive health check (outlier detection) configuration
+type OutlierConfig struct {
+	ConsecutiveErrors  int
+	EjectionTime       time.Duration
+	MaxEjectionPercent int
+}
+
+// OutlierStatus represents the outlier detection state of a single target
+type OutlierStatus struct {
+	Target            string    `json:"target"`
+	Ejected           bool      `json:"ejected"`
+	ConsecutiveErrors int       `json:"consecutive_errors"`
+	EjectedUntil      time.Time `json:"ejected_until,omitempty"`
+}
+
+// OutlierDetector watches the outcome of the proxied requests and ejects the targets that keep failing
+// for a cooling-off period
+type OutlierDetector struct {
+	name   string
+	config OutlierConfig
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	targets map[string]*OutlierStatus
+}
+
+// NewOutlierDetector creates a new instance of OutlierDetector
+func NewOutlierDetector(name string, config OutlierConfig, targets []string) *OutlierDetector {
+	d := &OutlierDetector{
+		name:    name,
+		config:  config.withDefaults(),
+		now:     time.Now,
+		targets: make(map[string]*OutlierStatus),
+	}
+	d.SetTargets(targets)
+
+	return d
+}
+
+// Config returns the configuration the detector was created with
+func (d *OutlierDetector) Config() OutlierConfig {
+	return d.config
+}
+
+// SetTargets updates the set of watched targets, keeping the state of the targets that are still present
+func (d *OutlierDetector) SetTargets(targets []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	current := make(map[string]*OutlierStatus, len(targets))
+	for _, target := range targets {
+		if status, ok := d.targets[target]; ok {
+			current[target] = status
+			continue
+		}
+
+		current[target] = &OutlierStatus{Target: target}
+	}
+
+	d.targets = current
+}
+
+// IsHealthy checks if the given target is not ejected. Unknown targets and a nil detector
+// are always considered healthy.
+func (d *OutlierDetector) IsHealthy(target string) bool {
+	if d == nil {
+		return true
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	status, ok := d.targets[target]
+	if !ok {
+		return true
+	}
+
+	return !d.isEjected(status)
+}
+
+// Observe reports the outcome of a request proxied to the given target
+func (d *OutlierDetector) Observe(target string, success bool) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	status, ok := d.targets[target]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+
+	if success {
+		status.ConsecutiveErrors = 0
+		d.mu.Unlock()
+		return
+	}
+
+	status.ConsecutiveErrors++
+	if status.ConsecutiveErrors < d.config.ConsecutiveErrors || d.isEjected(status) {
+		d.mu.Unlock()
+		return
+	}
+
+	if !d.canEject() {
+		d.mu.Unlock()
+		log.WithFields(log.Fields{
+			"api_name": d.name,
+			"target":   target,
+		}).Warn("Upstream target keeps failing, but the maximum ejection percent was reached")
+		return
+	}
+
+	status.ConsecutiveErrors = 0
+	status.EjectedUntil = d.now().Add(d.config.EjectionTime)
+	d.mu.Unlock()
+
+	log.WithFields(log.Fields{
+		"api_name":      d.name,
+		"target":        target,
+		"ejection_time": d.config.EjectionTime,
+	}).Warn("Upstream target keeps failing, ejecting it")
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(obs.KeyAPIName, d.name),
+		tag.Insert(obs.KeyUpstreamTarget, target),
+	)
+	if err != nil {
+		log.WithError(err).Debug("Failed to tag outlier detection metrics")
+		return
+	}
+	stats.Record(ctx, obs.MUpstreamTargetEjections.M(1))
+}
+
+// Statuses returns a snapshot of the targets outlier detection state, sorted by target
+func (d *OutlierDetector) Statuses() []OutlierStatus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	statuses := make([]OutlierStatus, 0, len(d.targets))
+	for _, status := range d.targets {
+		s := *status
+		s.Ejected = d.isEjected(status)
+		if !s.Ejected {
+			s.EjectedUntil = time.Time{}
+		}
+		statuses = append(statuses, s)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Target < statuses[j].Target
+	})
+
+	return statuses
+}
+
+// isEjected must be called holding the lock
+func (d *OutlierDetector) isEjected(status *OutlierStatus) bool {
+	return d.now().Before(status.EjectedUntil)
+}
+
+// canEject checks if one more target can be ejected without going over the maximum ejection percent,
+// it must be called holding the lock
+func (d *OutlierDetector) canEject() bool {
+	ejected := 1
+	for _, status := range d.targets {
+		if d.isEjected(status) {
+			ejected++
+		}
+	}
+
+	return ejected*100 <= d.config.MaxEjectionPercent*len(d.targets)
+}
+
+func (c OutlierConfig) withDefaults() OutlierConfig {
+	if c.ConsecutiveErrors <= 0 {
+		c.ConsecutiveErrors = DefaultConsecutiveErrors
+	}
+
+	if c.EjectionTime <= 0 {
+		c.EjectionTime = DefaultEjectionTime
+	}
+
+	if c.MaxEjectionPercent <= 0 {
+		c.MaxEjectionPercent = DefaultMaxEjectionPercent
+	}
+
+	return c
+}
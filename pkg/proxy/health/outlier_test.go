@@ -0,0 +1,108 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutlierDetector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scenario string
+		function func(*testing.T)
+	}{
+		{
+			scenario: "target is ejected after consecutive errors",
+			function: testTargetEjectedAfterConsecutiveErrors,
+		},
+		{
+			scenario: "success resets consecutive errors",
+			function: testSuccessResetsConsecutiveErrors,
+		},
+		{
+			scenario: "target comes back after ejection time",
+			function: testTargetComesBackAfterEjectionTime,
+		},
+		{
+			scenario: "max ejection percent is respected",
+			function: testMaxEjectionPercent,
+		},
+		{
+			scenario: "nil detector considers everything healthy",
+			function: testNilOutlierDetector,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			test.function(t)
+		})
+	}
+}
+
+func testTargetEjectedAfterConsecutiveErrors(t *testing.T) {
+	d := NewOutlierDetector("test", OutlierConfig{ConsecutiveErrors: 3}, []string{"a", "b"})
+
+	d.Observe("a", false)
+	d.Observe("a", false)
+	assert.True(t, d.IsHealthy("a"))
+
+	d.Observe("a", false)
+	assert.False(t, d.IsHealthy("a"))
+	assert.True(t, d.IsHealthy("b"))
+
+	statuses := d.Statuses()
+	require.Len(t, statuses, 2)
+	assert.True(t, statuses[0].Ejected)
+	assert.False(t, statuses[0].EjectedUntil.IsZero())
+	assert.False(t, statuses[1].Ejected)
+}
+
+func testSuccessResetsConsecutiveErrors(t *testing.T) {
+	d := NewOutlierDetector("test", OutlierConfig{ConsecutiveErrors: 2}, []string{"a", "b"})
+
+	d.Observe("a", false)
+	d.Observe("a", true)
+	d.Observe("a", false)
+	assert.True(t, d.IsHealthy("a"))
+}
+
+func testTargetComesBackAfterEjectionTime(t *testing.T) {
+	now := time.Now()
+	d := NewOutlierDetector("test", OutlierConfig{ConsecutiveErrors: 1, EjectionTime: time.Minute}, []string{"a", "b"})
+	d.now = func() time.Time { return now }
+
+	d.Observe("a", false)
+	assert.False(t, d.IsHealthy("a"))
+
+	now = now.Add(time.Minute)
+	assert.True(t, d.IsHealthy("a"))
+}
+
+func testMaxEjectionPercent(t *testing.T) {
+	d := NewOutlierDetector("test", OutlierConfig{ConsecutiveErrors: 1, MaxEjectionPercent: 50}, []string{"a", "b", "c", "d"})
+
+	d.Observe("a", false)
+	d.Observe("b", false)
+	d.Observe("c", false)
+
+	assert.False(t, d.IsHealthy("a"))
+	assert.False(t, d.IsHealthy("b"))
+	assert.True(t, d.IsHealthy("c"))
+	assert.True(t, d.IsHealthy("d"))
+
+	// a single target is never ejected with the default max ejection percent
+	single := NewOutlierDetector("test", OutlierConfig{ConsecutiveErrors: 1}, []string{"a"})
+	single.Observe("a", false)
+	assert.True(t, single.IsHealthy("a"))
+}
+
+func testNilOutlierDetector(t *testing.T) {
+	var d *OutlierDetector
+	d.Observe("a", false)
+	assert.True(t, d.IsHealthy("a"))
+}
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Registry holds the running health checkers and outlier detectors by API name
+type Registry struct {
+	sync.RWMutex
+	checkers  map[string]*Checker
+	detectors map[string]*OutlierDetector
+}
+
+// NewRegistry creates a new instance of Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		checkers:  make(map[string]*Checker),
+		detectors: make(map[string]*OutlierDetector),
+	}
+}
+
+// Set returns a running checker for the given API. A checker that is already running with the same
+// configuration is reused, so the targets health state survives configuration reloads.
+func (r *Registry) Set(name string, config Config, targets []string, rt http.RoundTripper) *Checker {
+	r.Lock()
+	defer r.Unlock()
+
+	if c, ok := r.checkers[name]; ok {
+		if c.Config() == config.withDefaults() {
+			c.SetTargets(targets)
+			return c
+		}
+
+		c.Stop()
+	}
+
+	c := NewChecker(name, config, targets, rt)
+	c.Start()
+	r.checkers[name] = c
+
+	return c
+}
+
+// SetOutlierDetector returns an outlier detector for the given API. A detector with the same configuration
+// is reused, so ejected targets stay ejected across configuration reloads.
+func (r *Registry) SetOutlierDetector(name string, config OutlierConfig, targets []string) *OutlierDetector {
+	r.Lock()
+	defer r.Unlock()
+
+	if d, ok := r.detectors[name]; ok && d.Config() == config.withDefaults() {
+		d.SetTargets(targets)
+		return d
+	}
+
+	d := NewOutlierDetector(name, config, targets)
+	r.detectors[name] = d
+
+	return d
+}
+
+// Get returns the checker of the given API
+func (r *Registry) Get(name string) (*Checker, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	c, ok := r.checkers[name]
+	return c, ok
+}
+
+// GetOutlierDetector returns the outlier detector of the given API
+func (r *Registry) GetOutlierDetector(name string) (*OutlierDetector, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	d, ok := r.detectors[name]
+	return d, ok
+}
+
+// RemoveOutlierDetector removes the outlier detector of the given API
+func (r *Registry) RemoveOutlierDetector(name string) {
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.detectors, name)
+}
+
+// Remove stops and removes the checker of the given API
+func (r *Registry) Remove(name string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if c, ok := r.checkers[name]; ok {
+		c.Stop()
+		delete(r.checkers, name)
+	}
+}
+
+// Retain stops and removes the checkers and outlier detectors of all the APIs that are not in the given list
+func (r *Registry) Retain(names []string) {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for name, c := range r.checkers {
+		if !keep[name] {
+			c.Stop()
+			delete(r.checkers, name)
+		}
+	}
+
+	for name := range r.detectors {
+		if !keep[name] {
+			delete(r.detectors, name)
+		}
+	}
+}
+
+// Statuses returns the targets health state of all the checked APIs
+func (r *Registry) Statuses() map[string][]TargetStatus {
+	r.RLock()
+	defer r.RUnlock()
+
+	statuses := make(map[string][]TargetStatus, len(r.checkers))
+	for name, c := range r.checkers {
+		statuses[name] = c.Statuses()
+	}
+
+	return statuses
+}
@@ -0,0 +1,20 @@
+package health
+
+// Status tells if an upstream target may receive traffic
+type Status interface {
+	IsHealthy(target string) bool
+}
+
+// Statuses combines many statuses, a target is healthy only if all of them consider it healthy
+type Statuses []Status
+
+// IsHealthy checks if all the statuses consider the target healthy
+func (s Statuses) IsHealthy(target string) bool {
+	for _, status := range s {
+		if !status.IsHealthy(target) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/hellofresh/janus/pkg/proxy/discovery"
+	"github.com/hellofresh/janus/pkg/proxy/health"
 	"github.com/hellofresh/janus/pkg/proxy/transport"
 	"github.com/hellofresh/janus/pkg/router"
 	"github.com/hellofresh/stats-go/client"
@@ -27,12 +29,18 @@ type Register struct {
 	flushInterval          time.Duration
 	statsClient            client.Client
 	matcher                *router.ListenPathMatcher
+	healthCheckers         *health.Registry
+	balancers              *balancer.Registry
+	discoveries            *discovery.Registry
 }
 
 // NewRegister creates a new instance of Register
 func NewRegister(opts ...RegisterOption) *Register {
 	r := Register{
-		matcher: router.NewListenPathMatcher(),
+		matcher:        router.NewListenPathMatcher(),
+		healthCheckers: health.NewRegistry(),
+		balancers:      balancer.NewRegistry(),
+		discoveries:    discovery.NewRegistry(),
 	}
 
 	for _, opt := range opts {
@@ -50,24 +58,60 @@ func (p *Register) UpdateRouter(router router.Router) {
 // Add register a new route
 func (p *Register) Add(definition *RouterDefinition) error {
 	log.WithField("balancing_alg", definition.Upstreams.Balancing).Debug("Using a load balancing algorithm")
-	balancerInstance, err := balancer.New(definition.Upstreams.Balancing)
+	balancerInstance, err := p.balancer(definition)
 	if err != nil {
 		msg := "Could not create a balancer"
 		log.WithError(err).Error(msg)
 		return errors.Wrap(err, msg)
 	}
 
-	handler := NewBalancedReverseProxy(definition.Definition, balancerInstance, p.statsClient)
-	handler.FlushInterval = p.flushInterval
-	handler.Transport = &ochttp.Transport{
-		Base: transport.New(
-			transport.WithIdleConnTimeout(p.idleConnTimeout),
-			transport.WithInsecureSkipVerify(definition.InsecureSkipVerify),
-			transport.WithDialTimeout(time.Duration(definition.ForwardingTimeouts.DialTimeout)),
-			transport.WithResponseHeaderTimeout(time.Duration(definition.ForwardingTimeouts.ResponseHeaderTimeout)),
-		),
+	baseTransport, err := transport.New(
+		transport.WithIdleConnTimeout(p.idleConnTimeout),
+		transport.WithIdleConnectionsPerHost(p.idleConnectionsPerHost),
+		transport.WithMaxIdleConns(definition.Transport.MaxIdleConns),
+		transport.WithInsecureSkipVerify(definition.InsecureSkipVerify),
+		transport.WithDialTimeout(time.Duration(definition.ForwardingTimeouts.DialTimeout)),
+		transport.WithResponseHeaderTimeout(time.Duration(definition.ForwardingTimeouts.ResponseHeaderTimeout)),
+		transport.WithMaxConnsPerHost(definition.Transport.MaxConnsPerHost),
+		transport.WithHTTP2(definition.Transport.HTTP2),
+		transport.WithClientCertificate(definition.Transport.TLS.CertFile, definition.Transport.TLS.KeyFile),
+		transport.WithCAFile(definition.Transport.TLS.CAFile),
+		transport.WithServerName(definition.Transport.TLS.ServerName),
+		transport.WithMinTLSVersion(definition.Transport.TLS.MinVersion),
+	)
+	if err != nil {
+		msg := "Could not create the upstream transport"
+		log.WithError(err).Error(msg)
+		return errors.Wrap(err, msg)
+	}
+
+	source, err := p.targetSource(definition)
+	if err != nil {
+		msg := "Could not discover the upstream targets"
+		log.WithError(err).Error(msg)
+		return errors.Wrap(err, msg)
+	}
+
+	targets := targetURLs(source.Targets())
+	checker := p.healthChecker(definition, targets, baseTransport)
+	detector := p.outlierDetector(definition, targets)
+
+	if watcher, ok := source.(*discovery.Watcher); ok {
+		watcher.OnUpdate(func(discovered []*balancer.Target) {
+			targets := targetURLs(discovered)
+			if checker != nil {
+				checker.SetTargets(targets)
+			}
+			if detector != nil {
+				detector.SetTargets(targets)
+			}
+		})
 	}
 
+	handler := NewBalancedReverseProxy(definition.Definition, source, balancerInstance, p.statsClient, health.Statuses{checker, detector})
+	handler.FlushInterval = p.flushInterval
+	handler.Transport = &ochttp.Transport{Base: newFeedbackTransport(&guardTransport{base: baseTransport}, detector, balancerInstance)}
+
 	if p.matcher.Match(definition.ListenPath) {
 		p.doRegister(p.matcher.Extract(definition.ListenPath), definition, &ochttp.Handler{Handler: handler, IsPublicEndpoint: true})
 	}
@@ -76,6 +120,114 @@ func (p *Register) Add(definition *RouterDefinition) error {
 	return nil
 }
 
+// HealthCheckers returns the registry of the running upstream health checkers
+func (p *Register) HealthCheckers() *health.Registry {
+	return p.healthCheckers
+}
+
+// Retain drops the upstream state kept for the APIs that are not in the given list
+func (p *Register) Retain(names []string) {
+	p.healthCheckers.Retain(names)
+	p.balancers.Retain(names)
+	p.discoveries.Retain(names)
+}
+
+// targetSource returns the source of the upstream targets of the API, the discovery of a named API is kept
+// across reloads as long as its configuration does not change
+func (p *Register) targetSource(definition *RouterDefinition) (discovery.Source, error) {
+	staticTargets := definition.Upstreams.allTargets().ToBalancerTargets()
+	static := discovery.Static(staticTargets)
+
+	d := definition.Upstreams.Discovery
+	if !d.IsEnabled() {
+		if definition.Name != "" {
+			p.discoveries.Remove(definition.Name)
+		}
+		return static, nil
+	}
+
+	if definition.Name == "" {
+		log.WithField("listen_path", definition.ListenPath).Warn("Upstream discovery requires a named API, using the static targets")
+		return static, nil
+	}
+
+	return p.discoveries.Set(definition.Name, discovery.Config{
+		Type:            d.Type,
+		Name:            d.Name,
+		Scheme:          d.Scheme,
+		Port:            d.Port,
+		RefreshInterval: time.Duration(d.RefreshInterval),
+	}, staticTargets)
+}
+
+// balancer returns the balancer of the API, the balancer of a named API is kept across reloads as long as
+// its algorithm and targets do not change
+func (p *Register) balancer(definition *RouterDefinition) (balancer.Balancer, error) {
+	upstreams := definition.Upstreams
+	if len(upstreams.Groups) == 0 {
+		return p.groupBalancer(definition.Name, "", upstreams.Balancing, upstreams.Targets)
+	}
+
+	balancers := make([]balancer.Balancer, 0, len(upstreams.Groups))
+	for _, group := range upstreams.Groups {
+		b, err := p.groupBalancer(definition.Name, group.Name, upstreams.Balancing, group.Targets)
+		if err != nil {
+			return nil, err
+		}
+		balancers = append(balancers, b)
+	}
+
+	return newGroupsBalancer(upstreams.Groups, balancers), nil
+}
+
+func (p *Register) groupBalancer(name string, group string, balance string, targets Targets) (balancer.Balancer, error) {
+	if name == "" {
+		return balancer.New(balance)
+	}
+
+	return p.balancers.Get(name, group, balance, targets.ToBalancerTargets())
+}
+
+func (p *Register) healthChecker(definition *RouterDefinition, targets []string, rt http.RoundTripper) *health.Checker {
+	if definition.Name == "" {
+		return nil
+	}
+
+	hc := definition.Upstreams.HealthCheck
+	if !hc.IsEnabled() {
+		p.healthCheckers.Remove(definition.Name)
+		return nil
+	}
+
+	return p.healthCheckers.Set(definition.Name, health.Config{
+		Path:               hc.Path,
+		Interval:           time.Duration(hc.Interval),
+		Timeout:            time.Duration(hc.Timeout),
+		StatusMin:          hc.ExpectedStatuses.Min,
+		StatusMax:          hc.ExpectedStatuses.Max,
+		HealthyThreshold:   hc.HealthyThreshold,
+		UnhealthyThreshold: hc.UnhealthyThreshold,
+	}, targets, rt)
+}
+
+func (p *Register) outlierDetector(definition *RouterDefinition, targets []string) *health.OutlierDetector {
+	if definition.Name == "" {
+		return nil
+	}
+
+	od := definition.Upstreams.OutlierDetection
+	if !od.Enabled {
+		p.healthCheckers.RemoveOutlierDetector(definition.Name)
+		return nil
+	}
+
+	return p.healthCheckers.SetOutlierDetector(definition.Name, health.OutlierConfig{
+		ConsecutiveErrors:  od.ConsecutiveErrors,
+		EjectionTime:       time.Duration(od.EjectionTime),
+		MaxEjectionPercent: od.MaxEjectionPercent,
+	}, targets)
+}
+
 func (p *Register) doRegister(listenPath string, def *RouterDefinition, handler http.Handler) {
 	log.WithFields(log.Fields{
 		"listen_path": listenPath,
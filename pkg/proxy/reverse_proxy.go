@@ -1,22 +1,27 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	"github.com/go-chi/chi"
 	"github.com/hellofresh/janus/pkg/middleware"
 	obs "github.com/hellofresh/janus/pkg/observability"
 	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/hellofresh/janus/pkg/proxy/discovery"
+	"github.com/hellofresh/janus/pkg/proxy/health"
 	"github.com/hellofresh/janus/pkg/router"
 	"github.com/hellofresh/stats-go/bucket"
 	"github.com/hellofresh/stats-go/client"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 	"go.opencensus.io/trace"
 )
@@ -25,28 +30,80 @@ const (
 	statsSection = "upstream"
 )
 
-// NewBalancedReverseProxy creates a reverse proxy that is load balanced
-func NewBalancedReverseProxy(def *Definition, balancer balancer.Balancer, statsClient client.Client) *httputil.ReverseProxy {
+type contextKeyType int
+
+const (
+	targetKey contextKeyType = iota
+	stickyKey
+	groupKey
+	attemptsKey
+	guardKey
+)
+
+// NewBalancedReverseProxy creates a reverse proxy that is load balanced between the targets of the given source.
+// Only the targets that the given health status considers healthy are elected.
+func NewBalancedReverseProxy(def *Definition, source discovery.Source, balancer balancer.Balancer, statsClient client.Client, status health.Status) *httputil.ReverseProxy {
 	return &httputil.ReverseProxy{
-		Director: createDirector(def, balancer, statsClient),
+		Director:       createDirector(def, source, balancer, statsClient, status),
+		ModifyResponse: createModifyResponse(def),
+		Transport:      &guardTransport{base: http.DefaultTransport},
 	}
 }
 
-func createDirector(proxyDefinition *Definition, balancer balancer.Balancer, statsClient client.Client) func(req *http.Request) {
+func createDirector(proxyDefinition *Definition, source discovery.Source, lb balancer.Balancer, statsClient client.Client, status health.Status) func(req *http.Request) {
 	paramNameExtractor := router.NewListenPathParamNameExtractor()
 	matcher := router.NewListenPathMatcher()
+	unhealthy := &unhealthyState{listenPath: proxyDefinition.ListenPath}
 
 	return func(req *http.Request) {
-		upstream, err := balancer.Elect(proxyDefinition.Upstreams.Targets.ToBalancerTargets())
-		if err != nil {
-			log.WithError(err).Error("Could not elect one upstream")
-			return
+		ctx := req.Context()
+		if proxyDefinition.Upstreams.Balancing == "hash" {
+			ctx = balancer.WithHashKey(ctx, hashKey(req, proxyDefinition.Upstreams.HashOn))
+		}
+		if group := pinnedGroup(req, proxyDefinition.Upstreams.Groups); group != "" {
+			ctx = context.WithValue(ctx, groupKey, group)
+		}
+
+		targets, allUnhealthy := healthyTargets(source.Targets(), status)
+		unhealthy.set(allUnhealthy, len(targets))
+		if allUnhealthy {
+			stats.Record(ctx, obs.MUpstreamAllTargetsUnhealthy.M(1))
+		}
+		if guard := targetGuardFromContext(ctx); guard != nil {
+			targets, _ = healthyTargets(targets, guard)
+		}
+		attempts := attemptsFromContext(ctx)
+		if attempts != nil {
+			targets = attempts.untriedTargets(targets)
+		}
+
+		upstream := stickyTarget(req, proxyDefinition.StickySession, targets)
+		if upstream != nil && attempts != nil && attempts.tried(upstream.Target) {
+			upstream = nil
+		}
+		sticky := upstream != nil
+		if sticky {
+			log.WithField("target", upstream.Target).Debug("Sticky target upstream elected")
+		} else {
+			var err error
+			upstream, err = lb.Elect(ctx, targets)
+			if err != nil {
+				log.WithError(err).Error("Could not elect one upstream")
+				return
+			}
+			log.WithField("target", upstream.Target).Debug("Target upstream elected")
+		}
+		if attempts != nil {
+			attempts.add(upstream.Target)
 		}
-		log.WithField("target", upstream.Target).Debug("Target upstream elected")
 
 		target, err := url.Parse(upstream.Target)
 		if err != nil {
 			log.WithError(err).WithField("upstream_url", upstream.Target).Error("Could not parse the target URL")
+			// the request is not sent to the elected target, so the feedback transport never releases it
+			if fb, ok := lb.(balancer.FeedbackBalancer); ok && !sticky {
+				fb.Done(upstream, 0, err)
+			}
 			return
 		}
 
@@ -113,11 +170,66 @@ func createDirector(proxyDefinition *Definition, balancer balancer.Balancer, sta
 		addTraceAttributes(req)
 
 		// Insert additional tags
-		ctx, _ := tag.New(req.Context(), tag.Insert(obs.KeyUpstreamPath, upstream.Target))
+		ctx, _ = tag.New(req.Context(), tag.Insert(obs.KeyUpstreamPath, upstream.Target))
+		ctx = context.WithValue(ctx, targetKey, upstream)
+		ctx = context.WithValue(ctx, stickyKey, sticky)
 		*req = *req.WithContext(ctx)
 	}
 }
 
+// healthyTargets filters out the targets that are marked down. If every target is down all of them are
+// returned, since sending traffic to a possibly recovered target is better than failing every request, and
+// true tells so.
+func healthyTargets(all []*balancer.Target, status health.Status) ([]*balancer.Target, bool) {
+	if status == nil {
+		return all, false
+	}
+
+	var healthy []*balancer.Target
+	for _, t := range all {
+		if status.IsHealthy(t.Target) {
+			healthy = append(healthy, t)
+		}
+	}
+
+	if len(healthy) == 0 && len(all) > 0 {
+		return all, true
+	}
+
+	return healthy, false
+}
+
+// unhealthyState tracks whether all the upstream targets of an API are unhealthy, so only the changes are logged.
+// The requests balanced between unhealthy targets are counted by the upstream_all_targets_unhealthy_request_total
+// metric.
+type unhealthyState struct {
+	listenPath string
+	unhealthy  int32
+}
+
+func (s *unhealthyState) set(unhealthy bool, targets int) {
+	var value int32
+	if unhealthy {
+		value = 1
+	}
+	if atomic.SwapInt32(&s.unhealthy, value) == value {
+		return
+	}
+
+	logger := log.WithFields(log.Fields{"listen_path": s.listenPath, "targets": targets})
+	if unhealthy {
+		logger.Warn("All upstream targets are unhealthy, balancing between all of them")
+	} else {
+		logger.Info("Upstream targets are healthy again")
+	}
+}
+
+// targetFromContext returns the upstream target elected for the request
+func targetFromContext(ctx context.Context) *balancer.Target {
+	target, _ := ctx.Value(targetKey).(*balancer.Target)
+	return target
+}
+
 func addTraceAttributes(req *http.Request) {
 	ctx := req.Context()
 	span := trace.FromContext(ctx)
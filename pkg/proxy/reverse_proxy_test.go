@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/hellofresh/janus/pkg/proxy/discovery"
+	"github.com/hellofresh/stats-go/client"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthyTargets(t *testing.T) {
+	t.Parallel()
+
+	targets := []*balancer.Target{{Target: "http://a"}, {Target: "http://b"}}
+
+	healthy, allUnhealthy := healthyTargets(targets, &rejectingGuard{rejected: "http://a"})
+	assert.Equal(t, targets[1:], healthy)
+	assert.False(t, allUnhealthy)
+
+	healthy, allUnhealthy = healthyTargets(targets[:1], &rejectingGuard{rejected: "http://a"})
+	assert.Equal(t, targets[:1], healthy)
+	assert.True(t, allUnhealthy)
+
+	healthy, allUnhealthy = healthyTargets(targets, nil)
+	assert.Equal(t, targets, healthy)
+	assert.False(t, allUnhealthy)
+}
+
+type countingBalancer struct {
+	elected int
+	done    int
+}
+
+func (b *countingBalancer) Elect(ctx context.Context, hosts []*balancer.Target) (*balancer.Target, error) {
+	b.elected++
+	return hosts[0], nil
+}
+
+func (b *countingBalancer) Done(target *balancer.Target, rtt time.Duration, err error) {
+	b.done++
+}
+
+func TestDirectorReleasesUnparsableTargets(t *testing.T) {
+	t.Parallel()
+
+	def := NewDefinition()
+	def.ListenPath = "/"
+	def.Upstreams = &Upstreams{Balancing: "least_conn", Targets: Targets{{Target: "http://[::1"}}}
+
+	lb := &countingBalancer{}
+	director := createDirector(def, discovery.Static(def.Upstreams.Targets.ToBalancerTargets()), lb, client.NewNoop(), nil)
+	director(httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, 1, lb.elected)
+	assert.Equal(t, 1, lb.done, "the elected target is released when the request is not sent to it")
+}
+
+func TestUnhealthyStateLogsOnChange(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	s := &unhealthyState{listenPath: "/example"}
+	s.set(false, 2)
+	assert.Empty(t, hook.AllEntries())
+
+	s.set(true, 2)
+	s.set(true, 2)
+	assert.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, "/example", hook.LastEntry().Data["listen_path"])
+
+	s.set(false, 2)
+	s.set(false, 2)
+	assert.Len(t, hook.AllEntries(), 2)
+}
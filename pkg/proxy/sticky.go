@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+)
+
+const defaultStickyCookieName = "janus_sticky"
+
+// stickyTarget returns the target the sticky session cookie of the request points to, as long as it is one of
+// the given targets
+func stickyTarget(req *http.Request, session StickySession, targets []*balancer.Target) *balancer.Target {
+	if !session.Enabled {
+		return nil
+	}
+
+	cookie, err := req.Cookie(session.cookieName())
+	if err != nil {
+		return nil
+	}
+
+	for _, target := range targets {
+		if stickyID(target.Target) == cookie.Value {
+			return target
+		}
+	}
+
+	return nil
+}
+
+// createModifyResponse returns the response modifier that sets the sticky session cookie for the targets that
+// were elected by the balancer
+func createModifyResponse(def *Definition) func(*http.Response) error {
+	if !def.StickySession.Enabled {
+		return nil
+	}
+
+	return func(resp *http.Response) error {
+		if resp.Request == nil {
+			return nil
+		}
+
+		ctx := resp.Request.Context()
+		target := targetFromContext(ctx)
+		if target == nil || isStickyFromContext(ctx) {
+			return nil
+		}
+
+		resp.Header.Add("Set-Cookie", def.StickySession.cookie(target.Target).String())
+		return nil
+	}
+}
+
+// isStickyFromContext checks if the target of the request was taken from the sticky session cookie
+func isStickyFromContext(ctx context.Context) bool {
+	sticky, _ := ctx.Value(stickyKey).(bool)
+	return sticky
+}
+
+// stickyID identifies a target in the cookie without exposing the upstream address to the clients
+func stickyID(target string) string {
+	sum := sha256.Sum256([]byte(target))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (s StickySession) cookieName() string {
+	if s.CookieName == "" {
+		return defaultStickyCookieName
+	}
+
+	return s.CookieName
+}
+
+func (s StickySession) cookie(target string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     s.cookieName(),
+		Value:    stickyID(target),
+		Path:     s.Path,
+		Domain:   s.Domain,
+		Secure:   s.Secure,
+		HttpOnly: s.HTTPOnly,
+	}
+
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+
+	if ttl := time.Duration(s.TTL); ttl > 0 {
+		cookie.MaxAge = int(ttl.Seconds())
+		cookie.Expires = time.Now().Add(ttl).UTC()
+	}
+
+	return cookie
+}
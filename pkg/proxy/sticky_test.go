@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/hellofresh/janus/pkg/proxy/discovery"
+	"github.com/hellofresh/janus/pkg/proxy/health"
+	"github.com/hellofresh/stats-go/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticStatus map[string]bool
+
+func (s staticStatus) IsHealthy(target string) bool {
+	healthy, ok := s[target]
+	return !ok || healthy
+}
+
+func TestStickySession(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scenario string
+		function func(*testing.T)
+	}{
+		{
+			scenario: "first request sets the cookie",
+			function: testStickySessionSetsCookie,
+		},
+		{
+			scenario: "cookie pins the target",
+			function: testStickySessionPinsTarget,
+		},
+		{
+			scenario: "unknown or unhealthy target falls back to the balancer",
+			function: testStickySessionFallsBack,
+		},
+		{
+			scenario: "disabled sticky session",
+			function: testStickySessionDisabled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			test.function(t)
+		})
+	}
+}
+
+func testStickySessionSetsCookie(t *testing.T) {
+	upstream := newNamedServer("a")
+	defer upstream.Close()
+
+	session := StickySession{Enabled: true, CookieName: "affinity", TTL: Duration(time.Hour), Secure: true, HTTPOnly: true}
+	resp := proxyRequest(t, newStickyDefinition(session, upstream.URL), nil, nil)
+
+	cookies := resp.Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "affinity", cookies[0].Name)
+	assert.Equal(t, stickyID(upstream.URL), cookies[0].Value)
+	assert.NotContains(t, cookies[0].Value, "127.0.0.1")
+	assert.Equal(t, "/", cookies[0].Path)
+	assert.Equal(t, 3600, cookies[0].MaxAge)
+	assert.True(t, cookies[0].Secure)
+	assert.True(t, cookies[0].HttpOnly)
+}
+
+func testStickySessionPinsTarget(t *testing.T) {
+	a := newNamedServer("a")
+	defer a.Close()
+	b := newNamedServer("b")
+	defer b.Close()
+
+	def := newStickyDefinition(StickySession{Enabled: true}, a.URL, b.URL)
+	cookie := &http.Cookie{Name: defaultStickyCookieName, Value: stickyID(b.URL)}
+
+	for i := 0; i < 4; i++ {
+		resp := proxyRequest(t, def, nil, cookie)
+		assert.Equal(t, "b", resp.Header.Get("X-Upstream"))
+		assert.Empty(t, resp.Cookies())
+	}
+}
+
+func testStickySessionFallsBack(t *testing.T) {
+	a := newNamedServer("a")
+	defer a.Close()
+	b := newNamedServer("b")
+	defer b.Close()
+
+	def := newStickyDefinition(StickySession{Enabled: true}, a.URL, b.URL)
+
+	resp := proxyRequest(t, def, nil, &http.Cookie{Name: defaultStickyCookieName, Value: stickyID("http://removed")})
+	require.Len(t, resp.Cookies(), 1)
+
+	resp = proxyRequest(t, def, staticStatus{b.URL: false}, &http.Cookie{Name: defaultStickyCookieName, Value: stickyID(b.URL)})
+	assert.Equal(t, "a", resp.Header.Get("X-Upstream"))
+	require.Len(t, resp.Cookies(), 1)
+	assert.Equal(t, stickyID(a.URL), resp.Cookies()[0].Value)
+}
+
+func testStickySessionDisabled(t *testing.T) {
+	upstream := newNamedServer("a")
+	defer upstream.Close()
+
+	resp := proxyRequest(t, newStickyDefinition(StickySession{}, upstream.URL), nil, nil)
+	assert.Empty(t, resp.Cookies())
+}
+
+func newStickyDefinition(session StickySession, targets ...string) *Definition {
+	def := NewDefinition()
+	def.ListenPath = "/"
+	def.StickySession = session
+	def.Upstreams.Balancing = "roundrobin"
+	for _, target := range targets {
+		def.Upstreams.Targets = append(def.Upstreams.Targets, &Target{Target: target})
+	}
+
+	return def
+}
+
+func newNamedServer(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", name)
+	}))
+}
+
+func proxyRequest(t *testing.T, def *Definition, status health.Status, cookie *http.Cookie) *http.Response {
+	source := discovery.Static(def.Upstreams.Targets.ToBalancerTargets())
+	handler := NewBalancedReverseProxy(def, source, balancer.NewRoundrobinBalancer(), client.NewNoop(), status)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	return w.Result()
+}
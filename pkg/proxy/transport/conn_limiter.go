@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// connLimiter limits the number of open connections per host, dialing a new connection waits until one of the
+// open connections to the same host is closed
+type connLimiter struct {
+	sync.Mutex
+	max   int
+	slots map[string]chan struct{}
+}
+
+func newConnLimiter(max int) *connLimiter {
+	return &connLimiter{max: max, slots: make(map[string]chan struct{})}
+}
+
+func (l *connLimiter) dialContext(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		slots := l.hostSlots(addr)
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			<-slots
+			return nil, err
+		}
+
+		return &limitedConn{Conn: conn, release: func() { <-slots }}, nil
+	}
+}
+
+func (l *connLimiter) hostSlots(addr string) chan struct{} {
+	l.Lock()
+	defer l.Unlock()
+
+	slots, ok := l.slots[addr]
+	if !ok {
+		slots = make(chan struct{}, l.max)
+		l.slots[addr] = slots
+	}
+
+	return slots
+}
+
+// limitedConn releases its slot in the limiter once it is closed
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
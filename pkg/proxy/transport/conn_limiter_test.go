@@ -0,0 +1,62 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnLimiter(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	dial := newConnLimiter(1).dialContext((&net.Dialer{}).DialContext)
+	addr := listener.Addr().String()
+
+	first, err := dial(context.Background(), "tcp", addr)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = dial(ctx, "tcp", addr)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	dialed := make(chan net.Conn)
+	go func() {
+		conn, err := dial(context.Background(), "tcp", addr)
+		assert.NoError(t, err)
+		dialed <- conn
+	}()
+
+	select {
+	case <-dialed:
+		t.Fatal("connection dialed over the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, first.Close())
+	// closing twice does not release the slot twice
+	first.Close()
+
+	select {
+	case conn := <-dialed:
+		conn.Close()
+	case <-time.After(time.Second):
+		t.Fatal("connection was not dialed after a slot was released")
+	}
+}
@@ -0,0 +1,154 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+)
+
+// ErrResponseHeaderTimeout is returned when the upstream does not send the response headers in time
+var ErrResponseHeaderTimeout = errors.New("timeout awaiting response headers")
+
+type proxyFunc func(*http.Request) (*url.URL, error)
+
+// h2cTransport uses HTTP/2 over plain TCP connections. The http2 transport has no response header timeout and
+// does not use a proxy, so both are handled here.
+type h2cTransport struct {
+	base                  http.RoundTripper
+	responseHeaderTimeout time.Duration
+}
+
+func newH2CTransport(dial dialFunc, proxy proxyFunc, responseHeaderTimeout time.Duration) *h2cTransport {
+	return &h2cTransport{
+		base: &http2.Transport{
+			AllowHTTP: true,
+			// h2c connections are plain TCP connections, even though the http2 transport calls them TLS
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialThroughProxy(context.Background(), dial, proxy, network, addr)
+			},
+		},
+		responseHeaderTimeout: responseHeaderTimeout,
+	}
+}
+
+func (t *h2cTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.responseHeaderTimeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(t.responseHeaderTimeout, cancel)
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if !timer.Stop() {
+		cancel()
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, ErrResponseHeaderTimeout
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// the request context is cancelled once the body is read, cancelling it before would abort the body
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// CloseIdleConnections closes the idle connections of the http2 transport
+func (t *h2cTransport) CloseIdleConnections() {
+	if tr, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		tr.CloseIdleConnections()
+	}
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	once   sync.Once
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.cancel)
+	return err
+}
+
+// dialThroughProxy connects to the address, through a CONNECT tunnel when a proxy is configured for it
+func dialThroughProxy(ctx context.Context, dial dialFunc, proxy proxyFunc, network, addr string) (net.Conn, error) {
+	proxyURL, err := proxy(&http.Request{URL: &url.URL{Scheme: "http", Host: addr}})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not resolve the proxy")
+	}
+	if proxyURL == nil {
+		return dial(ctx, network, addr)
+	}
+
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+
+	conn, err := dial(ctx, network, proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	connect := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u := proxyURL.User; u != nil {
+		password, _ := u.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.Username() + ":" + password))
+		connect.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+
+	if err := connect.Write(conn); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not send the CONNECT request to the proxy")
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, connect)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not read the CONNECT response of the proxy")
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, errors.Errorf("the proxy refused the connection to %s: %s", addr, resp.Status)
+	}
+
+	// the upstream may have sent its first frames along with the CONNECT response
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+
+	return conn, nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func TestH2CTransportResponseHeaderTimeout(t *testing.T) {
+	addr, closeServer := serveH2C(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte(r.Proto))
+	}))
+	defer closeServer()
+
+	tr := newH2CTransport(dialTCP, http.ProxyURL(nil), 50*time.Millisecond)
+	client := &http.Client{Transport: tr}
+
+	resp, err := client.Get("http://" + addr + "/fast")
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", readBody(t, resp))
+
+	_, err = client.Get("http://" + addr + "/slow")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrResponseHeaderTimeout.Error())
+}
+
+func TestH2CTransportProxy(t *testing.T) {
+	addr, closeServer := serveH2C(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	defer closeServer()
+
+	var mu sync.Mutex
+	var tunnels []string
+	proxy, closeProxy := listen(t, func(conn net.Conn) {
+		defer conn.Close()
+
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil || req.Method != http.MethodConnect {
+			return
+		}
+		mu.Lock()
+		tunnels = append(tunnels, req.Host+" "+req.Header.Get("Proxy-Authorization"))
+		mu.Unlock()
+
+		upstream, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			return
+		}
+		defer upstream.Close()
+
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go io.Copy(upstream, conn)
+		io.Copy(conn, upstream)
+	})
+	defer closeProxy()
+
+	proxyURL, err := url.Parse("http://user:secret@" + proxy)
+	require.NoError(t, err)
+	tr := newH2CTransport(dialTCP, http.ProxyURL(proxyURL), 0)
+
+	resp, err := (&http.Client{Transport: tr}).Get("http://" + addr)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", readBody(t, resp))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{addr + " Basic dXNlcjpzZWNyZXQ="}, tunnels)
+}
+
+func dialTCP(ctx context.Context, network, addr string) (net.Conn, error) {
+	return (&net.Dialer{}).DialContext(ctx, network, addr)
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func serveH2C(t *testing.T, handler http.Handler) (string, func()) {
+	server := &http2.Server{}
+	return listen(t, func(conn net.Conn) {
+		server.ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+	})
+}
+
+func listen(t *testing.T, serve func(net.Conn)) (string, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	return l.Addr().String(), func() { l.Close() }
+}
@@ -14,6 +14,20 @@ func WithInsecureSkipVerify(value bool) Option {
 	}
 }
 
+// WithIdleConnectionsPerHost sets the maximum number of idle (keep-alive) connections per host
+func WithIdleConnectionsPerHost(n int) Option {
+	return func(t *transport) {
+		t.idleConnectionsPerHost = n
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle (keep-alive) connections across all hosts
+func WithMaxIdleConns(n int) Option {
+	return func(t *transport) {
+		t.maxIdleConns = n
+	}
+}
+
 // WithDialTimeout sets the dial context timeout
 func WithDialTimeout(d time.Duration) Option {
 	return func(t *transport) {
@@ -36,3 +50,47 @@ func WithIdleConnTimeout(d time.Duration) Option {
 		t.idleConnTimeout = d
 	}
 }
+
+// WithMaxConnsPerHost limits the number of open connections per host, zero means no limit
+func WithMaxConnsPerHost(n int) Option {
+	return func(t *transport) {
+		t.maxConnsPerHost = n
+	}
+}
+
+// WithHTTP2 sets the HTTP/2 mode, one of HTTP2On, HTTP2Off or HTTP2H2C
+func WithHTTP2(mode string) Option {
+	return func(t *transport) {
+		t.http2 = mode
+	}
+}
+
+// WithClientCertificate sets the certificate and key files presented to the backend servers for mutual TLS
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(t *transport) {
+		t.certFile = certFile
+		t.keyFile = keyFile
+	}
+}
+
+// WithCAFile sets the bundle of CA certificates the backend servers certificates are verified with,
+// instead of the system ones
+func WithCAFile(caFile string) Option {
+	return func(t *transport) {
+		t.caFile = caFile
+	}
+}
+
+// WithServerName sets the server name sent in the TLS SNI extension and used to verify the certificate
+func WithServerName(name string) Option {
+	return func(t *transport) {
+		t.serverName = name
+	}
+}
+
+// WithMinTLSVersion sets the minimum TLS version, one of 1.0, 1.1 or 1.2
+func WithMinTLSVersion(version string) Option {
+	return func(t *transport) {
+		t.minTLSVersion = version
+	}
+}
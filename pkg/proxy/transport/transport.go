@@ -2,12 +2,16 @@ package transport
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"golang.org/x/net/http2"
 )
 
@@ -18,11 +22,33 @@ const (
 	// DefaultIdleConnsPerHost the default value set for http.Transport.MaxIdleConnsPerHost.
 	DefaultIdleConnsPerHost = 64
 
+	// DefaultMaxIdleConns the default value set for http.Transport.MaxIdleConns.
+	DefaultMaxIdleConns = 100
+
 	// DefaultIdleConnTimeout is the default value for the the maximum amount of time an idle
 	// (keep-alive) connection will remain idle before closing itself.
 	DefaultIdleConnTimeout = 90 * time.Second
+
+	// DefaultTLSHandshakeTimeout is the maximum amount of time to wait for a TLS handshake.
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// HTTP/2 modes used to connect to the backend servers
+const (
+	// HTTP2On negotiates HTTP/2 over TLS with the servers that support it, this is the default
+	HTTP2On = "on"
+	// HTTP2Off always uses HTTP/1.1
+	HTTP2Off = "off"
+	// HTTP2H2C uses HTTP/2 over plain TCP connections (h2c, prior knowledge)
+	HTTP2H2C = "h2c"
 )
 
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+}
+
 type transport struct {
 	// Same as net/http.Transport.MaxIdleConnsPerHost, but the default
 	// is 64. This value supports scenarios with relatively few remote
@@ -30,22 +56,55 @@ type transport struct {
 	// range of hundreds, it is recommended to set this options to a
 	// lower value.
 	idleConnectionsPerHost int
-	insecureSkipVerify     bool
-	dialTimeout            time.Duration
-	responseHeaderTimeout  time.Duration
-	idleConnTimeout        time.Duration
+	// maxIdleConns limits the number of idle connections across all hosts
+	maxIdleConns          int
+	insecureSkipVerify    bool
+	dialTimeout           time.Duration
+	responseHeaderTimeout time.Duration
+	idleConnTimeout       time.Duration
+	// maxConnsPerHost limits the number of open connections per host, zero means no limit
+	maxConnsPerHost int
+	http2           string
+	certFile        string
+	keyFile         string
+	caFile          string
+	serverName      string
+	minTLSVersion   string
 }
 
 func (t transport) hash() string {
 	return strings.Join([]string{
 		fmt.Sprintf("idleConnectionsPerHost:%v", t.idleConnectionsPerHost),
+		fmt.Sprintf("maxIdleConns:%v", t.maxIdleConns),
 		fmt.Sprintf("insecureSkipVerify:%v", t.insecureSkipVerify),
 		fmt.Sprintf("dialTimeout:%v", t.dialTimeout),
 		fmt.Sprintf("responseHeaderTimeout:%v", t.responseHeaderTimeout),
 		fmt.Sprintf("idleConnTimeout:%v", t.idleConnTimeout),
+		fmt.Sprintf("maxConnsPerHost:%v", t.maxConnsPerHost),
+		fmt.Sprintf("http2:%v", t.http2),
+		fmt.Sprintf("certFile:%v@%v", t.certFile, fileVersion(t.certFile)),
+		fmt.Sprintf("keyFile:%v@%v", t.keyFile, fileVersion(t.keyFile)),
+		fmt.Sprintf("caFile:%v@%v", t.caFile, fileVersion(t.caFile)),
+		fmt.Sprintf("serverName:%v", t.serverName),
+		fmt.Sprintf("minTLSVersion:%v", t.minTLSVersion),
 	}, ";")
 }
 
+// fileVersion identifies the content of a file by its modification time and size, so the transports are created
+// again once the certificate files are rotated
+func fileVersion(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
+
 var registryInstance *registry
 
 func init() {
@@ -53,7 +112,7 @@ func init() {
 }
 
 // New creates a new instance of Transport with the given params
-func New(opts ...Option) *http.Transport {
+func New(opts ...Option) (http.RoundTripper, error) {
 	t := transport{}
 
 	for _, opt := range opts {
@@ -68,37 +127,109 @@ func New(opts ...Option) *http.Transport {
 		t.idleConnectionsPerHost = DefaultIdleConnsPerHost
 	}
 
+	if t.maxIdleConns <= 0 {
+		t.maxIdleConns = DefaultMaxIdleConns
+	}
+
 	if t.idleConnTimeout == 0 {
 		t.idleConnTimeout = DefaultIdleConnTimeout
 	}
 
+	if t.http2 == "" {
+		t.http2 = HTTP2On
+	}
+
 	// let's try to get the cached transport from registry, since there is no need to create lots of
 	// transports with the same configuration
 	hash := t.hash()
 	if tr, ok := registryInstance.get(hash); ok {
-		return tr
+		return tr, nil
+	}
+
+	tlsConfig, err := t.tlsConfig()
+	if err != nil {
+		return nil, err
 	}
 
-	tr := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   t.dialTimeout,
-			KeepAlive: 30 * time.Second,
-			DualStack: true,
-		}).DialContext,
-		MaxIdleConns:          100,
-		IdleConnTimeout:       t.idleConnTimeout,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-		ResponseHeaderTimeout: t.responseHeaderTimeout,
-		MaxIdleConnsPerHost:   t.idleConnectionsPerHost,
-		TLSClientConfig:       &tls.Config{InsecureSkipVerify: t.insecureSkipVerify},
+	dialContext := (&net.Dialer{
+		Timeout:   t.dialTimeout,
+		KeepAlive: 30 * time.Second,
+		DualStack: true,
+	}).DialContext
+	if t.maxConnsPerHost > 0 {
+		dialContext = newConnLimiter(t.maxConnsPerHost).dialContext(dialContext)
 	}
 
-	http2.ConfigureTransport(tr)
+	var tr http.RoundTripper
+	switch t.http2 {
+	case HTTP2H2C:
+		tr = newH2CTransport(dialContext, http.ProxyFromEnvironment, t.responseHeaderTimeout)
+	case HTTP2On, HTTP2Off:
+		httpTransport := &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialContext,
+			MaxIdleConns:          t.maxIdleConns,
+			IdleConnTimeout:       t.idleConnTimeout,
+			TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+			ExpectContinueTimeout: 1 * time.Second,
+			ResponseHeaderTimeout: t.responseHeaderTimeout,
+			MaxIdleConnsPerHost:   t.idleConnectionsPerHost,
+			TLSClientConfig:       tlsConfig,
+		}
+
+		if t.http2 == HTTP2On {
+			if err := http2.ConfigureTransport(httpTransport); err != nil {
+				return nil, errors.Wrap(err, "could not configure HTTP/2")
+			}
+		} else {
+			// a non-nil empty map disables the HTTP/2 upgrade
+			httpTransport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		}
+		tr = httpTransport
+	default:
+		return nil, errors.Errorf("unsupported HTTP/2 mode %q", t.http2)
+	}
 
 	// save newly created transport in registry, to try to reuse it in the future
 	registryInstance.put(hash, tr)
 
-	return tr
+	return tr, nil
+}
+
+func (t transport) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: t.insecureSkipVerify,
+		ServerName:         t.serverName,
+	}
+
+	if t.minTLSVersion != "" {
+		version, ok := tlsVersions[t.minTLSVersion]
+		if !ok {
+			return nil, errors.Errorf("unsupported TLS version %q", t.minTLSVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if t.certFile != "" || t.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.certFile, t.keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load the client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if t.caFile != "" {
+		pem, err := ioutil.ReadFile(t.caFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read the CA bundle")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in the CA bundle %s", t.caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
 }
@@ -0,0 +1,203 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func TestNewReusesTransports(t *testing.T) {
+	tr1, err := New(WithServerName("reuse.local"))
+	require.NoError(t, err)
+
+	tr2, err := New(WithServerName("reuse.local"))
+	require.NoError(t, err)
+	assert.True(t, tr1 == tr2)
+
+	tr3, err := New(WithServerName("reuse.local"), WithMaxConnsPerHost(10))
+	require.NoError(t, err)
+	assert.False(t, tr1 == tr3)
+
+	tr4, err := New(WithServerName("reuse.local"), WithMaxIdleConns(10))
+	require.NoError(t, err)
+	assert.False(t, tr1 == tr4)
+	assert.Equal(t, 10, tr4.(*http.Transport).MaxIdleConns)
+	assert.Equal(t, DefaultMaxIdleConns, tr1.(*http.Transport).MaxIdleConns)
+}
+
+func TestNewReloadsRotatedCertificates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transport")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeCertificate(t, dir, "client")
+	tr1, err := New(WithClientCertificate(certFile, keyFile))
+	require.NoError(t, err)
+
+	tr2, err := New(WithClientCertificate(certFile, keyFile))
+	require.NoError(t, err)
+	assert.True(t, tr1 == tr2)
+
+	writeCertificate(t, dir, "client")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+
+	tr3, err := New(WithClientCertificate(certFile, keyFile))
+	require.NoError(t, err)
+	assert.False(t, tr1 == tr3)
+}
+
+func TestNewHTTP2Modes(t *testing.T) {
+	tr, err := New(WithHTTP2(HTTP2H2C), WithServerName("modes.local"))
+	require.NoError(t, err)
+	h2c, ok := tr.(*h2cTransport)
+	require.True(t, ok)
+	assert.True(t, h2c.base.(*http2.Transport).AllowHTTP)
+
+	tr, err = New(WithHTTP2(HTTP2Off), WithServerName("modes.local"))
+	require.NoError(t, err)
+	require.IsType(t, &http.Transport{}, tr)
+	assert.NotNil(t, tr.(*http.Transport).TLSNextProto)
+	assert.Empty(t, tr.(*http.Transport).TLSNextProto)
+
+	tr, err = New(WithServerName("modes.local"))
+	require.NoError(t, err)
+	assert.IsType(t, &http.Transport{}, tr)
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transport")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	empty := filepath.Join(dir, "empty.pem")
+	require.NoError(t, ioutil.WriteFile(empty, []byte("not a certificate"), 0600))
+
+	tests := []struct {
+		scenario string
+		opts     []Option
+	}{
+		{
+			scenario: "unsupported HTTP/2 mode",
+			opts:     []Option{WithHTTP2("h3")},
+		},
+		{
+			scenario: "unsupported TLS version",
+			opts:     []Option{WithMinTLSVersion("0.9")},
+		},
+		{
+			scenario: "missing client certificate",
+			opts:     []Option{WithClientCertificate(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))},
+		},
+		{
+			scenario: "missing CA bundle",
+			opts:     []Option{WithCAFile(filepath.Join(dir, "ca.pem"))},
+		},
+		{
+			scenario: "CA bundle without certificates",
+			opts:     []Option{WithCAFile(empty)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			_, err := New(tt.opts...)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transport")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clientCert, clientKey := writeCertificate(t, dir, "client")
+	clientPair, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+	clientCA := x509.NewCertPool()
+	clientCA.AddCert(parseCertificate(t, clientPair))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCA}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+
+	tr, err := New(
+		WithClientCertificate(clientCert, clientKey),
+		WithCAFile(caFile),
+		WithServerName("example.com"),
+		WithMinTLSVersion("1.2"),
+	)
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: tr}).Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "client", string(body))
+
+	// without the client certificate the server refuses the connection
+	tr, err = New(WithCAFile(caFile), WithServerName("example.com"))
+	require.NoError(t, err)
+	_, err = (&http.Client{Transport: tr}).Get(server.URL)
+	assert.Error(t, err)
+}
+
+func writeCertificate(t *testing.T, dir string, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+"-key.pem")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return certFile, keyFile
+}
+
+func parseCertificate(t *testing.T, pair tls.Certificate) *x509.Certificate {
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	require.NoError(t, err)
+
+	return cert
+}
@@ -0,0 +1,47 @@
+// Package request provides helpers to copy the incoming requests, for the plugins sending them more than once or
+// keeping them after they are served
+package request
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// readCloser replaces the request body with the buffered one, keeping the original closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// BufferBody reads the request body up to the limit, so it can be sent more than once. If the body is bigger
+// than the limit, or can not be read, the request keeps its whole body and false is returned.
+func BufferBody(r *http.Request, limit int64) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+
+	if r.ContentLength > limit {
+		return nil, false
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil || int64(len(body)) > limit {
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, false
+	}
+
+	r.Body = readCloser{bytes.NewReader(body), r.Body}
+	return body, true
+}
+
+// CloneHeader returns a deep copy of the header
+func CloneHeader(h http.Header) http.Header {
+	clone := make(http.Header, len(h))
+	for k, v := range h {
+		clone[k] = append([]string(nil), v...)
+	}
+
+	return clone
+}
@@ -0,0 +1,54 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferBody(t *testing.T) {
+	tests := []struct {
+		scenario      string
+		body          string
+		contentLength int64
+		buffered      bool
+	}{
+		{scenario: "when the body fits the limit", body: "hello", contentLength: 5, buffered: true},
+		{scenario: "when the content length is over the limit", body: "hello world", contentLength: 11, buffered: false},
+		{scenario: "when the body is bigger than the limit without content length", body: "hello world", contentLength: -1, buffered: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			r.ContentLength = test.contentLength
+
+			body, ok := BufferBody(r, 8)
+			assert.Equal(t, test.buffered, ok)
+			if test.buffered {
+				assert.Equal(t, test.body, string(body))
+			}
+
+			sent, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, test.body, string(sent), "the request keeps its whole body")
+		})
+	}
+
+	_, ok := BufferBody(httptest.NewRequest(http.MethodGet, "/", nil), 8)
+	assert.True(t, ok)
+}
+
+func TestCloneHeader(t *testing.T) {
+	h := http.Header{"Accept": {"text/plain"}}
+	clone := CloneHeader(h)
+	clone.Add("Accept", "application/json")
+	clone.Set("X-Other", "1")
+
+	assert.Equal(t, http.Header{"Accept": {"text/plain"}}, h)
+}
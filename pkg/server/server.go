@@ -173,6 +173,7 @@ func (s *Server) startProvider(ctx context.Context) error {
 		web.WithTLS(s.globalConfig.Web.TLS),
 		web.WithCredentials(s.globalConfig.Web.Credentials),
 		web.WithProfiler(s.profilingEnabled, s.profilingPublic),
+		web.WithHealthCheckers(s.register.HealthCheckers()),
 	)
 
 	if err := s.webServer.Start(); err != nil {
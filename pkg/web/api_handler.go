@@ -74,12 +74,21 @@ func (c *APIHandler) PutBy() http.HandlerFunc {
 			return
 		}
 
+		// the current definition is served while the update is decoded and validated, the update is a new
+		// definition that replaces it once it is valid
+		current := cfg
+		cfg = api.NewDefinition()
 		err = json.NewDecoder(r.Body).Decode(cfg)
 		if err != nil {
 			errors.Handler(w, err)
 			return
 		}
 
+		// upstream groups sent without targets keep their current ones, so only their weights are updated
+		if current.Proxy != nil && current.Proxy.Upstreams != nil {
+			cfg.Proxy.KeepGroupTargets(current.Proxy.Upstreams.Groups)
+		}
+
 		isValid, err := cfg.Validate()
 		if false == isValid && err != nil {
 			errors.Handler(w, errors.New(http.StatusBadRequest, err.Error()))
@@ -0,0 +1,73 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hellofresh/janus/pkg/api"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIHandlerPutByKeepsGroupTargets(t *testing.T) {
+	definition := api.NewDefinition()
+	definition.Name = "example"
+	definition.Proxy.ListenPath = "/example/*"
+	definition.Proxy.Upstreams = &proxy.Upstreams{
+		Balancing: "roundrobin",
+		Groups: []*proxy.UpstreamGroup{
+			{Name: "stable", Weight: 100, Targets: proxy.Targets{{Target: "http://stable.com"}}},
+			{Name: "canary", Weight: 0, Targets: proxy.Targets{{Target: "http://canary.com"}}},
+		},
+	}
+
+	cfgChan := make(chan api.ConfigurationMessage, 1)
+	handler := NewAPIHandler(cfgChan)
+	handler.Cfgs = &api.Configuration{Definitions: []*api.Definition{definition}}
+
+	r := router.NewChiRouter()
+	r.PUT("/apis/{name}", handler.PutBy())
+
+	// the groups are sent in another order and only with their weights
+	body := `{"name": "example", "proxy": {"listen_path": "/example/*", "upstreams": {"balancing": "roundrobin", "groups": [
+		{"name": "canary", "weight": 20},
+		{"name": "stable", "weight": 80}
+	]}}}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/apis/example", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	updated := (<-cfgChan).Configuration
+	groups := updated.Proxy.Upstreams.Groups
+	require.Len(t, groups, 2)
+	assert.Equal(t, "canary", groups[0].Name)
+	assert.Equal(t, 20, groups[0].Weight)
+	assert.Equal(t, "http://canary.com", groups[0].Targets[0].Target)
+	assert.Equal(t, "stable", groups[1].Name)
+	assert.Equal(t, 80, groups[1].Weight)
+	assert.Equal(t, "http://stable.com", groups[1].Targets[0].Target)
+
+	// the served definition is only replaced by the configuration update
+	assert.Equal(t, "stable", definition.Proxy.Upstreams.Groups[0].Name)
+	assert.Equal(t, 100, definition.Proxy.Upstreams.Groups[0].Weight)
+
+	body = `{"name": "example", "proxy": {"listen_path": "/example/*", "upstreams": {"balancing": "roundrobin", "groups": [
+		{"name": "canary", "weight": 200}
+	]}}}`
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/apis/example", strings.NewReader(body)))
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Len(t, definition.Proxy.Upstreams.Groups, 2, "an invalid update does not change the served definition")
+	assert.Equal(t, 0, definition.Proxy.Upstreams.Groups[1].Weight)
+
+	// an update without groups keeps them
+	body = `{"name": "example", "proxy": {"listen_path": "/example/*", "strip_path": true}}`
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/apis/example", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, (<-cfgChan).Configuration.Proxy.Upstreams.Groups, 2)
+}
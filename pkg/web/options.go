@@ -3,6 +3,7 @@ package web
 import (
 	"github.com/hellofresh/janus/pkg/api"
 	"github.com/hellofresh/janus/pkg/config"
+	"github.com/hellofresh/janus/pkg/proxy/health"
 )
 
 // Option represents the available options
@@ -43,3 +44,10 @@ func WithProfiler(enabled, public bool) Option {
 		s.profilingPublic = public
 	}
 }
+
+// WithHealthCheckers sets the registry of the upstream health checkers
+func WithHealthCheckers(checkers *health.Registry) Option {
+	return func(s *Server) {
+		s.healthCheckers = checkers
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/hellofresh/janus/pkg/middleware"
 	obs "github.com/hellofresh/janus/pkg/observability"
 	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy/health"
 	"github.com/hellofresh/janus/pkg/router"
 	"github.com/rs/cors"
 	log "github.com/sirupsen/logrus"
@@ -25,6 +26,7 @@ type Server struct {
 	TLS               config.TLS
 	ConfigurationChan chan api.ConfigurationMessage
 	apiHandler        *APIHandler
+	healthCheckers    *health.Registry
 	profilingEnabled  bool
 	profilingPublic   bool
 }
@@ -114,6 +116,7 @@ func (s *Server) addInternalRoutes(r router.Router, guard jwt.Guard) {
 		groupAPI.POST("/", s.apiHandler.Post())
 		groupAPI.PUT("/{name}", s.apiHandler.PutBy())
 		groupAPI.DELETE("/{name}", s.apiHandler.DeleteBy())
+		groupAPI.GET("/{name}/health", NewUpstreamHealthHandler(s.healthCheckers))
 	}
 
 	if s.profilingEnabled {
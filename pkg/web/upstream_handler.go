@@ -0,0 +1,41 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/proxy/health"
+	"github.com/hellofresh/janus/pkg/render"
+	"github.com/hellofresh/janus/pkg/router"
+)
+
+// ErrHealthCheckNotFound is used when the API has neither active nor passive health checking configured
+var ErrHealthCheckNotFound = errors.New(http.StatusNotFound, "health checking is not enabled for this api")
+
+// NewUpstreamHealthHandler creates instance of the upstream targets health state handler
+func NewUpstreamHealthHandler(checkers *health.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if checkers == nil {
+			errors.Handler(w, ErrHealthCheckNotFound)
+			return
+		}
+
+		name := router.URLParam(r, "name")
+		resp := render.M{}
+
+		if checker, ok := checkers.Get(name); ok {
+			resp["active"] = checker.Statuses()
+		}
+
+		if detector, ok := checkers.GetOutlierDetector(name); ok {
+			resp["passive"] = detector.Statuses()
+		}
+
+		if len(resp) == 0 {
+			errors.Handler(w, ErrHealthCheckNotFound)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, resp)
+	}
+}